@@ -0,0 +1,124 @@
+// Sokoban game
+//
+// Assignment problem: the cheapest way to give each of n rows its own
+// column among m >= n, solved with the Hungarian method in O(n²m). Boxes
+// are assigned to goals with it, for their labels and for the lower bound
+// of the solver (see solverBoard.lowerBound).
+
+package main
+
+// matcher keeps the scratch space of the method between calls
+type matcher struct {
+	cost       []int32 // row after row, see solve
+	u, v, minv []int32
+	p, way     []int
+	used       []bool
+}
+
+// grow makes room for n rows and m columns
+func (a *matcher) grow(n int, m int) {
+
+	if len(a.u) < n+1 {
+		a.u = make([]int32, n+1)
+	}
+	if len(a.v) < m+1 {
+		a.v = make([]int32, m+1)
+		a.minv = make([]int32, m+1)
+		a.p = make([]int, m+1)
+		a.way = make([]int, m+1)
+		a.used = make([]bool, m+1)
+	}
+}
+
+// costs returns room for the costs of n rows and m columns, that of row i
+// and column j at i*m+j, for solve
+func (a *matcher) costs(n int, m int) []int32 {
+
+	if cap(a.cost) < n*m {
+		a.cost = make([]int32, n*m)
+	}
+	a.cost = a.cost[:n*m]
+	return a.cost
+}
+
+// solve returns the smallest sum of the costs set by costs over the rows,
+// each having its own column; costs of unreachable or more stand for no
+// edge, the result being unreachable or more when they cannot be avoided
+func (a *matcher) solve(n int, m int) int32 {
+
+	const inf = 1 << 30
+
+	a.grow(n, m)
+	u, v, minv, p, way, used := a.u[:n+1], a.v[:m+1], a.minv[:m+1], a.p[:m+1], a.way[:m+1], a.used[:m+1]
+	for i := range u {
+		u[i] = 0
+	}
+	for j := range v {
+		v[j], p[j], way[j] = 0, 0, 0
+	}
+
+	// rows and columns count from 1, column 0 holding the row being placed
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		for j := range minv {
+			minv[j], used[j] = inf, false
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := p[j0], int32(inf), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				c := a.cost[(i0-1)*m+j-1]
+				if c > unreachable {
+					c = unreachable
+				}
+				if cur := c - u[i0] - v[j]; cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	var total int32
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			total += a.cost[(p[j]-1)*m+j-1]
+		}
+	}
+	return total
+}
+
+// columns returns the column of each of the n rows of the last solve
+func (a *matcher) columns(n int) []int {
+
+	cols := make([]int, n)
+	for j := 1; j < len(a.p); j++ {
+		if a.p[j] > 0 && a.p[j] <= n {
+			cols[a.p[j]-1] = j - 1
+		}
+	}
+	return cols
+}
@@ -0,0 +1,92 @@
+// Sokoban game
+//
+// Where each tile is in the tile sheet. The rectangles are in pixels of the
+// 64 pixel sheet, and are scaled for the sheets of other sizes.
+//
+// A sheet with another layout can come with sokoban_tilesheet.json, giving
+// the rectangle of each tile by name as [x, y, width, height]:
+//
+//	{"floor": [704, 384, 64, 64], "wall": [448, 448, 64, 64], ...}
+//
+// The decorations scattered around levels, decoration_1 to decoration_3,
+// are left out when their rectangle is empty.
+
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"log"
+)
+
+// names of the tiles in an atlas file
+var tileNames = map[string]int{
+	"floor":        EMPTY,
+	"wall":         WALL,
+	"box":          BOX,
+	"box_on_goal":  PLACED_BOX,
+	"goal":         GOAL,
+	"player_up":    PLAYERUP,
+	"player_down":  PLAYERDN,
+	"player_right": PLAYERRI,
+	"player_left":  PLAYERLE,
+	"background":   BACKGROUND,
+	"decoration_1": DECORATION1,
+	"decoration_2": DECORATION2,
+	"decoration_3": DECORATION3,
+}
+
+// layout of sokoban_tilesheet.png
+var tileAtlas = loadTileAtlas(map[int]image.Rectangle{
+	EMPTY:      image.Rect(704, 384, 768, 448),
+	WALL:       image.Rect(448, 448, 512, 512),
+	BOX:        image.Rect(384, 0, 448, 64),
+	PLACED_BOX: image.Rect(576, 0, 640, 64),
+	GOAL:       image.Rect(704, 448, 768, 512),
+	PLAYERUP:   image.Rect(192, 256, 256, 320),
+	PLAYERDN:   image.Rect(0, 256, 64, 320),
+	PLAYERRI:   image.Rect(0, 384, 64, 448),
+	PLAYERLE:   image.Rect(192, 384, 256, 448),
+
+	BACKGROUND:  image.Rect(640, 384, 704, 448),
+	DECORATION1: image.Rect(384, 256, 448, 320),
+	DECORATION2: image.Rect(512, 256, 576, 320),
+	DECORATION3: image.Rect(640, 256, 704, 320),
+})
+
+// loadTileAtlas returns the layout of sokoban_tilesheet.json when there is
+// one, otherwise defaults; tiles missing from the file keep their default
+func loadTileAtlas(defaults map[int]image.Rectangle) map[int]image.Rectangle {
+
+	data, err := tileSheetFiles.ReadFile("sokoban_tilesheet.json")
+	if err != nil {
+		return defaults
+	}
+
+	var rects map[string][4]int
+	if err := json.Unmarshal(data, &rects); err != nil {
+		log.Fatalf("sokoban_tilesheet.json: %v", err)
+	}
+
+	atlas := make(map[int]image.Rectangle)
+	for t, r := range defaults {
+		atlas[t] = r
+	}
+	for name, r := range rects {
+		t, ok := tileNames[name]
+		if !ok {
+			log.Fatalf("sokoban_tilesheet.json: unknown tile %q", name)
+		}
+		atlas[t] = image.Rect(r[0], r[1], r[0]+r[2], r[1]+r[3])
+	}
+
+	return atlas
+}
+
+// tileRect returns the rectangle of tile t in a sheet of tiles of size pixels
+func tileRect(t int, size int) image.Rectangle {
+
+	r := tileAtlas[t]
+	return image.Rect(r.Min.X*size/baseTileSize, r.Min.Y*size/baseTileSize,
+		r.Max.X*size/baseTileSize, r.Max.Y*size/baseTileSize)
+}
@@ -0,0 +1,195 @@
+// Sokoban game
+//
+// Audio cues, so that the game can be played without seeing the board
+//
+// F3 toggles the cues: a tick for each step, panned with the player's
+// position, a bump whose pitch tells the direction of the wall, a thud for
+// a push, a chime for a box placed on a goal followed by one beep per box
+// left, and a fanfare when the level is solved.
+//
+// F4 toggles the announcer, which describes what surrounds the player after
+// each move, on screen and on the standard output for screen readers.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+const audioSampleRate = 44100
+
+// a tone of a cue, silent when freq is 0
+type toneSpec struct {
+	freq     float64
+	duration float64 // seconds
+	volume   float64
+}
+
+var (
+	audioCuesOn  = false
+	announcerOn  = false
+	cueVolume    = 1.0
+	audioContext *audio.Context
+
+	// what the last move should sound like, played by the next update
+	pendingCue      []toneSpec
+	pendingCuePan   float64 // -1 left to 1 right
+	pendingAnnounce bool
+)
+
+// bump pitches, the higher the further up
+var bumpFreq = map[byte]float64{
+	UP:    660,
+	RIGHT: 440,
+	LEFT:  440,
+	DOWN:  220,
+}
+
+func updateAudioCues(s *GameState, in InputSource) {
+
+	if in.IsKeyJustPressed(ebiten.KeyF3) {
+		audioCuesOn = !audioCuesOn
+		showMessage(map[bool]string{true: "Audio cues on", false: "Audio cues off"}[audioCuesOn])
+		saveSettings()
+	}
+	if in.IsKeyJustPressed(ebiten.KeyF4) {
+		announcerOn = !announcerOn
+		showMessage(map[bool]string{true: "Announcer on", false: "Announcer off"}[announcerOn])
+		pendingAnnounce = announcerOn
+		saveSettings()
+	}
+
+	if audioCuesOn && pendingCue != nil {
+		playTones(pendingCue, pendingCuePan)
+	}
+	pendingCue = nil
+
+	if announcerOn && pendingAnnounce {
+		msg := describeSurroundings(s.curLev)
+		showMessage(msg)
+		fmt.Println(msg)
+	}
+	pendingAnnounce = false
+}
+
+// moveCue prepares the cue of a move of the player in direction d;
+// leftBefore is the number of boxes left before the move
+func moveCue(s *GameState, d byte, moved, pushed bool, leftBefore int) {
+
+	pendingAnnounce = true
+
+	pendingCuePan = 0
+	if s.curLev.w > 1 {
+		pendingCuePan = 2*float64(s.curLev.px)/float64(s.curLev.w-1) - 1
+	}
+
+	if !moved {
+		pan := map[byte]float64{LEFT: -1, RIGHT: 1}[d]
+		pendingCuePan = pan
+		pendingCue = []toneSpec{{bumpFreq[d], 0.08, 0.5}}
+		return
+	}
+
+	if !pushed {
+		pendingCue = []toneSpec{{1200, 0.015, 0.15}}
+		return
+	}
+
+	pendingCue = []toneSpec{{110, 0.06, 0.6}}
+
+	left := s.curLev.boxesLeft
+
+	switch {
+	case left == 0:
+		pendingCue = append(pendingCue, toneSpec{0, 0.05, 0},
+			toneSpec{523, 0.12, 0.5}, toneSpec{659, 0.12, 0.5}, toneSpec{784, 0.12, 0.5}, toneSpec{1047, 0.3, 0.5})
+	case left < leftBefore:
+		pendingCue = append(pendingCue, toneSpec{0, 0.05, 0}, toneSpec{880, 0.1, 0.4}, toneSpec{1320, 0.2, 0.4})
+		pendingCue = append(pendingCue, boxCountTones(left)...)
+	case left > leftBefore:
+		// a box pushed off its goal: the chime backwards
+		pendingCue = append(pendingCue, toneSpec{0, 0.05, 0}, toneSpec{1320, 0.1, 0.4}, toneSpec{880, 0.2, 0.4})
+		pendingCue = append(pendingCue, boxCountTones(left)...)
+	}
+}
+
+// deadlockCue buzzes when a box got stuck
+func deadlockCue() {
+
+	pendingCue = append(pendingCue, toneSpec{0, 0.05, 0}, toneSpec{90, 0.4, 0.6})
+}
+
+// boxCountTones beeps once per box left, a long beep standing for five
+func boxCountTones(n int) []toneSpec {
+
+	tones := []toneSpec{{0, 0.2, 0}}
+	for ; n >= 5; n -= 5 {
+		tones = append(tones, toneSpec{600, 0.3, 0.3}, toneSpec{0, 0.1, 0})
+	}
+	for ; n > 0; n-- {
+		tones = append(tones, toneSpec{600, 0.06, 0.3}, toneSpec{0, 0.1, 0})
+	}
+	return tones
+}
+
+// playTones synthesizes the tones as 16 bit stereo and plays them
+func playTones(tones []toneSpec, pan float64) {
+
+	if audioContext == nil {
+		audioContext = audio.NewContext(audioSampleRate)
+	}
+
+	// constant power panning
+	angle := (pan + 1) * math.Pi / 4
+	gainL, gainR := math.Cos(angle), math.Sin(angle)
+
+	var pcm []byte
+	for _, t := range tones {
+		n := int(t.duration * audioSampleRate)
+		for i := 0; i < n; i++ {
+			v := 0.0
+			if t.freq > 0 {
+				// short fades at both ends avoid clicks
+				env := math.Min(1, math.Min(float64(i), float64(n-i))/(0.005*audioSampleRate))
+				v = cueVolume * t.volume * env * math.Sin(2*math.Pi*t.freq*float64(i)/audioSampleRate)
+			}
+			pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(v*gainL*math.MaxInt16)))
+			pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(v*gainR*math.MaxInt16)))
+		}
+	}
+
+	audioContext.NewPlayerFromBytes(pcm).Play()
+}
+
+// describeSurroundings tells what is next to the player in each direction
+func describeSurroundings(l Level) string {
+
+	names := map[byte]string{WALL: "wall", BOX: "box", PLACED_BOX: "box on goal", GOAL: "goal"}
+
+	var parts []string
+	for _, d := range directions {
+		dx, dy := dirDelta(d)
+		x, y := l.px+dx, l.py+dy
+		what := "floor"
+		if x >= 0 && y >= 0 && x < l.w && y < l.h {
+			if n, ok := names[l.grid[x][y]]; ok {
+				what = n
+			}
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", directionName(d), what))
+	}
+
+	left := l.boxesLeft
+	return fmt.Sprintf("%s; %d box%s left", strings.Join(parts, ", "), left, map[bool]string{true: "", false: "es"}[left == 1])
+}
+
+func directionName(d byte) string {
+
+	return map[byte]string{UP: "up", RIGHT: "right", DOWN: "down", LEFT: "left"}[d]
+}
@@ -0,0 +1,119 @@
+// Sokoban game
+//
+// Auto-finish: when every remaining box can be pushed in a straight line
+// onto a goal, one box after the other, F performs those pushes
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	// moves finishing the level, nil when it cannot be finished trivially
+	autoFinish    []byte
+	autoFinishKey string
+)
+
+// findAutoFinish returns the moves finishing l by straight pushes, or nil
+func findAutoFinish(l Level) []byte {
+
+	b, boxes, player := newSolverBoard(l, nil)
+
+	occupied := make([]bool, b.w*b.h)
+	for _, c := range boxes {
+		occupied[c] = true
+	}
+
+	var moves []byte
+
+	for {
+		done, pushed := true, false
+
+		for i := 0; i < len(boxes) && !pushed; i++ {
+
+			c := int(boxes[i])
+			if b.goal[c] {
+				continue
+			}
+			done = false
+
+			for _, d := range directions {
+
+				behind, _, ok := b.canPush(c, d, occupied)
+				if !ok {
+					continue
+				}
+
+				walk := b.path(player, behind, occupied)
+				if walk == nil && player != behind {
+					continue
+				}
+
+				// the line up to the first goal has to be clear
+				to, n := c, 0
+				for to >= 0 && !(n > 0 && b.goal[to]) {
+					next := b.step(to, d)
+					if !b.floor(next) || occupied[next] {
+						next = -1
+					}
+					to, n = next, n+1
+				}
+				if to < 0 {
+					continue
+				}
+
+				moves = append(moves, walk...)
+				for k := 0; k < n; k++ {
+					moves = append(moves, d)
+				}
+
+				occupied[c], occupied[to] = false, true
+				boxes[i] = int32(to)
+				player = b.step(to, oppositeDir(d))
+				pushed = true
+				break
+			}
+		}
+
+		if done {
+			return moves
+		}
+		if !pushed {
+			return nil
+		}
+	}
+}
+
+// updateAutoFinish refreshes the offer when the position changes and starts
+// the auto-finish on F; it returns true when it took over the input
+func updateAutoFinish(s *GameState, in InputSource) bool {
+
+	key := fmt.Sprint(s.currentLevelNumber, s.moveCount(), s.curLev.px, s.curLev.py)
+
+	if key != autoFinishKey {
+		autoFinishKey = key
+		autoFinish = nil
+		if s.nBoxesLeft() > 0 && !reverseMode {
+			autoFinish = findAutoFinish(s.curLev)
+		}
+	}
+
+	if autoFinish != nil && in.IsKeyJustPressed(ebiten.KeyF) {
+		playback = autoFinish
+		playbackTick = 0
+		autoFinish = nil
+		return true
+	}
+
+	return false
+}
+
+func drawAutoFinishOffer(screen *ebiten.Image) {
+
+	if autoFinish != nil && len(playback) == 0 && solverRun == nil {
+		drawText(screen, "every box can go straight to a goal: press F to finish", 20, 60)
+	}
+}
@@ -0,0 +1,176 @@
+// Sokoban game
+//
+// Auto-solve: S runs the solver (or looks up its cache) in the background on
+// the current level, showing its progress until it is done or cancelled,
+// then plays the solution back. Any input stops the playback.
+//
+// Shift+S solves from the current position instead of the start of the
+// level: the continuation is played on top of the moves already made, or
+// the player learns that the position cannot be won anymore.
+//
+// Enter during the playback turns it into a tutorial, see tutorialSteps.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sync/atomic"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// number of updates between two moves of a solution played back
+const playbackTicks = 8
+
+var (
+	cancelScreenZone = screenZone{20, 10, 10, 7}
+
+	// non nil while the solver runs in the background
+	solverRun      *solverProgress
+	solverStart    time.Time
+	solverResults  chan solverResult
+	solverFromHere bool
+
+	// remaining moves of the solution being played back
+	playback     []byte
+	playbackTick int
+)
+
+func playbackPackChanged(e GameEvent) {
+
+	playback = nil
+}
+
+func startSolver(s *GameState, fromHere bool) {
+
+	// nothing to solve from a solved position
+	if fromHere && s.nBoxesLeft() == 0 {
+		return
+	}
+
+	solverRun = &solverProgress{}
+	solverStart = time.Now()
+	solverResults = make(chan solverResult, 1)
+	solverFromHere = fromHere
+
+	l := levelTemplate(s.currentLevelNumber)
+	if fromHere {
+		l = s.curLev.clone()
+	}
+
+	go func(p *solverProgress) {
+		solverResults <- solveLevelCached(l, solverModeSetting, p)
+	}(solverRun)
+}
+
+// updateSolver handles the solver and the playback of its solution; it
+// returns true when they take over the input for this update
+func updateSolver(s *GameState, in InputSource, mouseOrTouch bool, eventX int, eventY int) bool {
+
+	if solverRun != nil {
+
+		if in.IsKeyJustPressed(ebiten.KeyEscape) || (mouseOrTouch && inScreenZone(cancelScreenZone, eventX, eventY)) {
+			solverRun.cancel()
+		}
+
+		select {
+		case res := <-solverResults:
+			cancelled := solverRun.isCancelled()
+			solverRun = nil
+
+			if res.solved {
+				if !solverFromHere {
+					s.loadLevel(s.currentLevelNumber)
+				}
+				playback = res.moves
+				playbackTick = 0
+				s.assisted = true
+				showMessage(fmt.Sprintf("solution: %d moves, %d pushes, Enter to step through it", len(res.moves), res.pushes))
+			} else if cancelled {
+				showMessage("solver cancelled")
+			} else if solverFromHere {
+				showMessage("this position cannot be won anymore, undo some moves")
+			} else {
+				showMessage("no solution found")
+			}
+		default:
+		}
+
+		return true
+	}
+
+	if len(playback) == 0 {
+		endReplayNotes()
+	}
+
+	if len(playback) > 0 {
+
+		if in.IsKeyJustPressed(ebiten.KeyEnter) {
+			startTutorial(s, playback)
+			playback = nil
+			stopReplayNotes()
+			return true
+		}
+
+		if mouseOrTouch || len(in.AppendJustPressedKeys(nil)) > 0 {
+			playback = nil
+			stopReplayNotes()
+			return true
+		}
+
+		if holdReplayNote() {
+			return true
+		}
+
+		playbackTick++
+		if playbackTick >= playbackTicks {
+			playbackTick = 0
+			s.playMove(playback[0])
+			playback = playback[1:]
+			replayNoteMoved()
+		}
+
+		return true
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyS) {
+		if reverseMode {
+			reverseRefused()
+			return true
+		}
+		if in.IsKeyPressed(ebiten.KeyShift) {
+			startSolver(s, true)
+		} else {
+			confirmDiscard(s, "Solve the level from the start?", func() { startSolver(s, false) })
+		}
+		return true
+	}
+
+	return false
+}
+
+func drawSolverOverlay(screen *ebiten.Image, s *GameState, sh *spriteSheets) {
+
+	if solverRun != nil {
+
+		ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{0, 0, 0, 128})
+		ebitenutil.DrawRect(screen, 700, 350, 500, 370, color.RGBA{40, 40, 60, 230})
+
+		from := "start"
+		if solverFromHere {
+			from = "current position"
+		}
+
+		msg := fmt.Sprintf("Solving level %d from the %s (fewest %s)\n\nnodes explored: %d\ndepth:          %d %s\nelapsed:        %s\n\nEsc or X to cancel",
+			s.currentLevelNumber, from, solverModeSetting,
+			atomic.LoadInt64(&solverRun.nodes), atomic.LoadInt64(&solverRun.depth), solverModeSetting,
+			time.Since(solverStart).Round(time.Second/10))
+
+		drawText(screen, msg, 720, 370)
+
+		sh.drawIcon(screen, 92, cancelScreenZone, 0, 0)
+	}
+}
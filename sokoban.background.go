@@ -0,0 +1,158 @@
+// Sokoban game
+//
+// Background: the screen around the level, and the floor outside its
+// walls, is covered with the dimmed background tile of the tile sheet, on
+// the same grid as the board. A few decorations from the sheet are
+// scattered over it, always at the same places for a level; they can be
+// turned off in the settings.
+
+package main
+
+import (
+	"hash/fnv"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// tiles of the background layer, after those of the board
+const (
+	BACKGROUND = PLAYERLE + 1 + iota
+	DECORATION1
+	DECORATION2
+	DECORATION3
+)
+
+// one cell of the background in decorationOdds has a decoration
+const decorationOdds = 17
+
+var showDecorations = true
+
+type backgroundKey struct {
+	level, view     int
+	sx, sy, factor  float64
+	withDecorations bool
+}
+
+var (
+	backgroundImage *ebiten.Image
+	backgroundFor   backgroundKey
+)
+
+func backgroundPackChanged(e GameEvent) {
+
+	backgroundFor = backgroundKey{}
+}
+
+// outsideCells marks the floor of l the player could never reach, which
+// lies outside its walls
+func outsideCells(l Level) [][]bool {
+
+	w, h := l.w, l.h
+
+	inside := make([][]bool, w)
+	outside := make([][]bool, w)
+	for x := range inside {
+		inside[x] = make([]bool, h)
+		outside[x] = make([]bool, h)
+	}
+
+	stack := [][2]int{{l.px, l.py}}
+	inside[l.px][l.py] = true
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			x, y := c[0]+d[0], c[1]+d[1]
+			if x < 0 || x >= w || y < 0 || y >= h || inside[x][y] || l.grid[x][y] == WALL {
+				continue
+			}
+			inside[x][y] = true
+			stack = append(stack, [2]int{x, y})
+		}
+	}
+
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			outside[x][y] = !inside[x][y] && l.grid[x][y] == EMPTY
+		}
+	}
+	return outside
+}
+
+// decorationAt returns the decoration of background cell x, y of level n,
+// or -1
+func decorationAt(n int, x int, y int, tiles []int) int {
+
+	if len(tiles) == 0 {
+		return -1
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte{byte(n), byte(x), byte(x >> 8), byte(y), byte(y >> 8)})
+	v := h.Sum32()
+
+	if v%decorationOdds != 0 {
+		return -1
+	}
+	return tiles[(v/decorationOdds)%uint32(len(tiles))]
+}
+
+func decorationTiles() []int {
+
+	var tiles []int
+	for _, t := range []int{DECORATION1, DECORATION2, DECORATION3} {
+		if r, ok := tileAtlas[t]; ok && !r.Empty() {
+			tiles = append(tiles, t)
+		}
+	}
+	return tiles
+}
+
+// drawBackground draws the background layer, redrawn only when the level
+// or the camera changed
+func drawBackground(screen *ebiten.Image, s *GameState, sh *spriteSheets) {
+
+	sx, sy, factor := boardView(s)
+	key := backgroundKey{s.currentLevelNumber, camera.view, sx, sy, factor, showDecorations}
+
+	if backgroundImage == nil {
+		backgroundImage = ebiten.NewImage(screenWidth, screenHeight)
+	}
+
+	if key != backgroundFor {
+		backgroundFor = key
+		backgroundImage.Fill(color.Black)
+
+		var tiles []int
+		if showDecorations {
+			tiles = decorationTiles()
+		}
+		outside := outsideCells(s.curLev)
+
+		tile := baseTileSize * factor
+		x0, x1 := int(math.Floor(-sx/tile)), int(math.Ceil((screenWidth-sx)/tile))
+		y0, y1 := int(math.Floor(-sy/tile)), int(math.Ceil((screenHeight-sy)/tile))
+
+		w, h := viewSize(s.curLev, camera.view)
+		for x := x0; x < x1; x++ {
+			for y := y0; y < y1; y++ {
+				if x >= 0 && y >= 0 && x < w && y < h {
+					if cx, cy := viewToCell(s.curLev, camera.view, x, y); !outside[cx][cy] {
+						continue
+					}
+				}
+				sh.drawSprite(backgroundImage, x, y, BACKGROUND, sx, sy, factor, 64.0, 64.0)
+				if d := decorationAt(s.currentLevelNumber, x, y, tiles); d >= 0 {
+					sh.drawSprite(backgroundImage, x, y, d, sx, sy, factor, 64.0, 64.0)
+				}
+			}
+		}
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	r, g, b := zenTint()
+	op.ColorM.Scale(r, g, b, 1)
+	screen.DrawImage(backgroundImage, op)
+}
@@ -0,0 +1,104 @@
+// Sokoban game
+//
+// Solver benchmark: run with -bench
+//
+// Solves a fixed subset of the embedded levels in both solver modes and
+// reports the search speed, the memory held per node kept by the search,
+// boxes, links and transposition table included, and the solution lengths.
+// A solution length that differs from the recorded one is flagged, so
+// solver changes can be measured and checked over time.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+type benchCase struct {
+	level int
+	mode  solverMode
+	// expected optimal length in the mode's metric, 0 to only measure speed
+	length   int
+	maxNodes int
+}
+
+var benchCases = []benchCase{
+	{0, optimizePushes, 3, 10000},
+	{1, optimizePushes, 4, 10000},
+	{2, optimizePushes, 5, 10000},
+	{0, optimizeMoves, 4, 10000},
+	{1, optimizeMoves, 10, 10000},
+	{2, optimizeMoves, 26, 10000},
+	// mid-difficulty levels, each solved within a second or two
+	{3, optimizePushes, 116, 100000},
+	{42, optimizePushes, 81, 300000},
+	{9, optimizePushes, 0, 100000},
+	{3, optimizeMoves, 0, 100000},
+}
+
+func runSolverBenchmark() bool {
+
+	ok := true
+
+	var totalNodes, totalStored int
+	var totalTime time.Duration
+	var totalMemory uint64
+
+	solverMeasureMemory = true
+	defer func() { solverMeasureMemory = false }()
+
+	fmt.Printf("solver workers: %d\n", solverWorkers)
+	fmt.Printf("%5s %6s %7s %7s %10s %10s %12s %10s\n", "level", "mode", "moves", "pushes", "nodes", "time", "nodes/s", "bytes/node")
+
+	for _, c := range benchCases {
+
+		res := solveLevel(levelTemplate(c.level), c.mode, c.maxNodes, nil)
+
+		length := res.pushes
+		if c.mode == optimizeMoves {
+			length = len(res.moves)
+		}
+
+		totalNodes += res.stats.nodes
+		totalTime += res.stats.elapsed
+		totalStored += res.stats.stored
+		totalMemory += res.stats.memory
+
+		status := ""
+		if c.length == 0 {
+			status = "  (node budget)"
+		} else if !res.solved {
+			status = "  NOT SOLVED"
+			ok = false
+		} else if length != c.length {
+			status = fmt.Sprintf("  REGRESSION (expected %d %s)", c.length, c.mode)
+			ok = false
+		}
+
+		fmt.Printf("%5d %6s %7d %7d %10d %10s %12.0f %10.0f%s\n", c.level, c.mode, len(res.moves), res.pushes,
+			res.stats.nodes, res.stats.elapsed.Round(time.Microsecond), nodesPerSecond(res.stats),
+			bytesPerNode(res.stats.memory, res.stats.stored), status)
+	}
+
+	fmt.Printf("total %33d %10s %12.0f %10.0f\n", totalNodes, totalTime.Round(time.Microsecond),
+		nodesPerSecond(solverStats{nodes: totalNodes, elapsed: totalTime}), bytesPerNode(totalMemory, totalStored))
+
+	return ok
+}
+
+func bytesPerNode(memory uint64, stored int) float64 {
+
+	if stored == 0 {
+		return 0
+	}
+	return float64(memory) / float64(stored)
+}
+
+func nodesPerSecond(s solverStats) float64 {
+
+	if s.elapsed <= 0 {
+		return 0
+	}
+	return float64(s.nodes) / s.elapsed.Seconds()
+}
@@ -0,0 +1,242 @@
+// Sokoban game
+//
+// Bitboards: a set of cells of a board packed one bit per cell, the cells
+// indexed by y*w+x like those of the solver. A level is its walls, goals and
+// boxes as three bitboards and the cell of the player (see levelBits).
+//
+// The solver keeps the boxes of each of its positions as a bitboard, with a
+// bit for each cell a box may stand on rather than for every cell (see
+// nodeBoxes), and finds the cells the player can walk to by growing the set
+// of its cell a step at a time, a word of cells at once (see reachBits).
+
+package main
+
+import "math/bits"
+
+// a set of cells, cell c being bit c%64 of word c/64
+type bitboard []uint64
+
+func newBitboard(cells int) bitboard {
+
+	return make(bitboard, (cells+63)/64)
+}
+
+func (s bitboard) has(c int) bool {
+	return s[c>>6]&(1<<uint(c&63)) != 0
+}
+
+func (s bitboard) set(c int) {
+	s[c>>6] |= 1 << uint(c&63)
+}
+
+func (s bitboard) unset(c int) {
+	s[c>>6] &^= 1 << uint(c&63)
+}
+
+func (s bitboard) clear() {
+
+	for i := range s {
+		s[i] = 0
+	}
+}
+
+func (s bitboard) count() int {
+
+	n := 0
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// first returns the smallest cell of s, or -1 when s is empty
+func (s bitboard) first() int {
+
+	for i, w := range s {
+		if w != 0 {
+			return i<<6 + bits.TrailingZeros64(w)
+		}
+	}
+	return -1
+}
+
+// appendCells appends the cells of s to out, smallest first
+func (s bitboard) appendCells(out []int32) []int32 {
+
+	for i, w := range s {
+		for w != 0 {
+			out = append(out, int32(i<<6+bits.TrailingZeros64(w)))
+			w &= w - 1
+		}
+	}
+	return out
+}
+
+// shiftedWord returns word i of the cells of s moved k cells up the board,
+// toward the larger cells, or down when k is negative
+func (s bitboard) shiftedWord(i int, k int) uint64 {
+
+	if k < 0 {
+		j, off := i+(-k)>>6, uint(-k&63)
+		if j >= len(s) {
+			return 0
+		}
+		w := s[j] >> off
+		if off > 0 && j+1 < len(s) {
+			w |= s[j+1] << (64 - off)
+		}
+		return w
+	}
+
+	j, off := i-k>>6, uint(k&63)
+	if j < 0 {
+		return 0
+	}
+	w := s[j] << off
+	if off > 0 && j > 0 {
+		w |= s[j-1] >> (64 - off)
+	}
+	return w
+}
+
+// levelBits is a level as bitboards
+type levelBits struct {
+	w, h                int
+	walls, goals, boxes bitboard
+	player              int
+}
+
+func bitsOfLevel(l Level) levelBits {
+
+	lb := levelBits{w: l.w, h: l.h, player: l.py*l.w + l.px}
+	lb.walls = newBitboard(l.w * l.h)
+	lb.goals = newBitboard(l.w * l.h)
+	lb.boxes = newBitboard(l.w * l.h)
+
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			c := y*l.w + x
+			switch l.grid[x][y] {
+			case WALL:
+				lb.walls.set(c)
+			case GOAL:
+				lb.goals.set(c)
+			case BOX:
+				lb.boxes.set(c)
+			case PLACED_BOX:
+				lb.goals.set(c)
+				lb.boxes.set(c)
+			}
+		}
+	}
+	return lb
+}
+
+// level returns the level lb stands for, as the game draws and plays it
+func (lb levelBits) level() Level {
+
+	l := Level{w: lb.w, h: lb.h, px: lb.player % lb.w, py: lb.player / lb.w}
+	l.grid = make([][]byte, lb.w)
+	for x := range l.grid {
+		l.grid[x] = make([]byte, lb.h)
+		for y := range l.grid[x] {
+			c := y*lb.w + x
+			switch {
+			case lb.walls.has(c):
+				l.grid[x][y] = WALL
+			case lb.boxes.has(c) && lb.goals.has(c):
+				l.grid[x][y] = PLACED_BOX
+			case lb.boxes.has(c):
+				l.grid[x][y] = BOX
+			case lb.goals.has(c):
+				l.grid[x][y] = GOAL
+			default:
+				l.grid[x][y] = EMPTY
+			}
+		}
+	}
+
+	l.placeOnScreen()
+	l.psprite = PLAYERUP
+	l.countBoxes()
+	return l
+}
+
+// the floor of a board as bitboards, what the cells the player can walk to
+// grow over
+type floorBits struct {
+	w                       int
+	open, notLeft, notRight bitboard // floor cells, cells off the left and off the right column
+}
+
+func newFloorBits(lb levelBits) floorBits {
+
+	f := floorBits{w: lb.w}
+	f.open = newBitboard(lb.w * lb.h)
+	f.notLeft = newBitboard(lb.w * lb.h)
+	f.notRight = newBitboard(lb.w * lb.h)
+	for c := 0; c < lb.w*lb.h; c++ {
+		if !lb.walls.has(c) {
+			f.open.set(c)
+		}
+		if c%lb.w > 0 {
+			f.notLeft.set(c)
+		}
+		if c%lb.w < lb.w-1 {
+			f.notRight.set(c)
+		}
+	}
+	return f
+}
+
+// around sets dst to the floor cells next to those of src
+func (f floorBits) around(dst, src bitboard) {
+
+	for i := range dst {
+		dst[i] = (src.shiftedWord(i, 1)&f.notLeft[i] | src.shiftedWord(i, -1)&f.notRight[i] |
+			src.shiftedWord(i, f.w) | src.shiftedWord(i, -f.w)) & f.open[i]
+	}
+}
+
+// grow sets next to the floor cells next to those of frontier that are
+// neither blocked nor in area yet, and adds them to area; it tells if there
+// were any
+func (f floorBits) grow(area, frontier, next, blocked bitboard) bool {
+
+	grown := false
+	for i := range next {
+		w := frontier.shiftedWord(i, 1)&f.notLeft[i] | frontier.shiftedWord(i, -1)&f.notRight[i] |
+			frontier.shiftedWord(i, f.w) | frontier.shiftedWord(i, -f.w)
+		w &= f.open[i] &^ blocked[i] &^ area[i]
+		next[i] = w
+		if w != 0 {
+			grown = true
+			area[i] |= w
+		}
+	}
+	return grown
+}
+
+// reachBits finds the cells the player on cell player can walk to, the
+// cells of blocked being in the way, and how far they are: b.area holds
+// them afterwards, see reached. It returns the smallest one, used to
+// identify the player area.
+func (b *solverBoard) reachBits(player int, blocked bitboard) int {
+
+	area, frontier, next := b.area, b.frontier, b.next
+	area.clear()
+	frontier.clear()
+	area.set(player)
+	frontier.set(player)
+	b.dist[player] = 0
+
+	for dist := int32(1); b.grow(area, frontier, next, blocked); dist++ {
+		for i, w := range next {
+			for ; w != 0; w &= w - 1 {
+				b.dist[i<<6+bits.TrailingZeros64(w)] = dist
+			}
+		}
+		frontier, next = next, frontier
+	}
+	return area.first()
+}
@@ -0,0 +1,78 @@
+// Sokoban game
+//
+// Blindfold mode (settings > gameplay): a few seconds after a level starts
+// the boxes, or the whole board but the player, disappear and the level is
+// played from memory. Holding M peeks at the board, each peek adding ten
+// seconds to the time of the attempt.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	blindOff = iota
+	blindBoxes
+	blindBoard
+
+	blindPreview = 5 * time.Second
+	blindPenalty = 10 * time.Second
+)
+
+var (
+	blindfold      = blindOff
+	blindfoldNames = []string{"off", "boxes hidden", "board hidden"}
+
+	blindStart   time.Time // when the level started, for the preview
+	blindPeeking = false
+	blindPeeks   = 0 // in the current attempt
+)
+
+func blindLevelStarted(e GameEvent) {
+
+	blindStart = time.Now()
+	blindPeeks = 0
+}
+
+// blindHidden tells what is hidden now: blindOff, blindBoxes or blindBoard
+func blindHidden(s *GameState) int {
+
+	if blindfold == blindOff || blindPeeking || s.nBoxesLeft() == 0 || time.Since(blindStart) < blindPreview {
+		return blindOff
+	}
+	return blindfold
+}
+
+func updateBlindfold(s *GameState, in InputSource) {
+
+	if blindfold == blindOff {
+		blindPeeking = false
+		return
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyM) && blindHidden(s) != blindOff {
+		blindPeeks++
+		s.levelStart = s.levelStart.Add(-blindPenalty)
+	}
+	blindPeeking = in.IsKeyPressed(ebiten.KeyM)
+}
+
+func drawBlindfold(screen *ebiten.Image, s *GameState) {
+
+	if blindfold == blindOff || s.nBoxesLeft() == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("Blindfold: hold M to peek (+%d s), peeks: %d", int(blindPenalty.Seconds()), blindPeeks)
+	if left := blindPreview - time.Since(blindStart); left > 0 {
+		msg = fmt.Sprintf("Blindfold: remember the board, %d s left", int(left.Seconds())+1)
+	}
+	ebitenutil.DrawRect(screen, 20, screenHeight-100, float64(len(msg)*6+20), 24, color.RGBA{0, 0, 0, 180})
+	drawText(screen, msg, 30, screenHeight-96)
+}
@@ -0,0 +1,62 @@
+// Sokoban game
+//
+// Board diffs: the cells that changed between two boards of the same size,
+// as XSB characters, and the board they lead to when applied. A move
+// changes two or three cells, which is what the remote clients receive
+// after each move instead of the whole board, and which tells them what
+// to animate.
+
+package main
+
+import "fmt"
+
+// a cell and its new tile, an XSB character
+type cellChange struct {
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Tile string `json:"t"`
+}
+
+// diffBoards returns the changes turning the XSB rows from into to, or false
+// when the boards do not have the same size
+func diffBoards(from []string, to []string) ([]cellChange, bool) {
+
+	if len(from) != len(to) {
+		return nil, false
+	}
+
+	changes := []cellChange{}
+	for y := range to {
+		if len(from[y]) != len(to[y]) {
+			return nil, false
+		}
+		for x := 0; x < len(to[y]); x++ {
+			if from[y][x] != to[y][x] {
+				changes = append(changes, cellChange{x, y, to[y][x : x+1]})
+			}
+		}
+	}
+	return changes, true
+}
+
+// applyBoardDiff returns the XSB rows with the changes made
+func applyBoardDiff(rows []string, changes []cellChange) ([]string, error) {
+
+	grid := make([][]byte, len(rows))
+	for y, row := range rows {
+		grid[y] = []byte(row)
+	}
+
+	for _, c := range changes {
+		if c.Y < 0 || c.Y >= len(grid) || c.X < 0 || c.X >= len(grid[c.Y]) || len(c.Tile) != 1 {
+			return nil, fmt.Errorf("change %v outside the board", c)
+		}
+		grid[c.Y][c.X] = c.Tile[0]
+	}
+
+	out := make([]string, len(grid))
+	for y := range grid {
+		out[y] = string(grid[y])
+	}
+	return out, nil
+}
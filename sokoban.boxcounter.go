@@ -0,0 +1,63 @@
+// Sokoban game
+//
+// Boxes remaining: the number of boxes still off a goal is shown in large
+// type at the top of the screen. It swells and flashes green for a moment
+// each time a box reaches a goal.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	counterScale = 2.0
+	counterPop   = 500 * time.Millisecond
+)
+
+var (
+	counterImage  *ebiten.Image
+	counterPlaced time.Time
+	counterLevel  = -1
+)
+
+func counterPackChanged(e GameEvent) {
+
+	counterLevel = -1
+}
+
+func boxCounterPlaced(e GameEvent) {
+
+	counterPlaced = time.Now()
+	counterLevel = e.State.currentLevelNumber
+}
+
+func drawBoxCounter(screen *ebiten.Image, s *GameState) {
+
+	msg := fmt.Sprintf("boxes remaining: %d", s.curLev.boxesLeft)
+
+	if counterImage == nil {
+		counterImage = ebiten.NewImage(180, 20)
+	}
+	counterImage.Fill(color.RGBA{0, 0, 0, 160})
+	drawText(counterImage, msg, 6, 2)
+
+	// swell up and back down after a box was placed
+	scale := counterScale
+	op := &ebiten.DrawImageOptions{}
+	if age := time.Since(counterPlaced); age < counterPop && counterLevel == s.currentLevelNumber {
+		t := float64(age) / float64(counterPop)
+		scale += 0.6 * math.Sin(math.Pi*t) * motionScale()
+		op.ColorM.Scale(1-0.5*(1-t), 1, 1-0.5*(1-t), 1)
+	}
+
+	w, h := counterImage.Size()
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(screenWidth/2-float64(w)*scale/2, 40-float64(h)*scale/2)
+	screen.DrawImage(counterImage, op)
+}
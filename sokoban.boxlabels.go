@@ -0,0 +1,150 @@
+// Sokoban game
+//
+// Box and goal labels, turned on in the settings: each goal gets a letter,
+// in reading order, and each box the letter of the goal it is meant for.
+// Boxes are matched to goals so that the pushes needed, each box counted as
+// if alone on the board, add up to as few as possible, the lower bound the
+// solver reasons with. The matching follows the boxes as they move.
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const unreachable = 1 << 20
+
+var (
+	boxLabels = false
+
+	labelKey   string      // hash of the position the labels below were computed for
+	labelBoxes map[int]int // goal index of each box cell
+	labelGoals []int       // goal cells in reading order
+	labelLevel = -1
+)
+
+func labelsPackChanged(e GameEvent) {
+
+	labelLevel = -1
+}
+
+// pushDistances returns how many pushes a box alone on the board needs to
+// reach goal from each cell, pulling it away from the goal
+func pushDistances(b *solverBoard, goal int) []int {
+
+	dist := make([]int, b.w*b.h)
+	for c := range dist {
+		dist[c] = unreachable
+	}
+	dist[goal] = 0
+	queue := []int{goal}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, d := range directions {
+			from := b.step(c, d)
+			player := -1
+			if from >= 0 {
+				player = b.step(from, d)
+			}
+			if b.floor(from) && b.floor(player) && dist[from] == unreachable {
+				dist[from] = dist[c] + 1
+				queue = append(queue, from)
+			}
+		}
+	}
+	return dist
+}
+
+// assignment returns for each row of the square cost matrix the column that
+// makes the total cost minimal
+func assignment(cost [][]int) []int {
+
+	var m matcher
+	n := len(cost)
+	flat := m.costs(n, n)
+	for i, row := range cost {
+		for j, c := range row {
+			flat[i*n+j] = int32(c)
+		}
+	}
+	m.solve(n, n)
+	return m.columns(n)
+}
+
+// updateLabels matches the boxes of the current position with the goals
+func updateLabels(s *GameState) {
+
+	key := levelHash(s.curLev)
+	if key == labelKey && labelLevel == s.currentLevelNumber {
+		return
+	}
+	labelKey, labelLevel = key, s.currentLevelNumber
+
+	b, boxes, _ := newSolverBoard(s.curLev, nil)
+
+	labelGoals = labelGoals[:0]
+	for c, g := range b.goal {
+		if g {
+			labelGoals = append(labelGoals, c)
+		}
+	}
+
+	labelBoxes = make(map[int]int)
+	if len(boxes) != len(labelGoals) || len(boxes) == 0 {
+		return
+	}
+
+	cost := make([][]int, len(boxes))
+	for j, g := range labelGoals {
+		dist := pushDistances(b, g)
+		for i, c := range boxes {
+			if j == 0 {
+				cost[i] = make([]int, len(labelGoals))
+			}
+			cost[i][j] = dist[c]
+		}
+	}
+
+	for i, j := range assignment(cost) {
+		labelBoxes[int(boxes[i])] = j
+	}
+}
+
+func labelLetter(i int) string {
+
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return string(rune('a' + (i-26)%26))
+}
+
+// drawLabels writes the letters on the goals and the boxes
+func drawLabels(screen *ebiten.Image, s *GameState) {
+
+	if !boxLabels || blindHidden(s) != blindOff {
+		return
+	}
+	updateLabels(s)
+
+	w := s.curLev.w
+	label := func(c int, i int, bg color.Color) {
+		x, y, tile := cellOnScreen(s, c%w, c/w)
+		cx, cy := x+tile/2, y+tile/2
+		ebitenutil.DrawRect(screen, cx-7, cy-9, 14, 18, bg)
+		drawText(screen, labelLetter(i), int(cx)-3, int(cy)-9)
+	}
+
+	for i, c := range labelGoals {
+		if _, boxed := labelBoxes[c]; !boxed {
+			label(c, i, color.RGBA{0, 90, 0, 200})
+		}
+	}
+	for c, i := range labelBoxes {
+		label(c, i, color.RGBA{90, 50, 0, 200})
+	}
+}
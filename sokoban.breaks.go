@@ -0,0 +1,74 @@
+// Sokoban game
+//
+// Break reminder: after playing without a pause for the time chosen in the
+// settings, the game suggests a break and pauses, the level timer included,
+// until a key or a click. Five minutes without input count as a break.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const breakGap = 5 * time.Minute
+
+var (
+	breakAfter        = 0 // minutes, 0 for no reminder
+	breakAfterChoices = []int{0, 20, 30, 45, 60, 90, 120}
+
+	playingSince   = time.Now()
+	breakLastInput = time.Now()
+
+	breakPaused   = false
+	breakPausedAt time.Time
+)
+
+// updateBreak returns true while the reminder pauses the game
+func updateBreak(s *GameState, in InputSource, mouseOrTouch bool) bool {
+
+	if breakPaused {
+		if mouseOrTouch || len(in.AppendJustPressedKeys(nil)) > 0 {
+			breakPaused = false
+			s.levelStart = s.levelStart.Add(time.Since(breakPausedAt))
+			speedrunPaused(time.Since(breakPausedAt))
+			playingSince = time.Now()
+		}
+		return true
+	}
+
+	// a long enough gap between inputs was a break
+	if lastActivity.Sub(breakLastInput) >= breakGap {
+		playingSince = lastActivity
+	}
+	breakLastInput = lastActivity
+
+	if breakAfter > 0 && time.Since(playingSince) >= time.Duration(breakAfter)*time.Minute {
+		breakPaused = true
+		breakPausedAt = time.Now()
+		playback = nil
+		return true
+	}
+
+	return false
+}
+
+func drawBreakReminder(screen *ebiten.Image) {
+
+	if !breakPaused {
+		return
+	}
+
+	msg := fmt.Sprintf("You have been playing for %d minutes.\n\n"+
+		"A good time to rest your eyes, stretch and have a drink.\n"+
+		"The game is paused, the level timer too.\n\n"+
+		"Press any key or click to go on.", int(breakPausedAt.Sub(playingSince).Minutes()))
+
+	x, y := screenWidth/2-200, screenHeight/2-60
+	ebitenutil.DrawRect(screen, float64(x-30), float64(y-30), 430, 150, color.RGBA{20, 40, 30, 235})
+	drawText(screen, msg, x, y)
+}
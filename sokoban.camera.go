@@ -0,0 +1,142 @@
+// Sokoban game
+//
+// Camera: the mouse wheel and two finger pinches zoom the board around the
+// cursor or the middle of the fingers, 0 fits the board to the screen again.
+// At the fitting zoom the board is centered, closer it can not be moved
+// further than its edges.
+
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	minZoom = 1.0 // the whole board fits the screen
+	maxZoom = 4.0
+)
+
+type boardCamera struct {
+	level  int
+	view   int     // see levelView
+	fit    float64 // scale showing the whole board
+	zoom   float64
+	sx, sy float64 // screen position of the board's top left corner
+
+	pinchDist float64 // distance between the fingers, 0 when not pinching
+}
+
+var camera = boardCamera{level: -1}
+
+func cameraPackChanged(e GameEvent) {
+
+	camera.level = -1
+}
+
+// boardView returns where the board of s is drawn and its scale
+func boardView(s *GameState) (float64, float64, float64) {
+
+	if v := levelView(s.currentLevelNumber); camera.level != s.currentLevelNumber || camera.view != v {
+		// placed as the board is shown, its sides swapped by a quarter turn
+		x0, y0, w, h := boardFrame(s.curLev, v)
+		fit := Level{w: w, h: h}
+		fit.placeOnScreen()
+		tile := baseTileSize * fit.zfactor
+		camera = boardCamera{level: s.currentLevelNumber, view: v, fit: fit.zfactor, zoom: minZoom,
+			sx: fit.sx - float64(x0)*tile, sy: fit.sy - float64(y0)*tile}
+	}
+	return camera.sx, camera.sy, camera.fit * camera.zoom
+}
+
+// boardFrame returns the part of the board of l, in cells of view v, the
+// camera fits to the screen: all of it, or up to its walls in kid mode
+func boardFrame(l Level, v int) (int, int, int, int) {
+
+	if kidMode {
+		return wallsFrame(l, v)
+	}
+	w, h := viewSize(l, v)
+	return 0, 0, w, h
+}
+
+// screenToCell returns the cell of the board of s under x, y
+func screenToCell(s *GameState, x int, y int) (int, int, bool) {
+
+	sx, sy, factor := boardView(s)
+	vx := int(math.Floor((float64(x) - sx) / (baseTileSize * factor)))
+	vy := int(math.Floor((float64(y) - sy) / (baseTileSize * factor)))
+
+	w, h := viewSize(s.curLev, camera.view)
+	if vx < 0 || vy < 0 || vx >= w || vy >= h {
+		return vx, vy, false
+	}
+	cx, cy := viewToCell(s.curLev, camera.view, vx, vy)
+	return cx, cy, true
+}
+
+// cellOnScreen returns where the top left corner of cell x, y of the board
+// of s is drawn, and the size of a cell
+func cellOnScreen(s *GameState, x int, y int) (float64, float64, float64) {
+
+	sx, sy, factor := boardView(s)
+	vx, vy := cellToView(s.curLev, camera.view, x, y)
+	tile := baseTileSize * factor
+	return sx + float64(vx)*tile, sy + float64(vy)*tile, tile
+}
+
+func updateCamera(s *GameState, in InputSource) {
+
+	boardView(s)
+
+	if in.IsKeyJustPressed(ebiten.KeyDigit0) {
+		zoomCamera(s, minZoom/camera.zoom, 0, 0)
+	}
+
+	if _, wy := in.Wheel(); wy != 0 {
+		x, y := in.CursorPosition()
+		zoomCamera(s, math.Pow(1.1, wy), float64(x), float64(y))
+	}
+
+	touches := in.AppendTouchIDs(nil)
+	if len(touches) != 2 {
+		camera.pinchDist = 0
+		return
+	}
+
+	x0, y0 := in.TouchPosition(touches[0])
+	x1, y1 := in.TouchPosition(touches[1])
+	dist := math.Hypot(float64(x1-x0), float64(y1-y0))
+
+	if camera.pinchDist > 0 && dist > 0 {
+		zoomCamera(s, dist/camera.pinchDist, float64(x0+x1)/2, float64(y0+y1)/2)
+	}
+	camera.pinchDist = dist
+}
+
+// zoomCamera multiplies the zoom by ratio, keeping the point of the board
+// under x, y in place
+func zoomCamera(s *GameState, ratio float64, x float64, y float64) {
+
+	zoom := math.Max(minZoom, math.Min(maxZoom, camera.zoom*ratio))
+	ratio = zoom / camera.zoom
+
+	camera.zoom = zoom
+	camera.sx = x - (x-camera.sx)*ratio
+	camera.sy = y - (y-camera.sy)*ratio
+
+	// keep the board centered when it fits, on the edges of the screen otherwise
+	tile := baseTileSize * camera.fit * zoom
+	x0, y0, w, h := boardFrame(s.curLev, camera.view)
+	camera.sx = clampBoardEdge(camera.sx+float64(x0)*tile, tile*float64(w), screenWidth) - float64(x0)*tile
+	camera.sy = clampBoardEdge(camera.sy+float64(y0)*tile, tile*float64(h), screenHeight) - float64(y0)*tile
+}
+
+func clampBoardEdge(pos float64, size float64, screen float64) float64 {
+
+	if size <= screen {
+		return (screen - size) / 2
+	}
+	return math.Max(screen-size, math.Min(0, pos))
+}
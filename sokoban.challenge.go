@@ -0,0 +1,229 @@
+// Sokoban game
+//
+// Challenges: on the screen of a solved level ("stay on solved levels"), C
+// makes a link challenging a friend to beat the time of the solution and
+// Shift+C one to solve the level within its number of moves. The link shows
+// on that screen and is written to challenge.txt next to the settings.
+//
+// The friend runs the game with -challenge LINK: the level loads with the
+// goal to beat at the top right, and solving it shows both results side by
+// side. Leaving the level gives the challenge up.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	challengeFile = "challenge.txt"
+
+	timeAttack = "time"
+	moveLimit  = "moves"
+)
+
+type challenge struct {
+	level  int
+	hash   string // levelHash of the level, in case the levels are not numbered the same
+	mode   string // timeAttack or moveLimit
+	name   string // who made it
+	moves  int
+	pushes int
+	time   float64 // seconds
+}
+
+var (
+	challengeLink  string // made on the current solved level
+	challengeLevel = -1
+
+	activeChallenge *challenge
+
+	// the head to head screen after a challenge
+	challengeOutcome *challenge
+	outcomeMoves     int
+	outcomePushes    int
+	outcomeTime      float64
+)
+
+func challengePackChanged(e GameEvent) {
+
+	challengeLevel, activeChallenge = -1, nil
+}
+
+func (c challenge) link() string {
+
+	v := url.Values{}
+	v.Set("level", strconv.Itoa(c.level))
+	v.Set("hash", c.hash)
+	v.Set("mode", c.mode)
+	v.Set("name", c.name)
+	v.Set("moves", strconv.Itoa(c.moves))
+	v.Set("pushes", strconv.Itoa(c.pushes))
+	v.Set("time", strconv.FormatFloat(c.time, 'f', 1, 64))
+	return "sokoban://challenge?" + v.Encode()
+}
+
+// parseChallenge reads a link made by link
+func parseChallenge(link string) (challenge, error) {
+
+	var c challenge
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return c, err
+	}
+	if u.Scheme != "sokoban" || u.Host != "challenge" {
+		return c, fmt.Errorf("%q is not a challenge link", link)
+	}
+
+	v := u.Query()
+	c.hash, c.mode, c.name = v.Get("hash"), v.Get("mode"), v.Get("name")
+	if c.mode != timeAttack && c.mode != moveLimit {
+		return c, fmt.Errorf("unknown challenge mode %q", c.mode)
+	}
+
+	if c.level, err = strconv.Atoi(v.Get("level")); err == nil {
+		if c.moves, err = strconv.Atoi(v.Get("moves")); err == nil {
+			if c.pushes, err = strconv.Atoi(v.Get("pushes")); err == nil {
+				c.time, err = strconv.ParseFloat(v.Get("time"), 64)
+			}
+		}
+	}
+	if err != nil {
+		return c, fmt.Errorf("damaged challenge link: %v", err)
+	}
+
+	// find the level by its content first, the number may be of another
+	// game or made up
+	if c.level < 0 || c.level > lastLevel() || levelHash(levelTemplate(c.level)) != c.hash {
+		found := false
+		for n := 0; n <= lastLevel() && !found; n++ {
+			if levelHash(levelTemplate(n)) == c.hash {
+				c.level, found = n, true
+			}
+		}
+		if !found {
+			return c, fmt.Errorf("the level of the challenge is not in this game")
+		}
+	}
+	return c, nil
+}
+
+// makeChallenge turns the solution of the current level into a link
+func makeChallenge(s *GameState, mode string) {
+
+	name := nickname
+	if name == "" {
+		name = "a friend"
+	}
+
+	c := challenge{s.currentLevelNumber, levelHash(levelTemplate(s.currentLevelNumber)), mode, name,
+		s.moveCount(), s.pushCount, solvedTime.Seconds()}
+	challengeLink, challengeLevel = c.link(), s.currentLevelNumber
+
+	if path, err := userFilePath(challengeFile); err == nil {
+		if err := os.WriteFile(path, []byte(challengeLink+"\n"), 0644); err == nil {
+			showMessage("challenge link written to " + path)
+		}
+	}
+}
+
+func startChallenge(s *GameState, c challenge) {
+
+	s.loadLevel(c.level)
+	activeChallenge = &c
+}
+
+// challengeLevelStarted forgets the link made on the previous solution and
+// gives the challenge up when the player leaves its level
+func challengeLevelStarted(s *GameState) {
+
+	challengeLink = ""
+	if activeChallenge != nil && s.currentLevelNumber != activeChallenge.level {
+		activeChallenge = nil
+		showMessage("challenge given up")
+	}
+}
+
+func challengeCompleted(s *GameState) {
+
+	c := activeChallenge
+	if c == nil || s.currentLevelNumber != c.level || s.assisted {
+		return
+	}
+
+	activeChallenge = nil
+	challengeOutcome = c
+	outcomeMoves, outcomePushes, outcomeTime = s.moveCount(), s.pushCount, time.Since(s.levelStart).Seconds()
+}
+
+// challengeWon tells whether the result beats the challenge
+func challengeWon(c *challenge, moves int, elapsed float64) bool {
+
+	if c.mode == moveLimit {
+		return moves <= c.moves
+	}
+	return elapsed < c.time
+}
+
+// updateChallengeOutcome returns true while the head to head screen is shown
+func updateChallengeOutcome(in InputSource, mouseOrTouch bool) bool {
+
+	if challengeOutcome == nil {
+		return false
+	}
+	if mouseOrTouch || len(in.AppendJustPressedKeys(nil)) > 0 {
+		challengeOutcome = nil
+	}
+	return true
+}
+
+func drawChallenge(screen *ebiten.Image, s *GameState) {
+
+	if c := activeChallenge; c != nil {
+		msg := fmt.Sprintf("Challenge from %s\nbeat %s\ntime: %s", c.name, formatRunTime(c.time),
+			formatRunTime(time.Since(s.levelStart).Seconds()))
+		if c.mode == moveLimit {
+			msg = fmt.Sprintf("Challenge from %s\nsolve within %d moves\nmoves left: %d", c.name, c.moves, c.moves-s.moveCount())
+		}
+		ebitenutil.DrawRect(screen, screenWidth-310, 200, 290, 60, color.RGBA{0, 0, 0, 160})
+		drawText(screen, msg, screenWidth-300, 206)
+	}
+
+	c := challengeOutcome
+	if c == nil {
+		return
+	}
+
+	verdict := "You lost the challenge."
+	if challengeWon(c, outcomeMoves, outcomeTime) {
+		verdict = "You won the challenge!"
+	}
+	msg := fmt.Sprintf("Level %d, head to head\n\n%-10s %12s %12s\n%-10s %12d %12d\n%-10s %12d %12d\n%-10s %12s %12s\n\n%s\n\nPress any key or click to go on.",
+		c.level, "", "you", c.name,
+		"moves", outcomeMoves, c.moves,
+		"pushes", outcomePushes, c.pushes,
+		"time", formatRunTime(outcomeTime), formatRunTime(c.time), verdict)
+
+	x, y := screenWidth/2-170, screenHeight/2-90
+	ebitenutil.DrawRect(screen, float64(x-30), float64(y-30), 400, 230, color.RGBA{30, 20, 50, 235})
+	drawText(screen, msg, x, y)
+}
+
+// drawChallengeLink shows the link made on the solved level
+func drawChallengeLink(screen *ebiten.Image, s *GameState, x int, y int) {
+
+	if challengeLink == "" || challengeLevel != s.currentLevelNumber {
+		return
+	}
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(len(challengeLink)*6+30), 30, color.RGBA{0, 0, 0, 180})
+	drawText(screen, challengeLink, x+15, y+7)
+}
@@ -0,0 +1,100 @@
+// Sokoban game
+//
+// Click to move: clicking or touching a floor cell the player can reach
+// walks the player there, without pushing anything. The path it will take
+// is previewed with dots while the mouse hovers the cell.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var (
+	hoverPath []byte
+	hoverKey  string
+)
+
+func hoverPackChanged(e GameEvent) {
+
+	hoverKey, hoverPath = "", nil
+}
+
+// iconScreenZones returns where the icons are: clicks on them are not moves
+func iconScreenZones() []screenZone {
+
+	return []screenZone{rightScreenZone, leftScreenZone, upScreenZone, downScreenZone,
+		undoScreenZone, settingsScreenZone, helpScreenZone, nextScreenZone, previousScreenZone}
+}
+
+// walkTo returns the moves walking the player of s to the cell under x, y
+func walkTo(s *GameState, x int, y int) ([]byte, bool) {
+
+	for _, z := range iconScreenZones() {
+		if inScreenZone(z, x, y) {
+			return nil, false
+		}
+	}
+
+	cx, cy, ok := screenToCell(s, x, y)
+	if !ok || (cx == s.curLev.px && cy == s.curLev.py) {
+		return nil, false
+	}
+	if t := s.curLev.grid[cx][cy]; t != EMPTY && t != GOAL || !gameReach.reachable(s, cx, cy) {
+		return nil, false
+	}
+
+	b, boxes, player := newSolverBoard(s.curLev, nil)
+	for _, c := range boxes {
+		b.occupied[c] = true
+	}
+
+	walk := b.path(player, cy*b.w+cx, b.occupied)
+	return walk, walk != nil
+}
+
+// updateClickMove starts walking on a click and keeps the hover preview up
+// to date; it returns true when it took over the input
+func updateClickMove(s *GameState, in InputSource, mouseOrTouch bool, x int, y int) bool {
+
+	mx, my := in.CursorPosition()
+	key := fmt.Sprint(s.currentLevelNumber, s.moveCount(), s.curLev.px, s.curLev.py, mx, my, camera.zoom, camera.sx, camera.sy)
+	if key != hoverKey {
+		hoverKey = key
+		hoverPath, _ = walkTo(s, mx, my)
+	}
+
+	if !mouseOrTouch {
+		return false
+	}
+
+	walk, ok := walkTo(s, x, y)
+	if !ok {
+		return false
+	}
+
+	playback = walk
+	playbackTick = playbackTicks - 1
+	hoverPath = nil
+	return true
+}
+
+func drawPathPreview(screen *ebiten.Image, s *GameState) {
+
+	if len(hoverPath) == 0 || len(playback) > 0 || solverRun != nil {
+		return
+	}
+
+	x, y := s.curLev.px, s.curLev.py
+	for _, d := range hoverPath {
+		dx, dy := dirDelta(d)
+		x, y = x+dx, y+dy
+		px, py, tile := cellOnScreen(s, x, y)
+		dot := tile / 6
+		ebitenutil.DrawRect(screen, px+tile/2-dot/2, py+tile/2-dot/2, dot, dot, color.RGBA{255, 255, 160, 200})
+	}
+}
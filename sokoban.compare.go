@@ -0,0 +1,161 @@
+// Sokoban game
+//
+// Replay comparison: in the replay browser, C marks a replay, C on a second
+// replay of the same level plays both side by side, one move of each at
+// the same time. Space pauses, Left/Right step while paused, Esc goes back.
+//
+// The first move where the replays differ is shown, so a player can see
+// where a shorter solution leaves theirs.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var (
+	compareFirst *replay // replay marked for comparison
+
+	compareOpen    = false
+	compareReplays [2]*replay
+	compareMoves   [2][]byte
+	compareBoards  [2]Level
+	compareStep    int // moves played on each side
+	compareTick    int
+	comparePaused  = false
+	compareDiverge int // index of the first move that differs
+)
+
+func startCompare(a *replay, b *replay) {
+
+	for i, r := range []*replay{a, b} {
+		m, err := lurdToMoves(r.Moves)
+		if err != nil {
+			showMessage(fmt.Sprintf("replay %q is damaged", r.Name))
+			return
+		}
+		compareReplays[i] = r
+		compareMoves[i] = m
+	}
+
+	compareDiverge = 0
+	for compareDiverge < len(compareMoves[0]) && compareDiverge < len(compareMoves[1]) &&
+		compareMoves[0][compareDiverge] == compareMoves[1][compareDiverge] {
+		compareDiverge++
+	}
+
+	compareSeek(0)
+	compareOpen = true
+	comparePaused = false
+}
+
+// compareSeek replays both sides up to move n
+func compareSeek(n int) {
+
+	longest := len(compareMoves[0])
+	if len(compareMoves[1]) > longest {
+		longest = len(compareMoves[1])
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > longest {
+		n = longest
+	}
+
+	for i := range compareBoards {
+		compareBoards[i] = levelTemplate(compareReplays[i].Level)
+		for k := 0; k < n && k < len(compareMoves[i]); k++ {
+			compareBoards[i].play(compareMoves[i][k])
+		}
+	}
+	compareStep = n
+}
+
+// markForCompare is called by the replay browser on C
+func markForCompare(r *replay) {
+
+	if compareFirst == nil || compareFirst == r || compareFirst.Level != r.Level {
+		compareFirst = r
+		showMessage("select another replay of this level and press C to compare")
+		return
+	}
+
+	startCompare(compareFirst, r)
+	compareFirst = nil
+}
+
+// updateCompare returns true while the comparison takes the input
+func updateCompare(in InputSource) bool {
+
+	if !compareOpen {
+		return false
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) {
+		compareOpen = false
+		return true
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeySpace) {
+		comparePaused = !comparePaused
+	}
+
+	if comparePaused {
+		if in.IsKeyJustPressed(ebiten.KeyArrowRight) {
+			compareSeek(compareStep + 1)
+		}
+		if in.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+			compareSeek(compareStep - 1)
+		}
+		return true
+	}
+
+	compareTick++
+	if compareTick >= playbackTicks {
+		compareTick = 0
+		for i := range compareBoards {
+			if compareStep < len(compareMoves[i]) {
+				compareBoards[i].play(compareMoves[i][compareStep])
+			}
+		}
+		if compareStep < len(compareMoves[0]) || compareStep < len(compareMoves[1]) {
+			compareStep++
+		}
+	}
+
+	return true
+}
+
+func drawCompare(screen *ebiten.Image, sh *spriteSheets) {
+
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{20, 20, 30, 255})
+
+	half := float64(screenWidth) / 2
+
+	for i := range compareBoards {
+
+		sh.drawBoard(screen, compareBoards[i], float64(i)*half+10, 60, half-20, screenHeight-70)
+
+		r := compareReplays[i]
+		n := compareStep
+		if n > len(compareMoves[i]) {
+			n = len(compareMoves[i])
+		}
+		drawText(screen, fmt.Sprintf("%s: move %d/%d, %d pushes", r.Name, n, len(compareMoves[i]), r.Pushes),
+			int(float64(i)*half)+20, 20)
+	}
+
+	msg := fmt.Sprintf("the replays differ from move %d", compareDiverge+1)
+	if compareStep > compareDiverge {
+		msg += " (diverged)"
+	}
+	msg += "   Space: pause, Left/Right: step, Esc: back"
+
+	drawText(screen, msg, 20, 40)
+	ebitenutil.DrawLine(screen, half, 60, half, screenHeight, color.White)
+}
@@ -0,0 +1,93 @@
+// Sokoban game
+//
+// Confirmation before throwing away a long attempt: restarting the level,
+// loading a save, watching a replay or solving from the start ask first
+// when at least confirmMoves moves were played since the last quick save.
+// Y, Enter or the Yes button go on, N, Esc or the No button cancel.
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var (
+	confirmMoves        = 50 // 0 never asks
+	confirmMovesChoices = []int{0, 20, 50, 100, 200}
+
+	// the level and move count of the last quick save
+	quickSavedLevel = -1
+	quickSavedMoves = 0
+
+	confirmPrompt string
+	confirmAction func()
+
+	confirmYesZone = screenZone{20, 10, 9, 6}
+	confirmNoZone  = screenZone{20, 10, 12, 6}
+)
+
+func quickSavePackChanged(e GameEvent) {
+
+	quickSavedLevel = -1
+}
+
+// confirmDiscard runs action, after asking when it would throw away a long
+// attempt at the current level
+func confirmDiscard(s *GameState, prompt string, action func()) {
+
+	long := confirmMoves > 0 && s.moveCount() >= confirmMoves && s.nBoxesLeft() > 0
+	saved := quickSavedLevel == s.currentLevelNumber && quickSavedMoves == s.moveCount()
+
+	if !long || saved {
+		action()
+		return
+	}
+
+	confirmPrompt = prompt
+	confirmAction = action
+}
+
+// updateConfirm returns true while the dialog takes the input
+func updateConfirm(in InputSource, mouseOrTouch bool, x int, y int) bool {
+
+	if confirmAction == nil {
+		return false
+	}
+
+	switch {
+	case in.IsKeyJustPressed(ebiten.KeyY) || in.IsKeyJustPressed(ebiten.KeyEnter) ||
+		(mouseOrTouch && inScreenZone(confirmYesZone, x, y)):
+		action := confirmAction
+		confirmAction = nil
+		action()
+	case in.IsKeyJustPressed(ebiten.KeyN) || in.IsKeyJustPressed(ebiten.KeyEscape) ||
+		(mouseOrTouch && inScreenZone(confirmNoZone, x, y)):
+		confirmAction = nil
+	}
+
+	return true
+}
+
+func drawConfirm(screen *ebiten.Image, s *GameState) {
+
+	if confirmAction == nil {
+		return
+	}
+
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{0, 0, 0, 128})
+	ebitenutil.DrawRect(screen, screenWidth/2-300, screenHeight/2-140, 600, 260, color.RGBA{40, 40, 60, 230})
+	drawText(screen, confirmPrompt, screenWidth/2-280, screenHeight/2-120)
+	drawText(screen, "the moves played since the last quick save will be lost", screenWidth/2-280, screenHeight/2-100)
+
+	for _, b := range []struct {
+		z     screenZone
+		label string
+	}{{confirmYesZone, "Yes (Y)"}, {confirmNoZone, "No (N)"}} {
+		x0, y0, x1, y1 := screenZoneCoords(b.z)
+		ebitenutil.DrawRect(screen, float64(x0), float64(y0), float64(x1-x0), float64(y1-y0), color.RGBA{80, 80, 120, 255})
+		drawText(screen, b.label, x0+20, y0+40)
+	}
+}
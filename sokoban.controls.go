@@ -0,0 +1,185 @@
+// Sokoban game
+//
+// Control presets, chosen in the settings. The left hand preset puts every
+// game action on the left side of the keyboard and every icon on the left
+// edge of the screen, for one-handed play:
+//
+//	W A S D  move                Q         undo (Shift+Q: before the last push)
+//	E, Z     next, previous      X         restart
+//	T        solve (Shift+T)     Tab       Enter
+//	B        note                1, 2, 3   info, level select, heatmap
+//	4        fit the board       5, 6      quick save, quick load
+//	C        hint                `         coordinates
+//	Space    peek (hold)         F2        level packs
+//	Ctrl+R   record a macro      Ctrl+E    play it
+//	Ctrl+S   settings            Ctrl+X    export the position
+//	Ctrl+D   level select sort   Ctrl+A    replay note
+//
+// The arrows and the other keys keep working unless the preset uses them,
+// and text fields read the keyboard as it is. The cheat sheet shows the
+// keys of the chosen preset.
+
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	controlPresets = []string{"standard", "left hand"}
+	controlPreset  = "standard"
+)
+
+// presetKey is a key of a preset, pressed alone or with Ctrl
+type presetKey struct {
+	key  ebiten.Key
+	ctrl bool
+}
+
+// the key pressed for each key of the standard controls; keys the preset
+// takes for something else but which other screens read, like C or Space,
+// stand for themselves as well
+var leftHandKeys = map[ebiten.Key]presetKey{
+	ebiten.KeyArrowUp:    {ebiten.KeyW, false},
+	ebiten.KeyArrowLeft:  {ebiten.KeyA, false},
+	ebiten.KeyArrowDown:  {ebiten.KeyS, false},
+	ebiten.KeyArrowRight: {ebiten.KeyD, false},
+	ebiten.KeyBackspace:  {ebiten.KeyQ, false},
+	ebiten.KeyPageUp:     {ebiten.KeyE, false},
+	ebiten.KeyPageDown:   {ebiten.KeyZ, false},
+	ebiten.KeyHome:       {ebiten.KeyX, false},
+	ebiten.KeyS:          {ebiten.KeyT, false},
+	ebiten.KeyEnter:      {ebiten.KeyTab, false},
+	ebiten.KeyN:          {ebiten.KeyB, false},
+	ebiten.KeyI:          {ebiten.KeyDigit1, false},
+	ebiten.KeyL:          {ebiten.KeyDigit2, false},
+	ebiten.KeyH:          {ebiten.KeyDigit3, false},
+	ebiten.KeyDigit0:     {ebiten.KeyDigit4, false},
+	ebiten.KeyF5:         {ebiten.KeyDigit5, false},
+	ebiten.KeyF9:         {ebiten.KeyDigit6, false},
+	ebiten.KeyJ:          {ebiten.KeyC, false},
+	ebiten.KeyC:          {ebiten.KeyC, false},
+	ebiten.KeyK:          {ebiten.KeyBackquote, false},
+	ebiten.KeyM:          {ebiten.KeySpace, false},
+	ebiten.KeySpace:      {ebiten.KeySpace, false},
+	ebiten.KeyP:          {ebiten.KeyF2, false},
+	ebiten.KeyF2:         {ebiten.KeyF2, false},
+	ebiten.KeyR:          {ebiten.KeyR, false},
+	ebiten.KeyInsert:     {ebiten.KeyR, true},
+	ebiten.KeyEnd:        {ebiten.KeyE, true},
+	ebiten.KeyF10:        {ebiten.KeyS, true},
+	ebiten.KeyF12:        {ebiten.KeyX, true},
+	ebiten.KeyD:          {ebiten.KeyD, true},
+	ebiten.KeyA:          {ebiten.KeyA, true},
+}
+
+// the names the cheat sheet gives the keys of the left hand preset, by the
+// names it gives the standard ones
+var leftHandNames = map[string]string{
+	"arrows": "W A S D", "Backspace": "Q", "PageUp": "E", "PageDown": "Z",
+	"Home": "X", "S": "T", "Enter": "Tab", "N": "B", "I": "1", "L": "2",
+	"H": "3", "0": "4", "F5": "5", "F9": "6", "J": "C", "K": "`",
+	"M": "Space", "P": "F2", "Insert": "Ctrl+R", "End": "Ctrl+E",
+	"F10": "Ctrl+S", "F12": "Ctrl+X", "D": "Ctrl+D", "A": "Ctrl+A",
+	"Left": "A", "Right": "D",
+}
+
+// presetInput reads keys through a preset
+type presetInput struct {
+	InputSource
+	keys    map[ebiten.Key]presetKey
+	claimed map[ebiten.Key]bool // keys standing for another one
+}
+
+func newPresetInput(in InputSource, keys map[ebiten.Key]presetKey) presetInput {
+
+	claimed := make(map[ebiten.Key]bool)
+	for _, k := range keys {
+		claimed[k.key] = true
+	}
+	return presetInput{in, keys, claimed}
+}
+
+// mapped tells whether the preset key standing for k is pressed, or was
+// just pressed
+func (p presetInput) mapped(k ebiten.Key, just bool) bool {
+
+	m, ok := p.keys[k]
+	if !ok || p.InputSource.IsKeyPressed(ebiten.KeyControl) != m.ctrl {
+		return false
+	}
+	if just {
+		return p.InputSource.IsKeyJustPressed(m.key)
+	}
+	return p.InputSource.IsKeyPressed(m.key)
+}
+
+func (p presetInput) IsKeyPressed(k ebiten.Key) bool {
+
+	return p.mapped(k, false) || !p.claimed[k] && p.InputSource.IsKeyPressed(k)
+}
+
+func (p presetInput) IsKeyJustPressed(k ebiten.Key) bool {
+
+	return p.mapped(k, true) || !p.claimed[k] && p.InputSource.IsKeyJustPressed(k)
+}
+
+// presetKeyNames rewrites the key names of the cheat sheet, given for the
+// standard controls, for the chosen preset
+func presetKeyNames(keys string) string {
+
+	if controlPreset != "left hand" {
+		return keys
+	}
+
+	var b strings.Builder
+	for len(keys) > 0 {
+		i := strings.IndexFunc(keys, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if i < 0 {
+			i = len(keys)
+		}
+		word := keys[:i]
+		if name, ok := leftHandNames[word]; ok {
+			word = name
+		}
+		b.WriteString(word)
+		if i < len(keys) {
+			b.WriteByte(keys[i])
+			i++
+		}
+		keys = keys[i:]
+	}
+	return b.String()
+}
+
+// controlInput returns in as seen through the chosen preset
+func controlInput(in InputSource) InputSource {
+
+	if controlPreset != "left hand" || noteEntryOpen || jumpPromptOpen || replayRenaming || replayAnnotating {
+		return in
+	}
+	return newPresetInput(in, leftHandKeys)
+}
+
+// applyControlPreset places the icons for the chosen preset
+func applyControlPreset() {
+
+	if controlPreset == "left hand" {
+		rightScreenZone = screenZone{20, 10, 3, 9}
+		leftScreenZone = screenZone{20, 10, 1, 9}
+		upScreenZone = screenZone{20, 10, 2, 8}
+		downScreenZone = screenZone{20, 10, 2, 10}
+		previousScreenZone = screenZone{20, 10, 1, 3}
+		nextScreenZone = screenZone{20, 10, 1, 4}
+	} else {
+		rightScreenZone = screenZone{20, 10, 20, 9}
+		leftScreenZone = screenZone{20, 10, 18, 9}
+		upScreenZone = screenZone{20, 10, 19, 8}
+		downScreenZone = screenZone{20, 10, 19, 10}
+		previousScreenZone = screenZone{20, 10, 19, 1}
+		nextScreenZone = screenZone{20, 10, 20, 1}
+	}
+}
@@ -0,0 +1,132 @@
+// Sokoban game
+//
+// Solver pruning: tunnel macros and PI-corrals, which keep the solutions
+// with the fewest pushes but not those with the fewest moves, and so are
+// only used when optimizing pushes.
+//
+// A tunnel is a run of cells with walls on both sides. A box pushed along a
+// tunnel, the player behind it in the tunnel too, is pushed on until it
+// leaves the tunnel or reaches a goal: pushing it back would undo the push
+// and leaving it there only blocks the tunnel, so the search takes the
+// pushes as one.
+//
+// A corral is an area the player cannot reach, fenced by boxes. When every
+// box of the fence can only be pushed into the corral, by the player from
+// where it stands (a PI-corral), and the corral is not already solved, one
+// of those pushes comes first in some solution with the fewest pushes, the
+// other pushes waiting: only they are tried. When none of them can be made,
+// the position is a deadlock.
+
+package main
+
+// tunnel tells if cell c has walls on both sides across direction d
+func (b *solverBoard) tunnel(c int, d byte) bool {
+
+	side := DOWN
+	if d == UP || d == DOWN {
+		side = RIGHT
+	}
+	n, m := b.step(c, side), b.step(c, oppositeDir(side))
+	return (n < 0 || b.wall[n]) && (m < 0 || b.wall[m])
+}
+
+// tunnelRun returns where the box pushed from cell player to cell box in
+// direction d ends up pushing it along a tunnel, the player then standing
+// behind it, and the number of pushes, at most 255
+func (b *solverBoard) tunnelRun(player int, box int, d byte, occupied []bool) (int, int, int) {
+
+	pushes := 1
+	for pushes < 255 && !b.goal[box] && b.tunnel(player, d) && b.tunnel(box, d) {
+		next := b.step(box, d)
+		if !b.floor(next) || occupied[next] || b.dead[next] || !b.tunnel(next, d) {
+			break
+		}
+		player, box = box, next
+		pushes++
+	}
+	return player, box, pushes
+}
+
+// corralBoxes looks for the PI-corral with the fewest pushes among the
+// areas the player, on b.area, cannot reach; it returns the boxes of its
+// fence, none when there is no such corral, and false when the position
+// is a deadlock. It uses b.frontier and b.next.
+func (b *solverBoard) corralBoxes(occupied []bool) (bitboard, bool) {
+
+	rest := b.corralRest
+	for i := range rest {
+		rest[i] = b.inside[i] &^ b.blocked[i] &^ b.area[i]
+	}
+
+	var best bitboard
+	bestPushes := 0
+
+	for seed := rest.first(); seed >= 0; seed = rest.first() {
+		region := b.corral
+		region.clear()
+		region.set(seed)
+		frontier, next := b.frontier, b.next
+		frontier.clear()
+		frontier.set(seed)
+		for b.grow(region, frontier, next, b.blocked) {
+			frontier, next = next, frontier
+		}
+		for i := range rest {
+			rest[i] &^= region[i]
+		}
+
+		pushes, ok := b.corralFence(region, occupied)
+		if !ok {
+			continue
+		}
+		if pushes == 0 {
+			return nil, false
+		}
+		if best == nil || pushes < bestPushes {
+			copy(b.fence, b.fenceTry)
+			best, bestPushes = b.fence, pushes
+		}
+	}
+	return best, true
+}
+
+// corralFence sets b.fenceTry to the boxes around region, and returns the
+// pushes into it and if it is an unsolved PI-corral
+func (b *solverBoard) corralFence(region bitboard, occupied []bool) (int, bool) {
+
+	fence := b.fenceTry
+	b.around(fence, region)
+	solved := true
+	for i := range fence {
+		fence[i] &= b.blocked[i]
+		if region[i]&b.goals[i] != 0 {
+			solved = false
+		}
+	}
+
+	pushes := 0
+	b.packed = fence.appendCells(b.packed[:0])
+	for _, c := range b.packed {
+		if !b.goal[c] {
+			solved = false
+		}
+		for _, d := range directions {
+			to, from := b.step(int(c), d), b.step(int(c), oppositeDir(d))
+			switch {
+			case !b.floor(to) || !b.floor(from) || b.dead[to]:
+				// never pushed that way in a solution
+			case fence.has(to) || fence.has(from):
+				// not before another box of the fence moves
+			case !region.has(to):
+				return 0, false
+			case region.has(from):
+				// from inside, where the player is not
+			case !b.area.has(from):
+				return 0, false
+			default:
+				pushes++
+			}
+		}
+	}
+	return pushes, !solved
+}
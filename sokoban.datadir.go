@@ -0,0 +1,104 @@
+// Sokoban game
+//
+// Data directory: the settings, the progress, the replays, the level packs
+// and the other files kept between sessions go in the sokoban directory of
+// the user configuration directory, the solver cache in that of the user
+// cache directory, unless -data-dir names another directory, which then
+// holds everything, the cache in its cache directory.
+//
+// Portable mode, -portable or a file named "portable" next to the
+// executable, keeps everything in the sokoban-data directory next to the
+// executable, for a game played from a USB stick or several copies of the
+// game kept apart.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	portableMarker  = "portable"
+	portableDataDir = "sokoban-data"
+)
+
+var dataDir = "" // "" for the user directories
+
+// portableDir returns the data directory of portable mode, next to the
+// executable, or "" when it cannot be told
+func portableDir() string {
+
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Join(filepath.Dir(exe), portableDataDir)
+}
+
+// dataDirFromArgs returns the data directory the arguments ask for, the
+// settings being read before the flags are parsed
+func dataDirFromArgs(args []string) string {
+
+	dir, portable := "", false
+	for i := 0; i < len(args) && args[i] != "--"; i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		switch name {
+		case "data-dir":
+			if !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			dir = value
+		case "portable":
+			portable = !hasValue || value == "true" || value == "1"
+		}
+	}
+
+	if dir != "" {
+		return dir
+	}
+	if !portable {
+		if exe, err := os.Executable(); err == nil {
+			_, err := os.Stat(filepath.Join(filepath.Dir(exe), portableMarker))
+			portable = err == nil
+		}
+	}
+	if portable {
+		return portableDir()
+	}
+	return ""
+}
+
+// dataRoot returns the directory the files kept between sessions go in
+func dataRoot() (string, error) {
+
+	if dataDir != "" {
+		return dataDir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sokoban"), nil
+}
+
+// cacheRoot returns the directory of the files that can be computed again
+func cacheRoot() (string, error) {
+
+	if dataDir != "" {
+		return filepath.Join(dataDir, "cache"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sokoban"), nil
+}
@@ -0,0 +1,23 @@
+// Sokoban game
+//
+// Simple deadlocks: a box pushed where it can never reach a goal again
+
+package main
+
+// pushDeadlock tells if the box at x, y is stuck: on a cell from which no
+// goal can be reached, or frozen in a block of walls and boxes
+func pushDeadlock(l Level, x int, y int) bool {
+
+	b, boxes, _ := newSolverBoard(l, nil)
+	c := y*b.w + x
+
+	if b.dead[c] {
+		return true
+	}
+
+	for _, box := range boxes {
+		b.occupied[box] = true
+	}
+
+	return b.frozen(c, b.occupied)
+}
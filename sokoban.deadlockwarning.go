@@ -0,0 +1,82 @@
+// Sokoban game
+//
+// Deadlock warning: when a push leaves a box that can no longer reach a
+// goal, the board shakes briefly and the box pulses red for a second. Both
+// can be turned off in the accessibility settings; the shake also follows
+// reduced motion.
+
+package main
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	shakeDuration = 300 * time.Millisecond
+	shakeAmount   = 6.0 // pixels
+	pulseDuration = time.Second
+	pulses        = 3
+)
+
+var (
+	deadlockEffects = true
+
+	deadlockAt    time.Time
+	deadlockLevel = -1
+	deadlockX     int
+	deadlockY     int
+)
+
+func deadlockPackChanged(e GameEvent) {
+
+	deadlockLevel = -1
+}
+
+func deadlockWarning(e GameEvent) {
+
+	if !deadlockEffects {
+		return
+	}
+	deadlockAt = time.Now()
+	deadlockLevel = e.State.currentLevelNumber
+	deadlockX, deadlockY = e.X, e.Y
+}
+
+// shakeOffset returns how far the board is moved by the shake now
+func shakeOffset() (float64, float64) {
+
+	age := time.Since(deadlockAt)
+	if !deadlockEffects || age >= shakeDuration {
+		return 0, 0
+	}
+
+	// a damped wobble, faster horizontally than vertically
+	t := age.Seconds()
+	a := shakeAmount * motionScale() * (1 - float64(age)/float64(shakeDuration))
+	return a * math.Sin(t*90), a * math.Sin(t*70) / 2
+}
+
+// drawDeadlockPulse tints the deadlocked box while it stays where it got
+// stuck
+func drawDeadlockPulse(screen *ebiten.Image, s *GameState) {
+
+	age := time.Since(deadlockAt)
+	if !deadlockEffects || age >= pulseDuration || deadlockLevel != s.currentLevelNumber {
+		return
+	}
+	if deadlockX >= s.curLev.w || deadlockY >= s.curLev.h || s.curLev.grid[deadlockX][deadlockY] != BOX {
+		return
+	}
+
+	phase := float64(age) / float64(pulseDuration) * pulses
+	alpha := 140 * math.Sin(math.Pi*(phase-math.Floor(phase)))
+
+	x, y, tile := cellOnScreen(s, deadlockX, deadlockY)
+	shakeX, shakeY := shakeOffset()
+	ebitenutil.DrawRect(screen, x+shakeX, y+shakeY, tile, tile, color.RGBA{220, 30, 30, uint8(alpha)})
+}
@@ -0,0 +1,187 @@
+// Sokoban game
+//
+// Measured difficulty: run with -analyze, optionally followed by XSB files
+//
+// Solves every embedded level, or every level of the files, within a node
+// budget and keeps the solution length and the search effort of each one in
+// difficulty.json, keyed by the level hash. The score grows with the square
+// root of the pushes and with the number of digits of the nodes expanded; a
+// level the solver gives up on rates as expert, higher the more pushes the
+// search proved a solution needs at least. The level select shows the
+// measured score when there is one, and can sort and filter by difficulty.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	difficultyFile    = "difficulty.json"
+	unsolvedScore     = 90      // the lowest score of a level not solved
+	defaultAnalyzeMax = 1000000 // nodes per level
+)
+
+type difficultyScore struct {
+	Pushes int  `json:"pushes"`
+	Moves  int  `json:"moves"`
+	Nodes  int  `json:"nodes"`
+	Solved bool `json:"solved"`
+	Bound  int  `json:"bound,omitempty"` // fewest pushes of a solution, when not solved
+	Score  int  `json:"score"`
+}
+
+var (
+	difficultyScores map[string]difficultyScore // by level hash, nil until loaded
+	levelScores      map[int]difficultyScore    // of the embedded levels
+
+	selectByDifficulty = false
+	selectFilter       = 0 // index in selectFilters
+	selectFilters      = []string{"all", "trivial", "easy", "medium", "hard", "expert"}
+)
+
+func difficultyPackChanged(e GameEvent) {
+
+	difficultyScores, levelScores = nil, nil
+}
+
+// searchDifficulty rates a level from what the solver went through
+func searchDifficulty(res solverResult) int {
+
+	if !res.solved {
+		return unsolvedScore + int(math.Round(2*math.Sqrt(float64(res.bound))))
+	}
+
+	effort := math.Log10(float64(res.stats.nodes)) - 1
+	if effort < 0 {
+		effort = 0
+	}
+	return int(math.Round(2*math.Sqrt(float64(res.pushes)) + 10*effort))
+}
+
+func loadDifficultyScores() {
+
+	if difficultyScores != nil {
+		return
+	}
+
+	difficultyScores = make(map[string]difficultyScore)
+	if err := loadJSON(difficultyFile, &difficultyScores); err != nil && !os.IsNotExist(err) {
+		log.Printf("cannot read difficulty scores: %v", err)
+	}
+
+	levelScores = make(map[int]difficultyScore)
+	for n := 0; n <= lastLevel(); n++ {
+		if d, ok := difficultyScores[levelHash(levelTemplate(n))]; ok {
+			levelScores[n] = d
+		}
+	}
+}
+
+// levelDifficulty returns the measured score of level n, or the estimate of
+// the level facts and false when it was not analyzed
+func levelDifficulty(n int) (int, bool) {
+
+	loadDifficultyScores()
+	if d, ok := levelScores[n]; ok {
+		return d.Score, true
+	}
+	return getLevelInfo(n).difficulty, false
+}
+
+// analyzeLevel solves l and records its score
+func analyzeLevel(name string, l Level, maxNodes int) {
+
+	res := solveLevel(l, optimizePushes, maxNodes, nil)
+	d := difficultyScore{res.pushes, len(res.moves), res.stats.nodes, res.solved, 0, searchDifficulty(res)}
+	status := ""
+	if !res.solved {
+		d.Bound = res.bound
+		status = fmt.Sprintf("  (node budget, %d pushes or more)", d.Bound)
+	}
+	difficultyScores[levelHash(l)] = d
+
+	fmt.Printf("%-30s %7d %7d %10d %10s %5d %-7s%s\n", name, d.Moves, d.Pushes, d.Nodes,
+		res.stats.elapsed.Round(time.Millisecond), d.Score, difficultyLabel(d.Score), status)
+}
+
+// runAnalyze scores the embedded levels, or the levels of files
+func runAnalyze(files []string, maxNodes int) error {
+
+	loadDifficultyScores()
+
+	fmt.Printf("%-30s %7s %7s %10s %10s %5s\n", "level", "moves", "pushes", "nodes", "time", "score")
+
+	if len(files) == 0 {
+		for n := 0; n <= LEVEL_MAX; n++ {
+			analyzeLevel(fmt.Sprintf("level %d", n), levelTemplate(n), maxNodes)
+		}
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		levels, err := readXSBCollection(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+
+		for i, xl := range levels {
+			l, err := xsbToLevel(xl.rows)
+			if err != nil {
+				return fmt.Errorf("%s line %d: %v", file, xl.line, err)
+			}
+			analyzeLevel(xsbLevelName(file, i, xl), l, maxNodes)
+		}
+	}
+
+	return saveJSON(difficultyFile, difficultyScores)
+}
+
+// selectOrder returns the levels the level select shows, in order
+func selectOrder() []int {
+
+	var order []int
+	for n := 0; n <= lastLevel(); n++ {
+		if kidMode && !kidLevel(n) {
+			continue
+		}
+		d, _ := levelDifficulty(n)
+		if selectFilter == 0 || difficultyLabel(d) == selectFilters[selectFilter] {
+			order = append(order, n)
+		}
+	}
+
+	if selectByDifficulty {
+		sort.SliceStable(order, func(i, j int) bool {
+			di, _ := levelDifficulty(order[i])
+			dj, _ := levelDifficulty(order[j])
+			return di < dj
+		})
+	}
+	return order
+}
+
+// difficultySummary describes the difficulty of level n for the level select
+func difficultySummary(n int) string {
+
+	d, measured := levelDifficulty(n)
+	if !measured {
+		return difficultyLabel(d)
+	}
+	if s := levelScores[n]; !s.Solved {
+		if s.Bound > 0 {
+			return fmt.Sprintf("%s (unsolved, %d pushes or more)", difficultyLabel(d), s.Bound)
+		}
+		return difficultyLabel(d) + " (unsolved)"
+	}
+	return fmt.Sprintf("%s, %d pushes", difficultyLabel(d), levelScores[n].Pushes)
+}
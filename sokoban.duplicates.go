@@ -0,0 +1,258 @@
+// Sokoban game
+//
+// Duplicate levels: run with -find-duplicates pack.xsb ...
+//
+// Levels are compared by a canonical form, so that the same level moved
+// inside a larger grid, with extra walls around it, rotated, mirrored or
+// with the player standing elsewhere in the same area is still recognised. Every level of the packs
+// is checked against the embedded levels and against the levels before it.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// cropLevel returns the rows of l without the blank rows and columns
+// around it
+func cropLevel(l Level) [][]byte {
+
+	rows := levelToXSB(l)
+
+	x0, y0, x1, y1 := l.w, l.h, -1, -1
+	for y, row := range rows {
+		for x, c := range []byte(row) {
+			if c != ' ' {
+				if x < x0 {
+					x0 = x
+				}
+				if x > x1 {
+					x1 = x
+				}
+				if y < y0 {
+					y0 = y
+				}
+				y1 = y
+			}
+		}
+	}
+
+	var grid [][]byte
+	for y := y0; y <= y1; y++ {
+		grid = append(grid, []byte(rows[y][x0:x1+1]))
+	}
+	return grid
+}
+
+// transformGrid returns grid rotated by quarter turns t%4, mirrored when
+// t >= 4
+func transformGrid(grid [][]byte, t int) [][]byte {
+
+	for ; t%4 > 0; t-- {
+		h, w := len(grid), len(grid[0])
+		turned := make([][]byte, w)
+		for y := range turned {
+			turned[y] = make([]byte, h)
+			for x := range turned[y] {
+				turned[y][x] = grid[h-1-x][y]
+			}
+		}
+		grid = turned
+	}
+
+	if t >= 4 {
+		mirrored := make([][]byte, len(grid))
+		for y, row := range grid {
+			mirrored[y] = make([]byte, len(row))
+			for x, c := range row {
+				mirrored[y][len(row)-1-x] = c
+			}
+		}
+		grid = mirrored
+	}
+	return grid
+}
+
+// placePlayerFirst moves the player of grid to the first cell, in reading
+// order, of the area it can walk to
+func placePlayerFirst(grid [][]byte) {
+
+	px, py := -1, -1
+	for y, row := range grid {
+		for x, c := range row {
+			if c == '@' || c == '+' {
+				px, py = x, y
+			}
+		}
+	}
+	if px < 0 {
+		return
+	}
+
+	reach := make([][]bool, len(grid))
+	for y := range reach {
+		reach[y] = make([]bool, len(grid[y]))
+	}
+
+	stack := [][2]int{{px, py}}
+	reach[py][px] = true
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			x, y := c[0]+d[0], c[1]+d[1]
+			if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[y]) || reach[y][x] {
+				continue
+			}
+			if t := grid[y][x]; t == ' ' || t == '.' {
+				reach[y][x] = true
+				stack = append(stack, [2]int{x, y})
+			}
+		}
+	}
+
+	if grid[py][px] == '+' {
+		grid[py][px] = '.'
+	} else {
+		grid[py][px] = ' '
+	}
+
+	for y, row := range grid {
+		for x := range row {
+			if reach[y][x] {
+				if row[x] == '.' {
+					row[x] = '+'
+				} else {
+					row[x] = '@'
+				}
+				return
+			}
+		}
+	}
+}
+
+// canonicalGrid returns the rows of the normalized l in the orientation
+// giving the smallest text, with the player on the first cell of its area,
+// so that it is the same for levels that only differ by a rotation, a
+// mirroring or where the player stands
+func canonicalGrid(l Level) [][]byte {
+
+	grid := cropLevel(normalizeLevel(l))
+	if len(grid) == 0 {
+		return nil
+	}
+
+	var best [][]byte
+	key := ""
+	for t := 0; t < 8; t++ {
+		g := transformGrid(grid, t)
+		placePlayerFirst(g)
+
+		if k := gridKey(g); best == nil || k < key {
+			best, key = g, k
+		}
+	}
+	return best
+}
+
+func gridKey(grid [][]byte) string {
+
+	var b strings.Builder
+	for _, row := range grid {
+		b.WriteString(strings.TrimRight(string(row), " "))
+		b.WriteByte('|')
+	}
+	return b.String()
+}
+
+// canonicalLevelKey returns the same string for levels that are the same
+// once normalized, see canonicalGrid
+func canonicalLevelKey(l Level) string {
+
+	return gridKey(canonicalGrid(l))
+}
+
+// knownLevels maps the canonical key of every embedded level to its name
+func knownLevels() map[string]string {
+
+	known := make(map[string]string)
+	for n := 0; n <= LEVEL_MAX; n++ {
+		key := canonicalLevelKey(embeddedLevel(n))
+		if _, ok := known[key]; !ok {
+			known[key] = fmt.Sprintf("embedded level %d", n)
+		}
+	}
+	return known
+}
+
+// xsbLevelName names a level of a collection in messages
+func xsbLevelName(file string, n int, xl xsbLevel) string {
+
+	name := fmt.Sprintf("%s level %d", file, n)
+	if xl.title != "" {
+		name += fmt.Sprintf(" (%s)", xl.title)
+	}
+	return name
+}
+
+// findDuplicates returns, for every level of levels, the name of the known
+// level it duplicates or "" and adds the new ones to known
+func findDuplicates(known map[string]string, file string, levels []xsbLevel) ([]string, error) {
+
+	dups := make([]string, len(levels))
+
+	for i, xl := range levels {
+		l, err := xsbToLevel(xl.rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", file, xl.line, err)
+		}
+
+		key := canonicalLevelKey(l)
+		if name, ok := known[key]; ok {
+			dups[i] = name
+		} else {
+			known[key] = xsbLevelName(file, i, xl)
+		}
+	}
+
+	return dups, nil
+}
+
+// runFindDuplicates reports the duplicates in files and returns the number
+// found
+func runFindDuplicates(files []string) (int, error) {
+
+	known := knownLevels()
+	found := 0
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return found, err
+		}
+		levels, err := readXSBCollection(f)
+		f.Close()
+		if err != nil {
+			return found, fmt.Errorf("%s: %v", file, err)
+		}
+
+		dups, err := findDuplicates(known, file, levels)
+		if err != nil {
+			return found, err
+		}
+
+		n := 0
+		for i, name := range dups {
+			if name != "" {
+				fmt.Printf("%s is the same as %s\n", xsbLevelName(file, i, levels[i]), name)
+				n++
+			}
+		}
+		fmt.Printf("%s: %d levels, %d duplicates\n", file, len(levels), n)
+		found += n
+	}
+
+	return found, nil
+}
@@ -0,0 +1,146 @@
+// Sokoban game
+//
+// Game events: a GameState publishes what happens to it, and the features
+// that need to know (sounds, statistics, remote clients) subscribe to the
+// events instead of being called from Update.
+
+package main
+
+import "time"
+
+type GameEventKind int
+
+const (
+	LevelStarted GameEventKind = iota
+	MovePerformed
+	MoveUndone
+	BoxPlaced
+	DeadlockDetected
+	LevelCompleted
+	// the levels are now those of another pack: whatever is kept by level
+	// number belongs to the previous one
+	PackChanged
+)
+
+type GameEvent struct {
+	Kind  GameEventKind
+	State *GameState
+
+	// MovePerformed: the direction played, whether the player moved and
+	// pushed, and the number of boxes left before the move
+	Dir             byte
+	Moved, Pushed   bool
+	BoxesLeftBefore int
+
+	// BoxPlaced and DeadlockDetected: the box concerned
+	X, Y int
+}
+
+type gameEventHandler func(e GameEvent)
+
+type eventBus struct {
+	handlers map[GameEventKind][]gameEventHandler
+}
+
+func (b *eventBus) subscribe(kind GameEventKind, h gameEventHandler) {
+
+	if b.handlers == nil {
+		b.handlers = make(map[GameEventKind][]gameEventHandler)
+	}
+	b.handlers[kind] = append(b.handlers[kind], h)
+}
+
+// publish calls the handlers of e's kind, in the order they subscribed
+func (b *eventBus) publish(e GameEvent) {
+
+	for _, h := range b.handlers[e.Kind] {
+		h(e)
+	}
+}
+
+// subscribeGameFeatures connects the features of the windowed game to s
+func subscribeGameFeatures(s *GameState) {
+
+	s.events.subscribe(MovePerformed, func(e GameEvent) {
+		moveCue(e.State, e.Dir, e.Moved, e.Pushed, e.BoxesLeftBefore)
+	})
+	s.events.subscribe(DeadlockDetected, func(e GameEvent) { deadlockCue() })
+	s.events.subscribe(DeadlockDetected, deadlockWarning)
+
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { solvedTime = time.Since(e.State.levelStart) })
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { speedrunSplit(e.State) })
+	s.events.subscribe(LevelStarted, func(e GameEvent) { speedrunLevelStarted(e.State) })
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { recordCompletion(e.State) })
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { recordReplay(e.State) })
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { submitScore(e.State) })
+	s.events.subscribe(LevelStarted, func(e GameEvent) { challengeLevelStarted(e.State) })
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { challengeCompleted(e.State) })
+
+	s.events.subscribe(LevelStarted, func(e GameEvent) { heatLevelStarted(e.State) })
+	s.events.subscribe(MoveUndone, func(e GameEvent) { heatMoveUndone(e.State) })
+
+	s.events.subscribe(MovePerformed, pushDust)
+	s.events.subscribe(BoxPlaced, placeSparkles)
+	s.events.subscribe(BoxPlaced, boxCounterPlaced)
+	s.events.subscribe(LevelCompleted, levelConfetti)
+
+	s.events.subscribe(MovePerformed, onboardingMoved)
+	s.events.subscribe(MoveUndone, onboardingUndone)
+
+	s.events.subscribe(MovePerformed, sittingMoved)
+	s.events.subscribe(MoveUndone, sittingUndone)
+	s.events.subscribe(LevelCompleted, sittingLevelSolved)
+
+	s.events.subscribe(LevelStarted, recentLevelStarted)
+
+	s.events.subscribe(LevelStarted, func(e GameEvent) { forgetHint() })
+	s.events.subscribe(MoveUndone, func(e GameEvent) { forgetHint() })
+	s.events.subscribe(MovePerformed, hintMoved)
+
+	s.events.subscribe(LevelStarted, blindLevelStarted)
+
+	s.events.subscribe(LevelStarted, func(e GameEvent) { forgetStaticBoard() })
+
+	// caches and records kept by level number
+	s.events.subscribe(PackChanged, thumbnailsPackChanged)
+	s.events.subscribe(PackChanged, levelInfoPackChanged)
+	s.events.subscribe(PackChanged, difficultyPackChanged)
+	s.events.subscribe(PackChanged, heatPackChanged)
+	s.events.subscribe(PackChanged, backgroundPackChanged)
+	s.events.subscribe(PackChanged, cameraPackChanged)
+	s.events.subscribe(PackChanged, func(e GameEvent) { forgetStaticBoard() })
+	s.events.subscribe(PackChanged, transitionPackChanged)
+	s.events.subscribe(PackChanged, labelsPackChanged)
+	s.events.subscribe(PackChanged, introPackChanged)
+	s.events.subscribe(PackChanged, deadlockPackChanged)
+	s.events.subscribe(PackChanged, counterPackChanged)
+	s.events.subscribe(PackChanged, challengePackChanged)
+	s.events.subscribe(PackChanged, quickSavePackChanged)
+	s.events.subscribe(PackChanged, speedrunPackChanged)
+	s.events.subscribe(PackChanged, leaderboardPackChanged)
+	s.events.subscribe(PackChanged, hubPackChanged)
+	s.events.subscribe(PackChanged, hoverPackChanged)
+	s.events.subscribe(PackChanged, playbackPackChanged)
+
+	s.events.subscribe(BoxPlaced, kidStars)
+	s.events.subscribe(LevelCompleted, kidLevelCompleted)
+	s.events.subscribe(DeadlockDetected, kidDeadlock)
+
+	s.events.subscribe(LevelStarted, tutorialLevelStarted)
+
+	s.events.subscribe(MovePerformed, macroMoved)
+	s.events.subscribe(MoveUndone, macroUndone)
+	s.events.subscribe(LevelStarted, macroLevelStarted)
+
+	s.events.subscribe(LevelStarted, historyLevelStarted)
+	s.events.subscribe(MovePerformed, historyMoved)
+	s.events.subscribe(MoveUndone, historyUndone)
+
+	s.events.subscribe(LevelStarted, reachLevelStarted)
+	s.events.subscribe(MovePerformed, reachMoved)
+	s.events.subscribe(MoveUndone, reachUndone)
+
+	for _, kind := range []GameEventKind{LevelStarted, MovePerformed, MoveUndone} {
+		s.events.subscribe(kind, func(e GameEvent) { broadcastState(e.State) })
+	}
+}
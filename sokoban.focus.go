@@ -0,0 +1,52 @@
+// Sokoban game
+//
+// Pause while the window is not focused: the level timer stops and the
+// keyboard and mouse are ignored until the window gets the focus back.
+// Remote commands still run, as streamers and bots usually drive the game
+// from another window.
+
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	focusPaused = false
+	pausedAt    time.Time
+)
+
+// updateFocus returns true while the game is paused
+func updateFocus(s *GameState, in InputSource) bool {
+
+	if !in.IsFocused() {
+		if !focusPaused {
+			focusPaused = true
+			pausedAt = time.Now()
+		}
+		return true
+	}
+
+	if focusPaused {
+		focusPaused = false
+		// a break going on already stops the clocks for that long
+		if !breakPaused {
+			s.levelStart = s.levelStart.Add(time.Since(pausedAt))
+			speedrunPaused(time.Since(pausedAt))
+		}
+		// skip the frame in which the focus came back, its clicks and
+		// keys were meant for switching windows
+		return true
+	}
+
+	return false
+}
+
+func drawPaused(screen *ebiten.Image) {
+
+	if focusPaused {
+		drawText(screen, "Paused", screenWidth/2-18, 20)
+	}
+}
@@ -9,15 +9,18 @@ package main
 import (
 	_ "embed"
 	"bytes"
+	"errors"
 	"log"
 	"fmt"
 	"image"
 	"image/png"
+	"os"
+	"flag"
 	"time"
+	"sync"
+	"strings"
 	
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
 type screenZone struct {
@@ -26,16 +29,52 @@ type screenZone struct {
 }
 
 type Level struct {
-	w, h byte
+	w, h int
 	px, py int     // player coordinates
 	psprite byte
 	zfactor float64 // zoom factor (same for horizontal and vertical)
 	sx, sy float64  // screen offset to center level
 	grid [][]byte
+	boxesLeft int   // boxes not on a goal, kept up to date by move
+}
+
+// clone returns a copy of l that does not share its grid
+func (l Level) clone() Level {
+
+	c := l
+	c.grid = make([][]byte, len(l.grid))
+	for i := range l.grid {
+		c.grid[i] = append([]byte(nil), l.grid[i]...)
+	}
+	return c
 }
 
 type Game struct {
  	pressedKeys []ebiten.Key
+	state *GameState
+	// where Update reads the player's input, ebiten when nil
+	input InputSource
+	// what Draw draws with
+	sheets *spriteSheets
+}
+
+// GameState is a level being played and the moves played in it
+type GameState struct {
+	currentLevelNumber int
+	curLev Level
+	// stack of the moves that have been played to enable undo
+	moves []moveRecord
+	pushCount int
+	// moves dropped by the undo limit, see compactHistory
+	baseMoves int
+	levelStart time.Time
+	// the solver played some of the moves
+	assisted bool
+
+	// attempts at other levels, see switchLevel
+	sessions map[int]*levelSession
+
+	events eventBus
 }
 
 const (
@@ -44,16 +83,17 @@ const (
 
 	LEVEL_MAX = 62
 
-	EMPTY = 89
-	WALL = 98
-	BOX = 6
-	PLACED_BOX = 9
-	GOAL = 102
+	// tiles, see tileAtlas for where they are in the tile sheet
+	EMPTY = iota
+	WALL
+	BOX
+	PLACED_BOX
+	GOAL
 
-	PLAYERUP = 55
-	PLAYERDN = 52
-	PLAYERRI = 78
-	PLAYERLE = 81
+	PLAYERUP
+	PLAYERDN
+	PLAYERRI
+	PLAYERLE
 
 	UP byte = iota
 	RIGHT
@@ -61,12 +101,6 @@ const (
 	LEFT
 )
 
-// |        ground wall box boxgoal groundgoal
-// #sprites 89     98   6   9       102
-// 
-// |       playerup playerdn playerri playerle
-// #player 55       52       78       81
-
 //go:embed "sokoban_tilesheet.png"
 var spritePNG []byte
 
@@ -85,15 +119,11 @@ var (
 	nextScreenZone = screenZone     { 20, 10, 20, 1}
 	previousScreenZone = screenZone { 20, 10, 19, 1}
 
- 	tileSheet *ebiten.Image
- 	iconsSheet *ebiten.Image
- 
-	// stack of the moves that have been played to enable undo
-	moves []byte
-	currentLevelNumber = 0
-	curLev Level
-
 	prevUpdateTime    = time.Now()
+
+	// short message shown for a few seconds under the level number
+	statusMessage      string
+	statusMessageUntil time.Time
 )
 
 func prepareSpriteSheet(PNG []byte) *ebiten.Image {
@@ -117,35 +147,51 @@ func prepareSpriteSheet(PNG []byte) *ebiten.Image {
 	return tileSheet
 }
 
-func init() {
+// cutSpriteSheet returns the sub images of sheet, cut in w x h sprites
+func cutSpriteSheet(sheet *ebiten.Image, w int, h int) [][]*ebiten.Image {
 
-	// sokoban sprites
-	tileSheet = prepareSpriteSheet(spritePNG)
-	
-	// icon sprites
-	iconsSheet = prepareSpriteSheet(iconsPNG)
+	sheetW, sheetH := sheet.Size()
+
+	sprites := make([][]*ebiten.Image, sheetW/w)
+	for i := range sprites {
+		sprites[i] = make([]*ebiten.Image, sheetH/h)
+		for j := range sprites[i] {
+			sprites[i][j] = sheet.SubImage(image.Rect(i*w, j*h, (i+1)*w, (j+1)*h)).(*ebiten.Image)
+		}
+	}
 
-	// decompress current level
-	curLev = decompressLevel(levels[currentLevelNumber])
+	return sprites
+}
+
+func showMessage(msg string) {
+
+	statusMessage = msg
+	statusMessageUntil = time.Now().Add(3 * time.Second)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return screenWidth, screenHeight
 }
 
-func handleMove(dx int, dy int) {
+func (s *GameState) handleMove(dx int, dy int) (bool, bool) {
+	return s.curLev.move(dx, dy)
+}
 
-	moveOnce := int(curLev.grid[curLev.px+dx][curLev.py+dy])
+// move tells if the player moved and if it pushed a box
+func (l *Level) move(dx int, dy int) (bool, bool) {
+
+	moveOnce := int(l.grid[l.px+dx][l.py+dy])
 	
 	if moveOnce == EMPTY || moveOnce == GOAL {
 		// just move the player in the grid
-		curLev.px += dx
-		curLev.py += dy
+		l.px += dx
+		l.py += dy
+		return true, false
 		
 	} else if moveOnce == BOX || moveOnce == PLACED_BOX {
 		var saveTile byte
 		
- 		moveTwice := int(curLev.grid[curLev.px+2*dx][curLev.py+2*dy])
+ 		moveTwice := int(l.grid[l.px+2*dx][l.py+2*dy])
 
 		saveTile=EMPTY
 		
@@ -154,34 +200,317 @@ func handleMove(dx int, dy int) {
 		}
 		
  		if moveTwice == EMPTY {
-			curLev.grid[curLev.px+dx][curLev.py+dy] = saveTile
- 			curLev.grid[curLev.px+2*dx][curLev.py+2*dy] = BOX
-			curLev.px += dx
-			curLev.py += dy
+			l.grid[l.px+dx][l.py+dy] = saveTile
+ 			l.grid[l.px+2*dx][l.py+2*dy] = BOX
+			l.px += dx
+			l.py += dy
+			if moveOnce == PLACED_BOX {
+				l.boxesLeft++
+			}
+			return true, true
  		} else if moveTwice == GOAL {
- 			curLev.grid[curLev.px+dx][curLev.py+dy] = saveTile
- 			curLev.grid[curLev.px+2*dx][curLev.py+2*dy] = PLACED_BOX
-			curLev.px += dx
-			curLev.py += dy
+ 			l.grid[l.px+dx][l.py+dy] = saveTile
+ 			l.grid[l.px+2*dx][l.py+2*dy] = PLACED_BOX
+			l.px += dx
+			l.py += dy
+			if moveOnce == BOX {
+				l.boxesLeft--
+			}
+			return true, true
  		} 
  	}
+
+	return false, false
+}
+
+// applyMove turns the player and moves it in direction d
+func (s *GameState) applyMove(d byte) (bool, bool) {
+	return s.curLev.play(d)
 }
 
-func nBoxesLeft() int {
+func (l *Level) play(d byte) (bool, bool) {
 
-	w, h := curLev.w, curLev.h
+	l.psprite = playerSprite(d)
 
-	boxesLeft:=0
-	
-	for i:=0; i<int(w); i++ {
-		for j:=0; j<int(h); j++ {
-			if curLev.grid[i][j] == BOX {
-				boxesLeft++
-			}
+	dx, dy := dirDelta(d)
+	return l.move(dx, dy)
+}
+
+// pull moves the player in direction d, away from the box behind it, which
+// follows; it tells if the player moved and if a box came along
+func (l *Level) pull(d byte) (bool, bool) {
+
+	dx, dy := dirDelta(d)
+	if t := l.grid[l.px+dx][l.py+dy]; t != EMPTY && t != GOAL {
+		return false, false
+	}
+	l.psprite = playerSprite(d)
+
+	bx, by := l.px-dx, l.py-dy
+	box := l.grid[bx][by]
+	if box != BOX && box != PLACED_BOX {
+		l.px += dx
+		l.py += dy
+		return true, false
+	}
+
+	if box == PLACED_BOX {
+		l.grid[bx][by] = GOAL
+		l.boxesLeft++
+	} else {
+		l.grid[bx][by] = EMPTY
+	}
+	if l.grid[l.px][l.py] == GOAL {
+		l.grid[l.px][l.py] = PLACED_BOX
+		l.boxesLeft--
+	} else {
+		l.grid[l.px][l.py] = BOX
+	}
+	l.px += dx
+	l.py += dy
+	return true, true
+}
+
+// playerSprite returns the player facing direction d
+func playerSprite(d byte) byte {
+
+	switch d {
+	case RIGHT:
+		return PLAYERRI
+	case LEFT:
+		return PLAYERLE
+	case DOWN:
+		return PLAYERDN
+	}
+	return PLAYERUP
+}
+
+// a move of the undo stack
+type moveRecord struct {
+	dir    byte
+	pushed bool
+	left   byte // with a push, the tile the box left: EMPTY or GOAL
+	pulled bool // the box behind the player followed it, see pull
+}
+
+// unplay takes back move m, the last one played on l
+func (l *Level) unplay(m moveRecord) {
+
+	dx, dy := dirDelta(m.dir)
+
+	if m.pulled {
+		// the box goes back behind the cell the player goes back to
+		bx, by := l.px-dx, l.py-dy
+		if l.grid[bx][by] == PLACED_BOX {
+			l.grid[bx][by] = GOAL
+		} else {
+			l.grid[bx][by] = EMPTY
+			l.boxesLeft--
+		}
+		if l.grid[bx-dx][by-dy] == GOAL {
+			l.grid[bx-dx][by-dy] = PLACED_BOX
+		} else {
+			l.grid[bx-dx][by-dy] = BOX
+			l.boxesLeft++
+		}
+	}
+
+	if m.pushed {
+		bx, by := l.px+dx, l.py+dy
+		if l.grid[bx][by] == BOX {
+			l.boxesLeft--
+		}
+		if l.grid[bx][by] == PLACED_BOX {
+			l.grid[bx][by] = GOAL
+		} else {
+			l.grid[bx][by] = EMPTY
 		}
+
+		if m.left == GOAL {
+			l.grid[l.px][l.py] = PLACED_BOX
+		} else {
+			l.grid[l.px][l.py] = BOX
+			l.boxesLeft++
+		}
+	}
+
+	l.px -= dx
+	l.py -= dy
+}
+
+// playMove applies the move and, unless the player bumped into something,
+// records it on the undo stack
+func (s *GameState) playMove(d byte) {
+
+	left := s.curLev.boxesLeft
+	moved, pushed := s.applyMove(d)
+
+	if moved {
+		// the player stands where the box was, on the tile it left
+		s.moves = append(s.moves, moveRecord{d, pushed, s.curLev.grid[s.curLev.px][s.curLev.py], false})
+		s.compactHistory()
 	}
+	if pushed {
+		s.pushCount++
+	}
+
+	s.events.publish(GameEvent{Kind: MovePerformed, State: s, Dir: d, Moved: moved, Pushed: pushed, BoxesLeftBefore: left})
+
+	if pushed {
+		dx, dy := dirDelta(d)
+		x, y := s.curLev.px+dx, s.curLev.py+dy
 
-	return(boxesLeft)
+		if s.curLev.boxesLeft < left {
+			s.events.publish(GameEvent{Kind: BoxPlaced, State: s, X: x, Y: y})
+		}
+		if s.curLev.boxesLeft == 0 {
+			s.events.publish(GameEvent{Kind: LevelCompleted, State: s})
+		} else if pushDeadlock(s.curLev, x, y) {
+			s.events.publish(GameEvent{Kind: DeadlockDetected, State: s, X: x, Y: y})
+		}
+	}
+}
+
+// pullMove pulls the box behind the player in direction d, the pull being
+// recorded on the undo stack like a move; a level played with pulls is
+// assisted, its moves no longer being a solution
+func (s *GameState) pullMove(d byte) {
+
+	left := s.curLev.boxesLeft
+	moved, pulled := s.curLev.pull(d)
+
+	if moved {
+		s.moves = append(s.moves, moveRecord{d, false, EMPTY, pulled})
+		s.compactHistory()
+	}
+	if pulled {
+		s.assisted = true
+	}
+
+	s.events.publish(GameEvent{Kind: MovePerformed, State: s, Dir: d, Moved: moved, BoxesLeftBefore: left})
+
+	if pulled {
+		dx, dy := dirDelta(d)
+		x, y := s.curLev.px-dx, s.curLev.py-dy
+
+		if s.curLev.boxesLeft < left {
+			s.events.publish(GameEvent{Kind: BoxPlaced, State: s, X: x, Y: y})
+		}
+		if s.curLev.boxesLeft == 0 {
+			s.events.publish(GameEvent{Kind: LevelCompleted, State: s})
+		}
+	}
+}
+
+// hasPulls tells if a box of the level was pulled, the moves then not
+// replaying to the position
+func (s *GameState) hasPulls() bool {
+
+	for _, m := range s.moves {
+		if m.pulled {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *GameState) undoMove() {
+
+	if len(s.moves) == 0 {
+		if !s.historyComplete() {
+			showMessage("undo limit reached")
+		}
+		return
+	}
+
+	m := s.moves[len(s.moves)-1]
+	s.moves = s.moves[:len(s.moves)-1]
+
+	s.curLev.unplay(m)
+	if m.pushed {
+		s.pushCount--
+	}
+
+	// face the way of the previous move
+	s.curLev.psprite = PLAYERUP
+	if len(s.moves) > 0 {
+		s.curLev.psprite = playerSprite(s.moves[len(s.moves)-1].dir)
+	}
+
+	s.events.publish(GameEvent{Kind: MoveUndone, State: s})
+}
+
+// undoPush takes back the moves played since the last push and that push
+func (s *GameState) undoPush() {
+
+	for len(s.moves) > 0 {
+		pushed := s.moves[len(s.moves)-1].pushed
+		s.undoMove()
+		if pushed {
+			return
+		}
+	}
+
+	if !s.historyComplete() {
+		showMessage("undo limit reached")
+	}
+}
+
+// newGameState returns a game starting at level n
+func newGameState(n int) *GameState {
+
+	s := &GameState{}
+	s.loadLevel(n)
+	return s
+}
+
+func clampLevel(n int) int {
+
+	if n < 0 {
+		return 0
+	}
+	if n > lastLevel() {
+		return lastLevel()
+	}
+	return n
+}
+
+// loadLevel starts level n from scratch, n being clamped to the existing levels
+func (s *GameState) loadLevel(n int) {
+	s.enterLevel(n, false)
+}
+
+// enterLevel starts level n, or resumes the attempt left there
+func (s *GameState) enterLevel(n int, resume bool) {
+
+	n = clampLevel(n)
+
+	if n != s.currentLevelNumber {
+		s.stashSession()
+	}
+	sess := s.takeSession(n)
+
+	s.currentLevelNumber = n
+
+	if resume && sess != nil {
+		s.resumeSession(sess)
+	} else {
+		s.curLev = levelTemplate(s.currentLevelNumber)
+		if reverseMode {
+			s.curLev = reverseLevel(s.curLev)
+		}
+		s.moves = nil
+		s.pushCount = 0
+		s.baseMoves = 0
+		s.levelStart = time.Now()
+		s.assisted = false
+	}
+
+	s.events.publish(GameEvent{Kind: LevelStarted, State: s})
+}
+
+func (s *GameState) nBoxesLeft() int {
+	return s.curLev.boxesLeft
 }
 
 func screenZoneCoords(z screenZone) (int,int,int,int) {
@@ -220,20 +549,30 @@ func inScreenZone(z screenZone, xEvent int, yEvent int) bool {
 
 func (g *Game) Update() error {
 
+	s := g.state
+	in := g.input
+	if in == nil {
+		in = ebitenInput{}
+	}
+	in = controlInput(in)
+
+	updateIdle(s, in)
+	updateParticles()
+
 	mouseOrTouch := false
 	eventX, eventY := 0, 0
 
 	// mouse
-	xm, ym := ebiten.CursorPosition()
-	pressedLeft := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	xm, ym := in.CursorPosition()
+	pressedLeft := in.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
 
 	// touch events
-	touches := inpututil.AppendJustPressedTouchIDs(nil)
+	touches := in.AppendJustPressedTouchIDs(nil)
 	xt, yt := -1, -1
 	touched := false
 	
 	if len(touches) > 0 {
-		xt, yt = ebiten.TouchPosition(touches[0])
+		xt, yt = in.TouchPosition(touches[0])
 		touched = true
 	}
 
@@ -251,93 +590,150 @@ func (g *Game) Update() error {
 
 	prevUpdateTime = time.Now()
 
+	updateWindowTitle(s)
+	updateWindowOptions(in)
+
+	applyRemoteCommands(s)
+	updateAudioCues(s, in)
+	updateZen(s)
+
+	if shown, err := updateSitting(in, mouseOrTouch); shown {
+		return err
+	}
+
+	if updateFocus(s, in) {
+		return nil
+	}
+
+	if updateBreak(s, in, mouseOrTouch) {
+		return nil
+	}
+
+	if updateTitle(s, in, mouseOrTouch, eventY) {
+		return nil
+	}
+
+	if updateIntro(s, in, mouseOrTouch) {
+		return nil
+	}
+
+	if updateChallengeOutcome(in, mouseOrTouch) {
+		return nil
+	}
+
+	if updateConfirm(in, mouseOrTouch, eventX, eventY) {
+		return nil
+	}
+
+	if updateOnboarding(s, in, mouseOrTouch) {
+		return nil
+	}
+
+	if updateHelp(in, mouseOrTouch, eventX, eventY) {
+		return nil
+	}
+
+	if updateSettings(in, mouseOrTouch, eventX, eventY) {
+		return nil
+	}
+
+	if updateLevelSelect(s, in, mouseOrTouch, eventX, eventY) {
+		return nil
+	}
+
+	if updateReplayBrowser(s, in) {
+		return nil
+	}
+
+	if updatePackBrowser(s, in, mouseOrTouch, eventY) {
+		return nil
+	}
+
+	if updateJumpPrompt(s, in) {
+		return nil
+	}
+
+	if updateNoteEntry(s, in) {
+		return nil
+	}
+
+	if updateTutorial(s, in, mouseOrTouch) {
+		return nil
+	}
+
+	if updateSolver(s, in, mouseOrTouch, eventX, eventY) {
+		return nil
+	}
+
+	if updateAutoFinish(s, in) {
+		return nil
+	}
+
+	updateLevelInfo(in)
+	updateHints(s, in)
+	updateBlindfold(s, in)
+	updateHeatmap(in)
+	updateAnalysis(in)
+	updateQuickSave(s, in)
+	updateMacro(s, in)
+	updatePositionExport(s, in)
+	updateCamera(s, in)
+
+	if updateSolved(s, in, mouseOrTouch, eventX, eventY) {
+		return nil
+	}
+
+	if updateSendBox(s, in) {
+		return nil
+	}
+
+	updateView(s, in)
+
+	if updateClickMove(s, in, mouseOrTouch, eventX, eventY) {
+		return nil
+	}
+
 	// the below style of keyboard input takes care of key repetition
-        if inpututil.IsKeyJustPressed(ebiten.KeyPageUp) || (mouseOrTouch && inScreenZone(nextScreenZone,eventX, eventY)){
-		currentLevelNumber++
-		if currentLevelNumber > LEVEL_MAX {
-			currentLevelNumber = LEVEL_MAX
-		}
-		l := decompressLevel(levels[currentLevelNumber])
-		moves = nil
-		curLev = l
+        if in.IsKeyJustPressed(ebiten.KeyPageUp) || (mouseOrTouch && inScreenZone(nextScreenZone,eventX, eventY)){
+		s.switchLevel(stepLevel(s.currentLevelNumber, 1))
         }
 	
-	if inpututil.IsKeyJustPressed(ebiten.KeyPageDown) || (mouseOrTouch && inScreenZone(previousScreenZone,eventX, eventY)) {
-		currentLevelNumber--
-		if currentLevelNumber<0 {
-			currentLevelNumber=0
-		}
-		l := decompressLevel(levels[currentLevelNumber])
-		moves = nil
-		curLev = l
+	if in.IsKeyJustPressed(ebiten.KeyPageDown) || (mouseOrTouch && inScreenZone(previousScreenZone,eventX, eventY)) {
+		s.switchLevel(stepLevel(s.currentLevelNumber, -1))
         }
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) || ( mouseOrTouch && inScreenZone(undoScreenZone,eventX, eventY)) {
-
-		// UNDO
-		if len(moves)>0 {
-			// get original level data
-			l := decompressLevel(levels[currentLevelNumber])
-			curLev = l
-
-			// replay all moves but the very last one
-			for i:=0;i<len(moves)-1;i++ {
-				if moves[i]==RIGHT {
-					curLev.psprite = PLAYERRI
-					handleMove(1,0)
-				} else if moves[i]==LEFT {
-					curLev.psprite = PLAYERLE
-					handleMove(-1,0)
-				} else if moves[i]==UP {
-					curLev.psprite = PLAYERUP
-					handleMove(0,-1)
-				} else if moves[i]==DOWN {
-					curLev.psprite = PLAYERDN
-					handleMove(0,1)
-				}
-			}
-			// remove the last move
-			moves = moves[:len(moves)-1]
-		}
+	if in.IsKeyJustPressed(ebiten.KeyHome) {
+		confirmDiscard(s, "Restart the level?", func() { s.loadLevel(s.currentLevelNumber) })
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyBackspace) && in.IsKeyPressed(ebiten.KeyShift) {
+		s.undoPush()
+	} else if in.IsKeyJustPressed(ebiten.KeyBackspace) || ( mouseOrTouch && inScreenZone(undoScreenZone,eventX, eventY)) {
+		s.undoMove()
         }
 	
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) || (mouseOrTouch && inScreenZone(rightScreenZone,eventX, eventY) ) {
-		
-		curLev.psprite = PLAYERRI
-		moves=append(moves, RIGHT)
-		handleMove(1,0)
+	if in.IsKeyJustPressed(ebiten.KeyArrowRight) || (mouseOrTouch && inScreenZone(rightScreenZone,eventX, eventY) ) {
+		moveOrPull(s, in, levelDir(RIGHT, camera.view))
         }
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) || (mouseOrTouch && inScreenZone(leftScreenZone,eventX, eventY) ) {
-		curLev.psprite = PLAYERLE
-		moves=append(moves, LEFT)
-		handleMove(-1,0)
+	if in.IsKeyJustPressed(ebiten.KeyArrowLeft) || (mouseOrTouch && inScreenZone(leftScreenZone,eventX, eventY) ) {
+		moveOrPull(s, in, levelDir(LEFT, camera.view))
         }
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) || (mouseOrTouch && inScreenZone(upScreenZone,eventX, eventY)) {
-		curLev.psprite = PLAYERUP
-		moves=append(moves, UP)
-		handleMove(0,-1)
+	if in.IsKeyJustPressed(ebiten.KeyArrowUp) || (mouseOrTouch && inScreenZone(upScreenZone,eventX, eventY)) {
+		moveOrPull(s, in, levelDir(UP, camera.view))
         }
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) || (mouseOrTouch && inScreenZone(downScreenZone,eventX, eventY)) {
-		curLev.psprite = PLAYERDN
-		moves=append(moves, DOWN)
-		handleMove(0,1)
+	if in.IsKeyJustPressed(ebiten.KeyArrowDown) || (mouseOrTouch && inScreenZone(downScreenZone,eventX, eventY)) {
+		moveOrPull(s, in, levelDir(DOWN, camera.view))
         }
 
 	//
-	if nBoxesLeft() == 0 {
-		currentLevelNumber++
-		if currentLevelNumber > LEVEL_MAX {
-			currentLevelNumber = LEVEL_MAX
-		}
-		l := decompressLevel(levels[currentLevelNumber])
-		moves = nil
-		curLev = l
+	if s.nBoxesLeft() == 0 && !stayOnSolved {
+		s.switchLevel(stepLevel(s.currentLevelNumber, 1))
 	}
 
 	return nil
 }
 
-func drawIcon(screen *ebiten.Image, iconNumber int, z screenZone, x int, y int) {
+func (sh *spriteSheets) drawIcon(screen *ebiten.Image, iconNumber int, z screenZone, x int, y int) {
 
 	yIcon := iconNumber % 20
 	xIcon := iconNumber / 20
@@ -350,58 +746,146 @@ func drawIcon(screen *ebiten.Image, iconNumber int, z screenZone, x int, y int)
 	op.GeoM.Scale((float64(xMax-xMin))/100,(float64(yMax-yMin))/100)
         op.GeoM.Translate(float64(xMin),float64(yMin))
 	
-	screen.DrawImage(iconsSheet.SubImage(image.Rect(xIcon*100, yIcon*100, (1+xIcon)*100, (1+yIcon)*100)).(*ebiten.Image), op)
+	screen.DrawImage(sh.icons[xIcon][yIcon], op)
 }
 
-func drawSprite(screen *ebiten.Image, x int, y int, num int, startX float64, startY float64, factor float64, spriteW int, spriteH int) {
+func (sh *spriteSheets) drawSprite(screen *ebiten.Image, x int, y int, num int, startX float64, startY float64, factor float64, spriteW int, spriteH int) {
 
-	// compute sprite number -> coordinates
-	i := num % 13
-	j := num / 13
+	// the sheet closest to the size of the sprite on screen
+	sheet := sh.tileSheetFor(float64(spriteW)*factor)
+	scale := factor*float64(spriteW)/float64(sheet.size)
 
 	op := &ebiten.DrawImageOptions{}
 
-	op.GeoM.Scale(factor,factor)
+	op.GeoM.Scale(scale,scale)
         op.GeoM.Translate(startX+float64(x)*float64(spriteW)*factor,startY+float64(y)*float64(spriteH)*factor)
 	
-	screen.DrawImage(tileSheet.SubImage(image.Rect(i*spriteW,j*spriteH,(i+1)*spriteW,(j+1)*spriteH)).(*ebiten.Image), op)
+	screen.DrawImage(sheet.sprites[num], op)
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
+// drawBoard draws l as large as it fits in the rectangle x, y, w, h
+func (sh *spriteSheets) drawBoard(screen *ebiten.Image, l Level, x float64, y float64, w float64, h float64) {
+
+	width := 64.0 * float64(l.w)
+	height := 64.0 * float64(l.h)
+
+	factor := w / width
+	if h/height < factor {
+		factor = h / height
+	}
 
-	// draw the curLev
-	w, h := curLev.w, curLev.h
+	sx := x + (w-factor*width)/2
+	sy := y + (h-factor*height)/2
 
-	cell:=0
-	for i:=0; i<int(w); i++ {
-		for j:=0; j<int(h); j++ {
-			drawSprite(screen, i, j, EMPTY, curLev.sx, curLev.sy, curLev.zfactor, 64.0, 64.0)
-			drawSprite(screen, i, j, int(curLev.grid[i][j]), curLev.sx, curLev.sy, curLev.zfactor, 64.0, 64.0)
-			cell++
+	for i := 0; i < l.w; i++ {
+		for j := 0; j < l.h; j++ {
+			sh.drawSprite(screen, i, j, EMPTY, sx, sy, factor, 64.0, 64.0)
+			sh.drawSprite(screen, i, j, int(l.grid[i][j]), sx, sy, factor, 64.0, 64.0)
 		}
 	}
 
-	// Draw the player
+	sh.drawSprite(screen, l.px, l.py, int(l.psprite), sx, sy, factor, 64.0, 64.0)
+}
 
-	drawSprite(screen, int(curLev.px), int(curLev.py), int(curLev.psprite), curLev.sx, curLev.sy, curLev.zfactor, 64.0, 64.0)
-	
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("Current level: %2d (fps: %0.2f)", currentLevelNumber, ebiten.CurrentTPS()))
+func (g *Game) Draw(screen *ebiten.Image) {
+
+	s, sh := g.state, g.sheets
+
+	if skipDraw() {
+		return
+	}
+
+	if titleOpen {
+		drawTitle(screen)
+		drawSitting(screen)
+		return
+	}
+
+	if settingsOpen {
+		drawSettings(screen)
+		return
+	}
 
-	// To draw frames per second
-	//	const x = 20
-	//	msg := fmt.Sprintf("TPS: %0.2f", ebiten.CurrentTPS())
-	//	text.Draw(screen, msg, mplusNormalFont, x, 40, color.White)
+	if levelSelectOpen {
+		drawLevelSelect(screen, sh)
+		return
+	}
+
+	if replayBrowserOpen {
+		drawReplayBrowser(screen, sh)
+		return
+	}
+
+	if packBrowserOpen {
+		drawPackBrowser(screen, sh)
+		return
+	}
+
+	// draw the level and the player
+	drawBackground(screen, s, sh)
+	drawCurrentLevel(screen, s, sh)
+	drawTransition(screen, s)
+	drawParticles(screen)
+	
+	if !zenMode {
+		drawText(screen, fmt.Sprintf("Current level: %2d", s.currentLevelNumber), 0, 0)
+		if msg := shuffleStatus(s.currentLevelNumber); msg != "" {
+			drawText(screen, msg, 0, 16)
+		}
+	}
 
 	// draw icons: left, right, up, down next level, prev level, undo
 
-	drawIcon(screen, 45, undoScreenZone, 0, 0)
-	drawIcon(screen, 9, upScreenZone, 0, 0)
-	drawIcon(screen, 10, rightScreenZone, 0, 0)
-	drawIcon(screen, 11, leftScreenZone, 0, 0)
-	drawIcon(screen, 12, downScreenZone, 0, 0)
+	sh.drawIcon(screen, 45, undoScreenZone, 0, 0)
+	sh.drawIcon(screen, 77, settingsScreenZone, 0, 0)
+	sh.drawIcon(screen, 46, helpScreenZone, 0, 0)
+	sh.drawIcon(screen, 9, upScreenZone, 0, 0)
+	sh.drawIcon(screen, 10, rightScreenZone, 0, 0)
+	sh.drawIcon(screen, 11, leftScreenZone, 0, 0)
+	sh.drawIcon(screen, 12, downScreenZone, 0, 0)
+
+	sh.drawIcon(screen, 83, nextScreenZone, 0, 0)
+	sh.drawIcon(screen, 44, previousScreenZone, 0, 0)
 
-	drawIcon(screen, 83, nextScreenZone, 0, 0)
-	drawIcon(screen, 44, previousScreenZone, 0, 0)
+	if statusMessage != "" && time.Now().Before(statusMessageUntil) {
+		drawText(screen, statusMessage, 20, 40)
+	}
+
+	drawReach(screen, s)
+	drawLabels(screen, s)
+	if !zenMode {
+		drawBoxCounter(screen, s)
+	}
+	drawHeatmap(screen, s)
+	drawAnalysis(screen, s)
+	drawHint(screen, s)
+	drawBlindfold(screen, s)
+	drawReverse(screen, s)
+	drawMacro(screen)
+	drawPathPreview(screen, s)
+	drawSendBox(screen, s)
+	drawLevelInfo(screen, s)
+	drawAutoFinishOffer(screen)
+	drawSolverOverlay(screen, s, sh)
+	drawTutorial(screen, s)
+	drawReplayNote(screen)
+	drawJumpPrompt(screen)
+	drawNoteEntry(screen, s)
+	drawSolvedSummary(screen, s)
+	drawKidBanner(screen)
+	drawIntro(screen, s)
+	drawConfirm(screen, s)
+	if !zenMode {
+		drawSpeedrun(screen)
+		drawChallenge(screen, s)
+	}
+	drawOnboarding(screen)
+	drawHelp(screen)
+	drawUpdateNotice(screen)
+	drawFPSOverlay(screen)
+	drawBreakReminder(screen)
+	drawPaused(screen)
+	drawSitting(screen)
 }
 
 //|  -- Format of the compressed levels ( RLE style )
@@ -422,15 +906,61 @@ func (g *Game) Draw(screen *ebiten.Image) {
 //|         char man_x
 //|         char man_y
 
-func decompressLevel(level []byte) Level {
+var (
+	// decompressed levels, never modified
+	levelTemplates     = make(map[int]Level)
+	levelTemplatesLock sync.Mutex
+)
+
+// levelTemplate returns a copy of level n of the pack being played as it
+// starts
+func levelTemplate(n int) Level {
+
+	if currentPack != nil {
+		return currentPack.levels[n].clone()
+	}
+	return embeddedLevel(n)
+}
+
+// embeddedLevel returns a copy of embedded level n as it starts,
+// decompressing it only the first time
+func embeddedLevel(n int) Level {
+
+	levelTemplatesLock.Lock()
+	defer levelTemplatesLock.Unlock()
+
+	l, ok := levelTemplates[n]
+	if !ok {
+		var err error
+		if l, err = decompressLevel(levels[n]); err != nil {
+			// the embedded levels are checked with -verifypack
+			panic(fmt.Sprintf("embedded level %d: %v", n, err))
+		}
+		levelTemplates[n] = l
+	}
+	return l.clone()
+}
+
+func decompressLevel(level []byte) (Level, error) {
 	var l Level
 	var length = len(level)
 	var bits []bool
 	var grid []byte
 
-	l.w, l.h = level[0], level[1]
+	if length < 4 {
+		return l, errors.New("level data too short")
+	}
+
+	l.w, l.h = int(level[0]), int(level[1])
 	l.px, l.py = int(level[length-2]), int(level[length-1])
 
+	if l.w == 0 || l.h == 0 {
+		return l, fmt.Errorf("empty board %dx%d", l.w, l.h)
+	}
+	if l.px >= l.w || l.py >= l.h {
+		return l, fmt.Errorf("player outside the board at %d,%d", l.px, l.py)
+	}
+
 	for i:=2; i<length-2; i++ {
 		b:=level[i]
 		for j:=7; j>=0; j-- {
@@ -445,15 +975,31 @@ func decompressLevel(level []byte) Level {
 	var counter int
 	var object byte
 
+	size := l.w * l.h
+
+	// the data ends before the board is filled when i+n bits are missing
+	truncated := func(i, n int) error {
+		if i+n > len(bits) {
+			return fmt.Errorf("level data truncated after %d of %d tiles", len(grid), size)
+		}
+		return nil
+	}
+
 	i := 0
-	for len(grid) != int(l.w) * int(l.h) {
+	for len(grid) < size {
 
 		// extract counter
-		
+		if err := truncated(i, 1); err != nil {
+			return l, err
+		}
+
 		if(! bits[i]) {
 			counter = 1
 			i++
 		} else {
+			if err := truncated(i, 4); err != nil {
+				return l, err
+			}
 			counter = 2
 			d3 := bits[i+1]
 			d2 := bits[i+2]
@@ -471,6 +1017,9 @@ func decompressLevel(level []byte) Level {
 		}
 
 		// extract object
+		if err := truncated(i, 2); err != nil {
+			return l, err
+		}
 		if !bits[i] {
 			if!bits[i+1] {
 				object = EMPTY
@@ -484,6 +1033,9 @@ func decompressLevel(level []byte) Level {
 				object = BOX
 				i+=2
 			} else {
+				if err := truncated(i, 3); err != nil {
+					return l, err
+				}
 				if bits[i+2] {
 					object = PLACED_BOX
 				} else {
@@ -492,6 +1044,11 @@ func decompressLevel(level []byte) Level {
 				i+=3
 			}
 		}
+
+		if len(grid)+counter > size {
+			return l, fmt.Errorf("run of %d tiles overflows the board after %d of %d tiles", counter, len(grid), size)
+		}
+
 		// add in grid object
 		for j:=0;j<counter;j++ {
 			grid = append(grid, object)
@@ -506,15 +1063,24 @@ func decompressLevel(level []byte) Level {
 		grid2[i] = make([]byte, l.h)
 	}
 
-	for x:=0;x<int(l.w);x++ {
-		for y:=0;y<int(l.h);y++ {
-			grid2[x][y] = grid[y*int(l.w)+x]
+	for x:=0;x<l.w;x++ {
+		for y:=0;y<l.h;y++ {
+			grid2[x][y] = grid[y*l.w+x]
 		}
 	}
 
 	l.grid = grid2
 
-	// Compute screen specifics
+	l.placeOnScreen()
+	l.psprite = PLAYERUP
+	l.countBoxes()
+	
+	return l, nil
+}
+
+// placeOnScreen computes the zoom factor and offset showing l as large as
+// the screen allows, centered
+func (l *Level) placeOnScreen() {
 
 	startX:=0.0
 	startY:=0.0
@@ -537,18 +1103,255 @@ func decompressLevel(level []byte) Level {
 
 	l.zfactor = factor
 	l.sx, l.sy = startX, startY
+}
 
-	l.psprite = PLAYERUP
-	
-	return(l)
+// countBoxes sets the number of boxes not on a goal
+func (l *Level) countBoxes() {
+
+	l.boxesLeft = 0
+	for i:=0; i<l.w; i++ {
+		for j:=0; j<l.h; j++ {
+			if l.grid[i][j] == BOX {
+				l.boxesLeft++
+			}
+		}
+	}
 }
 
 func main() {
 
+	dataDir = dataDirFromArgs(os.Args[1:])
+	loadSettings()
+
+	bench := flag.Bool("bench", false, "run the solver benchmark and exit")
+	mode := flag.String("solver", "pushes", "what the solver minimizes: pushes or moves")
+	flag.IntVar(&solverWorkers, "workers", solverWorkers, "number of goroutines used by the solver")
+	verify := flag.Bool("verifypack", false, "check the embedded levels and exit")
+	budget := flag.Duration("verify-budget", 0, "with -verifypack, also try to solve each level for this long")
+	fuzz := flag.Int("verify-fuzz", 0, "with -verifypack, also feed the level parser this many random and damaged levels")
+	regress := flag.Bool("regress", false, "replay the recorded move sequences of every level and exit")
+	verifySolutions := flag.String("verify-solutions", "", "check the LURD solutions of this file against their levels, print a report and exit")
+	verifyPack := flag.String("pack", "", "with -verify-solutions, a level file or the name of a pack of the levels directory (default: the built-in levels)")
+	regressRecord := flag.Bool("regress-record", false, "print new recorded move sequences for -regress and exit")
+	listen := flag.String("listen", "", "accept remote control WebSocket connections on this address, e.g. localhost:8765")
+	allowOrigin := flag.String("allow-origin", "", "with -listen, also accept WebSocket connections from web pages of these comma separated origins, e.g. https://example.com")
+	twitch := flag.String("twitch", "", "let the viewers of this Twitch channel vote for the moves in its chat")
+	twitchNick := flag.String("twitch-nick", "", "with -twitch, the account used to announce the votes")
+	twitchToken := flag.String("twitch-token", "", "with -twitch, OAuth token of -twitch-nick (the chat is only read without it)")
+	voteWindow := flag.Duration("vote-window", 5*time.Second, "with -twitch, how long each vote lasts")
+	gym := flag.Bool("gym", false, "serve the reinforcement learning environment as JSON lines on stdin/stdout")
+	gymSteps := flag.Int("gym-max-steps", gymDefaultMaxSteps, "with -gym, steps before an episode is truncated")
+	tui := flag.Bool("tui", false, "play in the terminal instead of a window")
+	flag.BoolVar(&tuiASCII, "ascii", false, "with -tui, draw the board with plain ASCII characters")
+	findDups := flag.Bool("find-duplicates", false, "report the levels of the XSB files given as arguments that duplicate another level, and exit")
+	normalize := flag.Bool("normalize", false, "print the levels of the XSB files given as arguments without their outer floor and extra walls, and exit")
+	canonical := flag.Bool("canonical", false, "with -normalize, also turn the levels to the orientation used to find duplicates")
+	exportPackFile := flag.String("export-pack", "", "write the levels of the XSB files given as arguments, in order, to this .sok or .slc collection and exit")
+	var pack packInfo
+	flag.StringVar(&pack.title, "pack-title", "", "with -export-pack, title of the collection (default: the file name)")
+	flag.StringVar(&pack.author, "pack-author", "", "with -export-pack, author of the collection")
+	flag.StringVar(&pack.description, "pack-description", "", "with -export-pack, description of the collection")
+	exportPDFFile := flag.String("export-pdf", "", "write the embedded levels, or the levels of the XSB files given as arguments, to this PDF file, one level per page, and exit")
+	importImage := flag.String("import-image", "", "experimental: print the level shown in this screenshot of a board, cropped to the board, and exit")
+	importGrid := flag.String("grid", "", "with -import-image, columns and rows of the board, e.g. 12x9")
+	analyze := flag.Bool("analyze", false, "score the difficulty of the embedded levels, or of the levels of the XSB files given as arguments, with the solver and exit")
+	analyzeNodes := flag.Int("analyze-nodes", defaultAnalyzeMax, "with -analyze, nodes the solver may expand per level")
+	exportCSV := flag.String("export-csv", "", "write the progress of every level to this CSV file and exit")
+	flag.BoolVar(&windowSettings.Borderless, "borderless", windowSettings.Borderless, "hide the window decorations")
+	flag.BoolVar(&windowSettings.Fullscreen, "fullscreen", windowSettings.Fullscreen, "start in borderless fullscreen (toggle with F11)")
+	flag.BoolVar(&reducedMotion, "reduced-motion", reducedMotion, "turn off decorative animations")
+	flag.BoolVar(&windowSettings.Floating, "floating", windowSettings.Floating, "keep the window on top of other windows")
+	flag.BoolVar(&stayOnSolved, "stay", stayOnSolved, "show the result of a solved level and wait instead of loading the next one")
+	speedrunMode := flag.Bool("speedrun", false, "time a run through every level, with splits")
+	seed := flag.Int64("shuffle", 0, "play the levels in the shuffled order drawn from this seed")
+	randomSeed := flag.Bool("shuffle-random", false, "play the levels in a shuffled order drawn from a new seed")
+	challengeArg := flag.String("challenge", "", "play the challenge of this link, made with C on a solved level")
+	flag.StringVar(&speedrunLSS, "speedrun-lss", "", "with -speedrun, write the splits to this LiveSplit file at the end of each run")
+	flag.IntVar(&tickRate, "tps", tickRate, "updates per second")
+	flag.BoolVar(&vsyncEnabled, "vsync", vsyncEnabled, "wait for the screen refresh before showing a frame")
+	flag.StringVar(&leaderboardURL, "leaderboard", leaderboardURL, "send the best results of each solved level to the leaderboard server at this URL")
+	flag.StringVar(&nickname, "nickname", nickname, "with -leaderboard, the name the results are sent under")
+	flag.BoolVar(&checkUpdates, "check-updates", checkUpdates, "look for a newer release on GitHub at startup")
+	flag.BoolVar(&showFPS, "fps", showFPS, "show the frame and update rates")
+	flag.IntVar(&undoLimit, "undo-limit", undoLimit, "only keep this many moves to undo, 0 for no limit")
+	fonts := flag.String("fonts", "", "comma separated TTF or OTF files drawing the text beyond ASCII before the bundled font, e.g. for Arabic or Hebrew")
+	// read by dataDirFromArgs before the settings
+	flag.String("data-dir", "", "keep the settings, progress, replays, packs and caches in this directory")
+	flag.Bool("portable", false, "keep everything in "+portableDataDir+" next to the executable, as when a file named "+portableMarker+" is there")
+	flag.Parse()
+
+	// the leaderboard account is given once and kept
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "leaderboard" || f.Name == "nickname" {
+			saveSettings()
+		}
+	})
+
+	if *fonts != "" {
+		fontFiles = strings.Split(*fonts, ",")
+	}
+
+	var err error
+	if solverModeSetting, err = parseSolverMode(*mode); err != nil {
+		log.Fatal(err)
+	}
+
+	if *bench {
+		if !runSolverBenchmark() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *verifySolutions != "" {
+		ok, err := runVerifySolutions(*verifySolutions, *verifyPack)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *regress {
+		if !runRegression() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *regressRecord {
+		recordRegression(20000)
+		return
+	}
+
+	if *normalize {
+		if err := runNormalize(flag.Args(), *canonical); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *exportPackFile != "" {
+		if err := exportPack(*exportPackFile, pack, flag.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *exportPDFFile != "" {
+		if err := exportPDF(*exportPDFFile, flag.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *importImage != "" {
+		if err := runImportImage(*importImage, *importGrid); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *analyze {
+		if err := runAnalyze(flag.Args(), *analyzeNodes); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *findDups {
+		n, err := runFindDuplicates(flag.Args())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if n > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	loadProgress()
+	loadReplays()
+
+	if *exportCSV != "" {
+		if err := exportProgressCSV(*exportCSV); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *gym {
+		if err := runGymBridge(os.Stdin, os.Stdout, *gymSteps); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *tui {
+		if err := runTUI(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *verify {
+		if !runVerifyPack(*budget, *fuzz) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *listen != "" {
+		if *allowOrigin != "" {
+			wsAllowedOrigins = strings.Split(*allowOrigin, ",")
+		}
+		startHTTPServer(*listen)
+	}
+
+	if *twitch != "" {
+		startTwitch(*twitch, *twitchNick, *twitchToken, *voteWindow)
+	}
+
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Sokoban")
+	setWindowIcon()
+	applyWindowOptions()
+	applyPerformanceSettings()
+	if checkUpdates {
+		checkForUpdate()
+	}
+
+	if *randomSeed && *seed == 0 {
+		*seed = randomShuffleSeed()
+	}
+	startShuffle(*seed)
+
+	s := newGameState(levelAt(0))
+	subscribeGameFeatures(s)
+	startOnboarding()
+	if !*speedrunMode && *challengeArg == "" {
+		// zen mode goes straight back to where it was left
+		if !zenMode || !restoreZen(s) {
+			startTitle()
+		}
+	}
+	if *speedrunMode {
+		startSpeedrun(s)
+	}
+	if *challengeArg != "" {
+		c, err := parseChallenge(*challengeArg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		startChallenge(s, c)
+	}
+	broadcastState(s)
 
-	if err := ebiten.RunGame(&Game{}); err != nil {
+	// closing the window shows the session summary first
+	ebiten.SetWindowClosingHandled(true)
+	if err := ebiten.RunGame(&Game{state: s, sheets: newSpriteSheets()}); err != nil {
 		panic(err)
 	}
 }
@@ -0,0 +1,217 @@
+// Sokoban game
+//
+// Reinforcement learning environment, in the style of OpenAI Gym
+//
+// Actions are 0 up, 1 right, 2 down and 3 left. Each step costs 0.1, a box
+// pushed onto a goal earns 1 (and pushing it off costs 1 back), solving the
+// level earns 10. An episode ends when the level is solved, or is truncated
+// after a maximum number of steps.
+//
+// With -gym the game reads JSON requests on stdin, one per line, and answers
+// each with one line of JSON on stdout, without opening a window:
+//
+//	{"reset": 3}            start level 3
+//	{"step": 1}             play an action
+//	{"observe": true}       current observation
+//
+// Answers carry "observation", "reward", "done", "truncated" and "info", or
+// "error". The info lists the "legal_actions", those that move the player,
+// to mask the others.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	gymStepReward   = -0.1
+	gymBoxReward    = 1.0
+	gymSolvedReward = 10.0
+
+	gymDefaultMaxSteps = 500
+)
+
+// tile codes of an observation
+const (
+	gymWall = iota
+	gymFloor
+	gymGoal
+	gymBox
+	gymBoxOnGoal
+	gymPlayer
+	gymPlayerOnGoal
+)
+
+type gymEnv struct {
+	level    int
+	board    Level
+	steps    int
+	maxSteps int
+}
+
+type gymObservation struct {
+	Grid  [][]int  `json:"grid"`  // rows of tile codes
+	Board []string `json:"board"` // the same as XSB rows
+}
+
+type gymInfo struct {
+	Level        int   `json:"level"`
+	Steps        int   `json:"steps"`
+	BoxesLeft    int   `json:"boxes_left"`
+	LegalActions []int `json:"legal_actions"`
+}
+
+func newGymEnv(maxSteps int) *gymEnv {
+
+	if maxSteps <= 0 {
+		maxSteps = gymDefaultMaxSteps
+	}
+	return &gymEnv{maxSteps: maxSteps}
+}
+
+// Reset starts an episode on embedded level n
+func (e *gymEnv) Reset(n int) (gymObservation, error) {
+
+	if n < 0 || n >= len(levels) {
+		return gymObservation{}, fmt.Errorf("no level %d", n)
+	}
+
+	e.level = n
+	e.board = levelTemplate(n)
+	e.steps = 0
+
+	return e.Observation(), nil
+}
+
+// Step plays an action and returns the new observation, the reward, whether
+// the level is solved and whether the episode ran out of steps
+func (e *gymEnv) Step(action int) (gymObservation, float64, bool, bool, error) {
+
+	if e.board.grid == nil {
+		return gymObservation{}, 0, false, false, fmt.Errorf("reset the environment first")
+	}
+	if action < 0 || action >= len(directions) {
+		return gymObservation{}, 0, false, false, fmt.Errorf("invalid action %d", action)
+	}
+
+	before := e.board.boxesLeft
+	e.board.play(directions[action])
+
+	e.steps++
+
+	reward := gymStepReward + gymBoxReward*float64(before-e.board.boxesLeft)
+
+	done := e.board.boxesLeft == 0
+	if done {
+		reward += gymSolvedReward
+	}
+
+	return e.Observation(), reward, done, !done && e.steps >= e.maxSteps, nil
+}
+
+func (e *gymEnv) Observation() gymObservation {
+
+	l := e.board
+	obs := gymObservation{Grid: make([][]int, l.h), Board: levelToXSB(l)}
+
+	for y := 0; y < l.h; y++ {
+		obs.Grid[y] = make([]int, l.w)
+		for x := 0; x < l.w; x++ {
+			code := gymFloor
+			switch l.grid[x][y] {
+			case WALL:
+				code = gymWall
+			case GOAL:
+				code = gymGoal
+			case BOX:
+				code = gymBox
+			case PLACED_BOX:
+				code = gymBoxOnGoal
+			}
+			if x == l.px && y == l.py {
+				code = gymPlayer
+				if l.grid[x][y] == GOAL {
+					code = gymPlayerOnGoal
+				}
+			}
+			obs.Grid[y][x] = code
+		}
+	}
+
+	return obs
+}
+
+func (e *gymEnv) info() gymInfo {
+
+	info := gymInfo{Level: e.level, Steps: e.steps, BoxesLeft: e.board.boxesLeft, LegalActions: []int{}}
+	for _, m := range LegalMoves(e.board) {
+		for a, d := range directions {
+			if d == m.Dir {
+				info.LegalActions = append(info.LegalActions, a)
+			}
+		}
+	}
+	return info
+}
+
+type gymRequest struct {
+	Reset   *int `json:"reset"`
+	Step    *int `json:"step"`
+	Observe bool `json:"observe"`
+}
+
+type gymResponse struct {
+	Observation *gymObservation `json:"observation,omitempty"`
+	Reward      float64         `json:"reward"`
+	Done        bool            `json:"done"`
+	Truncated   bool            `json:"truncated"`
+	Info        *gymInfo        `json:"info,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// runGymBridge serves the environment over JSON lines until in is closed
+func runGymBridge(in io.Reader, out io.Writer, maxSteps int) error {
+
+	env := newGymEnv(maxSteps)
+	enc := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+
+		var req gymRequest
+		var resp gymResponse
+		var err error
+
+		if err = json.Unmarshal(scanner.Bytes(), &req); err == nil {
+			var obs gymObservation
+			switch {
+			case req.Reset != nil:
+				obs, err = env.Reset(*req.Reset)
+			case req.Step != nil:
+				obs, resp.Reward, resp.Done, resp.Truncated, err = env.Step(*req.Step)
+			case req.Observe && env.board.grid != nil:
+				obs = env.Observation()
+			default:
+				err = fmt.Errorf("expected reset, step or observe")
+			}
+			if err == nil {
+				info := env.info()
+				resp.Observation, resp.Info = &obs, &info
+			}
+		}
+
+		if err != nil {
+			resp = gymResponse{Error: err.Error()}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,159 @@
+// Sokoban game
+//
+// Mistake heatmap: H shows where the player undid moves (red) and where it
+// walked more than needed between two pushes (yellow) in the current
+// attempt. With -stay it can be looked at on the solved board.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+type heatmap struct {
+	undos  map[int]int // cell where the player went back to, number of undos
+	wasted map[int]int // cells of walks longer than needed
+	nUndos int
+	nWaste int // moves that a shortest walk would have saved
+}
+
+var (
+	showHeatmap = false
+
+	// undos per level, forgotten when the level starts afresh
+	undoHeat = make(map[int]map[int]int)
+
+	heatmapCache    heatmap
+	heatmapCacheKey string
+)
+
+func heatPackChanged(e GameEvent) {
+
+	undoHeat = make(map[int]map[int]int)
+	heatmapCacheKey = ""
+}
+
+// heatLevelStarted forgets the undos of a level started from scratch
+func heatLevelStarted(s *GameState) {
+
+	if s.moveCount() == 0 {
+		delete(undoHeat, s.currentLevelNumber)
+	}
+}
+
+func heatMoveUndone(s *GameState) {
+
+	h, ok := undoHeat[s.currentLevelNumber]
+	if !ok {
+		h = make(map[int]int)
+		undoHeat[s.currentLevelNumber] = h
+	}
+	h[s.curLev.py*s.curLev.w+s.curLev.px]++
+}
+
+// wastedWalking replays the moves of s and returns the cells of the walks
+// between pushes that were longer than the shortest walk, and the number
+// of moves lost
+func wastedWalking(s *GameState) (map[int]int, int) {
+
+	wasted := make(map[int]int)
+	total := 0
+
+	if !s.historyComplete() {
+		return wasted, 0
+	}
+
+	l := levelTemplate(s.currentLevelNumber)
+	w := l.w
+	var dead []bool
+
+	for i := 0; i < len(s.moves); {
+
+		if s.moves[i].pushed {
+			l.play(s.moves[i].dir)
+			i++
+			continue
+		}
+
+		// a walk: the moves up to the next push
+		b, boxes, from := newSolverBoard(l, dead)
+		dead = b.dead
+		for _, c := range boxes {
+			b.occupied[c] = true
+		}
+
+		var cells []int
+		for ; i < len(s.moves) && !s.moves[i].pushed; i++ {
+			l.play(s.moves[i].dir)
+			cells = append(cells, l.py*w+l.px)
+		}
+
+		to := l.py*w + l.px
+		if extra := len(cells) - len(b.path(from, to, b.occupied)); extra > 0 {
+			total += extra
+			for _, c := range cells {
+				wasted[c]++
+			}
+		}
+	}
+
+	return wasted, total
+}
+
+func currentHeatmap(s *GameState) heatmap {
+
+	key := fmt.Sprint(s.currentLevelNumber, s.moveCount(), s.curLev.px, s.curLev.py, s.levelStart.UnixNano())
+	if key == heatmapCacheKey {
+		return heatmapCache
+	}
+
+	h := heatmap{undos: undoHeat[s.currentLevelNumber]}
+	for _, n := range h.undos {
+		h.nUndos += n
+	}
+	h.wasted, h.nWaste = wastedWalking(s)
+
+	heatmapCache, heatmapCacheKey = h, key
+	return h
+}
+
+func updateHeatmap(in InputSource) {
+
+	if in.IsKeyJustPressed(ebiten.KeyH) {
+		showHeatmap = !showHeatmap
+	}
+}
+
+func drawHeatmap(screen *ebiten.Image, s *GameState) {
+
+	if !showHeatmap {
+		return
+	}
+
+	h := currentHeatmap(s)
+	w := s.curLev.w
+
+	cell := func(c int, n int, r, g, b uint8) {
+		a := 60 + 40*n
+		if a > 200 {
+			a = 200
+		}
+		x, y, tile := cellOnScreen(s, c%w, c/w)
+		ebitenutil.DrawRect(screen, x, y, tile, tile,
+			color.RGBA{r * uint8(a) / 255, g * uint8(a) / 255, b * uint8(a) / 255, uint8(a)})
+	}
+
+	for c, n := range h.wasted {
+		cell(c, n, 255, 220, 0)
+	}
+	for c, n := range h.undos {
+		cell(c, n, 255, 0, 0)
+	}
+
+	drawText(screen, fmt.Sprintf("undos: %d   moves lost walking: %d of %d", h.nUndos, h.nWaste, s.moveCount()),
+		20, screenHeight-30)
+}
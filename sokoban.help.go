@@ -0,0 +1,132 @@
+// Sokoban game
+//
+// Cheat sheet: F1, ? or the question mark icon list every control, with the
+// keys of the chosen preset; any key or click closes it
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+type shortcut struct {
+	keys   string
+	action string
+}
+
+type shortcutGroup struct {
+	title     string
+	shortcuts []shortcut
+}
+
+var (
+	helpOpen       = false
+	helpScreenZone = screenZone{20, 10, 3, 1}
+
+	helpGroups = []shortcutGroup{
+		{"Playing", []shortcut{
+			{"arrows, arrow icons", "move"},
+			{"click or touch a cell", "walk there"},
+			{"right click a box", "then a cell: push it there"},
+			{"Backspace, top left icon", "undo"},
+			{"Shift+Backspace", "undo back to before the last push"},
+			{"Home", "restart the level"},
+			{"F", "finish when every box goes straight to a goal"},
+			{"S, Shift+S", "solve from here, from the start"},
+			{"Enter during a solution", "step through it push by push"},
+			{"Insert, End", "record a move macro, play it"},
+			{"J", "hint, again for a stronger one"},
+			{"M (hold)", "peek in blindfold mode"},
+			{"Shift+arrows", "kid mode: pull a box back, pull only: step without pulling"},
+		}},
+		{"Levels", []shortcut{
+			{"PageUp, top right icon", "next level"},
+			{"PageDown, icon left of it", "previous level"},
+			{"G", "go to a level by number"},
+			{"L", "level select"},
+			{"D, F in the level select", "sort, filter by difficulty"},
+			{"A in the replays", "note on a move"},
+			{"R", "replays of the level"},
+			{"P", "level packs"},
+			{"I", "level facts"},
+			{"N", "note on the level"},
+			{"H", "undo heatmap"},
+			{"K", "coordinates and pushes"},
+		}},
+		{"Saving", []shortcut{
+			{"F6, F7, F8", "select quick save slot 1, 2, 3"},
+			{"F5", "quick save"},
+			{"F9", "quick load"},
+			{"F12", "export the position as PNG"},
+		}},
+		{"View and sound", []shortcut{
+			{"mouse wheel, pinch", "zoom"},
+			{"0", "fit the board to the screen"},
+			{"V, Shift+V", "turn, mirror the board"},
+			{"F3, F4", "audio cues, announcer"},
+			{"F10, gear icon", "settings"},
+			{"settings > controls", "left hand preset"},
+			{"F11", "fullscreen"},
+			{"F1, ?", "this cheat sheet"},
+		}},
+	}
+)
+
+// helpKeyPressed tells whether F1 or ? was just pressed
+func helpKeyPressed(in InputSource) bool {
+
+	if in.IsKeyJustPressed(ebiten.KeyF1) {
+		return true
+	}
+	for _, r := range in.AppendInputChars(nil) {
+		if r == '?' {
+			return true
+		}
+	}
+	return false
+}
+
+// updateHelp returns true while the cheat sheet takes the input
+func updateHelp(in InputSource, mouseOrTouch bool, eventX int, eventY int) bool {
+
+	if !helpOpen {
+		// the ? typed in a text field belongs to it
+		if settingsOpen || levelSelectOpen || replayBrowserOpen || packBrowserOpen || jumpPromptOpen || noteEntryOpen {
+			return false
+		}
+		if helpKeyPressed(in) || (mouseOrTouch && inScreenZone(helpScreenZone, eventX, eventY)) {
+			helpOpen = true
+			return true
+		}
+		return false
+	}
+
+	if mouseOrTouch || len(in.AppendJustPressedKeys(nil)) > 0 || helpKeyPressed(in) {
+		helpOpen = false
+	}
+	return true
+}
+
+func drawHelp(screen *ebiten.Image) {
+
+	if !helpOpen {
+		return
+	}
+
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{20, 20, 30, 235})
+	drawText(screen, "Controls (any key or click to close)", 80, 60)
+
+	for i, g := range helpGroups {
+		var b strings.Builder
+		b.WriteString(g.title + "\n\n")
+		for _, sc := range g.shortcuts {
+			fmt.Fprintf(&b, "%-28s %s\n", presetKeyNames(sc.keys), sc.action)
+		}
+		drawText(screen, b.String(), 80+(i%2)*(screenWidth/2), 120+(i/2)*300)
+	}
+}
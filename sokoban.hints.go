@@ -0,0 +1,166 @@
+// Sokoban game
+//
+// Hint ladder: J asks the solver, in the background, how to go on from the
+// current position and reveals its next push one step at a time: the first
+// J shows the region of the board to work on, the second the box to push
+// and the third the cell to push it to. Each step costs a hint point, kept
+// with the progress of the level and shown in the level facts (I).
+//
+// The hint holds until a box moves, a move is undone or the level changes.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	hintNone = iota
+	hintRegion
+	hintBox
+	hintDirection
+
+	hintRegionSize = 4 // cells, the board being split in squares of this size
+)
+
+var (
+	hintRun     *solverProgress
+	hintResults chan solverResult
+
+	hintStep = hintNone
+	hintBoxX int
+	hintBoxY int
+	hintDir  byte
+)
+
+// firstPush returns the box the moves push first from the position of l and
+// the direction of the push
+func firstPush(l Level, moves []byte) (int, int, byte, bool) {
+
+	x, y := l.px, l.py
+	for _, d := range moves {
+		dx, dy := dirDelta(d)
+		x, y = x+dx, y+dy
+		if t := l.grid[x][y]; t == BOX || t == PLACED_BOX {
+			return x, y, d, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// forgetHint drops the hint of a position that is gone
+func forgetHint() {
+
+	if hintRun != nil {
+		hintRun.cancel()
+		hintRun = nil
+	}
+	hintStep = hintNone
+}
+
+func hintMoved(e GameEvent) {
+
+	if e.Pushed {
+		forgetHint()
+	}
+}
+
+// takeHint climbs one step of the ladder and charges it
+func takeHint(s *GameState) {
+
+	hintStep++
+	levelProgressFor(s.currentLevelNumber).HintPoints++
+	saveProgress()
+}
+
+func updateHints(s *GameState, in InputSource) {
+
+	if hintRun != nil {
+		select {
+		case res := <-hintResults:
+			hintRun = nil
+			if !res.solved {
+				showMessage("this position cannot be won anymore, undo some moves")
+				break
+			}
+			if x, y, d, ok := firstPush(s.curLev, res.moves); ok {
+				hintBoxX, hintBoxY, hintDir = x, y, d
+				takeHint(s)
+			}
+		default:
+		}
+	}
+
+	if !in.IsKeyJustPressed(ebiten.KeyJ) || hintRun != nil || solverRun != nil || s.nBoxesLeft() == 0 {
+		return
+	}
+	if reverseMode {
+		reverseRefused()
+		return
+	}
+
+	switch hintStep {
+	case hintNone:
+		hintRun = &solverProgress{}
+		hintResults = make(chan solverResult, 1)
+		l := s.curLev.clone()
+		go func(p *solverProgress, results chan solverResult) {
+			results <- solveLevelCached(l, solverModeSetting, p)
+		}(hintRun, hintResults)
+		showMessage("looking for a hint...")
+	case hintDirection:
+		showMessage("no more hints for this push")
+	default:
+		takeHint(s)
+	}
+}
+
+func drawHint(screen *ebiten.Image, s *GameState) {
+
+	if hintStep == hintNone {
+		return
+	}
+
+	cell := func(x, y int, c color.RGBA) {
+		if x >= 0 && y >= 0 && x < s.curLev.w && y < s.curLev.h {
+			sx, sy, tile := cellOnScreen(s, x, y)
+			ebitenutil.DrawRect(screen, sx, sy, tile, tile, c)
+		}
+	}
+
+	// the square of the board holding the box, which is not always in its
+	// middle
+	x0, y0 := hintBoxX/hintRegionSize*hintRegionSize, hintBoxY/hintRegionSize*hintRegionSize
+	for x := x0; x < x0+hintRegionSize; x++ {
+		for y := y0; y < y0+hintRegionSize; y++ {
+			cell(x, y, color.RGBA{0, 40, 90, 90})
+		}
+	}
+
+	msg := "hint 1/3: work in the blue region (J for more)"
+	if hintStep >= hintBox {
+		cell(hintBoxX, hintBoxY, color.RGBA{120, 100, 0, 120})
+		msg = "hint 2/3: push the yellow box (J for more)"
+	}
+	if hintStep >= hintDirection {
+		dx, dy := dirDelta(hintDir)
+		cell(hintBoxX+dx, hintBoxY+dy, color.RGBA{0, 120, 0, 120})
+		msg = "hint 3/3: push the yellow box into the green cell"
+	}
+
+	ebitenutil.DrawRect(screen, screenWidth/2-200, screenHeight-70, 400, 26, color.RGBA{0, 0, 0, 180})
+	drawText(screen, msg, screenWidth/2-190, screenHeight-65)
+}
+
+// hintSummary describes the hint points spent on level n for the level facts
+func hintSummary(n int) string {
+
+	if p, ok := levelsProgress()[n]; ok && p.HintPoints > 0 {
+		return fmt.Sprintf("hint points: %d", p.HintPoints)
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+// Sokoban game
+//
+// Power saving: after a while without input or anything happening on the
+// board, the game slows its update rate down and stops redrawing the
+// screen, which keeps the last frame. The first input wakes it up.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	idleAfter = 30 * time.Second
+	idleTPS   = 10
+)
+
+var (
+	idle         = false
+	idleDrawn    = false // the screen kept while idle has been drawn
+	lastActivity = time.Now()
+	lastCursorX  int
+	lastCursorY  int
+	lastIdleKey  string
+)
+
+// updateIdle enters or leaves the idle mode, it runs first in Update
+func updateIdle(s *GameState, in InputSource) {
+
+	if activityDetected(s, in) {
+		lastActivity = time.Now()
+		if idle {
+			idle = false
+			ebiten.SetTPS(tickRate)
+			ebiten.SetScreenClearedEveryFrame(true)
+		}
+		return
+	}
+
+	if !idle && time.Since(lastActivity) > idleAfter {
+		idle = true
+		idleDrawn = false
+		ebiten.SetTPS(idleTPS)
+		ebiten.SetScreenClearedEveryFrame(false)
+	}
+}
+
+func activityDetected(s *GameState, in InputSource) bool {
+
+	active := false
+
+	if len(in.AppendPressedKeys(nil)) > 0 || len(in.AppendTouchIDs(nil)) > 0 {
+		active = true
+	}
+	for _, b := range []ebiten.MouseButton{ebiten.MouseButtonLeft, ebiten.MouseButtonRight, ebiten.MouseButtonMiddle} {
+		if in.IsMouseButtonPressed(b) {
+			active = true
+		}
+	}
+	if wx, wy := in.Wheel(); wx != 0 || wy != 0 {
+		active = true
+	}
+
+	x, y := in.CursorPosition()
+	if x != lastCursorX || y != lastCursorY {
+		lastCursorX, lastCursorY = x, y
+		active = true
+	}
+
+	// something moving on its own, or moved by a remote client
+	if solverRun != nil || playback != nil || (compareOpen && !comparePaused) ||
+		time.Now().Before(statusMessageUntil) || sittingEnded != nil {
+		active = true
+	}
+
+	key := fmt.Sprint(s.currentLevelNumber, s.moveCount(), s.curLev.px, s.curLev.py, s.levelStart.UnixNano())
+	if key != lastIdleKey {
+		lastIdleKey = key
+		active = true
+	}
+
+	return active
+}
+
+// skipDraw is true when idle and the kept screen is up to date
+func skipDraw() bool {
+
+	if !idle {
+		return false
+	}
+	if !idleDrawn {
+		idleDrawn = true
+		return false
+	}
+	return true
+}
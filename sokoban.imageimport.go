@@ -0,0 +1,386 @@
+// Sokoban game
+//
+// Screenshot import (experimental): run with -import-image board.png -grid 12x9
+//
+// Reads a screenshot of a board from another game or a web site, cropped
+// to the board and cut in the given number of columns and rows, and prints
+// the level it shows in XSB. Each cell is described by the average colours
+// of a grid of small blocks and the cells are grouped by colour. The groups
+// are then given a role: the player is a cell of its own, the walls the
+// group that closes the board around it, the floor the largest group
+// inside, goals look mostly like the floor and boxes do not. The first
+// roles making a valid level, with as many goals as boxes, win.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	importBlocks      = 8    // a cell is measured in importBlocks x importBlocks blocks
+	importClusterDist = 30.0 // colour distance of the most different blocks joining a cell to a group
+	importFloorLike   = 0.6  // share of blocks like the floor making a goal rather than a box
+)
+
+// the average colours of the blocks of a cell
+type cellColor [importBlocks * importBlocks][3]float64
+
+func blockDist(a [3]float64, b [3]float64) float64 {
+
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
+
+// dist is the colour distance of the blocks of c and o that differ most
+func (c cellColor) dist(o cellColor) float64 {
+
+	d := 0.0
+	for i := range c {
+		d = math.Max(d, blockDist(c[i], o[i]))
+	}
+	return d
+}
+
+// likeness returns the share of the blocks of c that look like those of o
+func (c cellColor) likeness(o cellColor) float64 {
+
+	n := 0
+	for i := range c {
+		if blockDist(c[i], o[i]) < importClusterDist {
+			n++
+		}
+	}
+	return float64(n) / float64(len(c))
+}
+
+// cellColors measures the cells of img cut in cols x rows
+func cellColors(img image.Image, cols int, rows int) [][]cellColor {
+
+	b := img.Bounds()
+	cells := make([][]cellColor, cols)
+
+	for x := 0; x < cols; x++ {
+		cells[x] = make([]cellColor, rows)
+		for y := 0; y < rows; y++ {
+			x0, x1 := b.Min.X+x*b.Dx()/cols, b.Min.X+(x+1)*b.Dx()/cols
+			y0, y1 := b.Min.Y+y*b.Dy()/rows, b.Min.Y+(y+1)*b.Dy()/rows
+
+			var c cellColor
+			var n [importBlocks * importBlocks]float64
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					i := (py-y0)*importBlocks/(y1-y0)*importBlocks + (px-x0)*importBlocks/(x1-x0)
+					r, g, bl, _ := img.At(px, py).RGBA()
+					c[i][0] += float64(r >> 8)
+					c[i][1] += float64(g >> 8)
+					c[i][2] += float64(bl >> 8)
+					n[i]++
+				}
+			}
+			for i := range c {
+				for j := 0; j < 3; j++ {
+					c[i][j] /= math.Max(n[i], 1)
+				}
+			}
+			cells[x][y] = c
+		}
+	}
+	return cells
+}
+
+// clusterCells groups the cells by colour, returning the group of each cell
+// and the average colours of the groups
+func clusterCells(cells [][]cellColor) ([][]int, []cellColor) {
+
+	groups := make([][]int, len(cells))
+	var centers []cellColor
+
+	for pass := 0; pass < 4; pass++ {
+		sums := make([]cellColor, len(centers))
+		counts := make([]float64, len(centers))
+
+		for x := range cells {
+			groups[x] = make([]int, len(cells[x]))
+			for y, c := range cells[x] {
+				best, bestDist := -1, importClusterDist
+				for i, center := range centers {
+					if d := c.dist(center); d < bestDist {
+						best, bestDist = i, d
+					}
+				}
+				// new groups are only made on the first pass
+				if best < 0 && pass == 0 {
+					centers = append(centers, c)
+					sums = append(sums, cellColor{})
+					counts = append(counts, 0)
+					best = len(centers) - 1
+				}
+				if best < 0 {
+					best = nearestGroup(c, centers)
+				}
+				groups[x][y] = best
+				for i := range c {
+					for j := 0; j < 3; j++ {
+						sums[best][i][j] += c[i][j]
+					}
+				}
+				counts[best]++
+			}
+		}
+
+		for i := range centers {
+			if counts[i] == 0 {
+				continue
+			}
+			for j := range centers[i] {
+				for k := 0; k < 3; k++ {
+					centers[i][j][k] = sums[i][j][k] / counts[i]
+				}
+			}
+		}
+	}
+	return groups, centers
+}
+
+func nearestGroup(c cellColor, centers []cellColor) int {
+
+	best := 0
+	for i := range centers {
+		if c.dist(centers[i]) < c.dist(centers[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// insideOf returns the cells reachable from x, y without crossing the
+// cells of group wall, or nil when they reach the edge of the image
+func insideOf(groups [][]int, wall int, x int, y int) [][]bool {
+
+	cols, rows := len(groups), len(groups[0])
+	inside := make([][]bool, cols)
+	for i := range inside {
+		inside[i] = make([]bool, rows)
+	}
+	inside[x][y] = true
+
+	for queue := [][2]int{{x, y}}; len(queue) > 0; queue = queue[1:] {
+		cx, cy := queue[0][0], queue[0][1]
+		if cx == 0 || cy == 0 || cx == cols-1 || cy == rows-1 {
+			return nil
+		}
+		for _, d := range directions {
+			dx, dy := dirDelta(d)
+			nx, ny := cx+dx, cy+dy
+			if !inside[nx][ny] && groups[nx][ny] != wall {
+				inside[nx][ny] = true
+				queue = append(queue, [2]int{nx, ny})
+			}
+		}
+	}
+	return inside
+}
+
+// guessLevel gives the groups of the cells their roles, returning the rows
+// of the level or nil when no roles fit
+func guessLevel(cells [][]cellColor, groups [][]int, centers []cellColor) []string {
+
+	cols, rows := len(groups), len(groups[0])
+	sizes := make([]int, len(centers))
+	border := make([]int, len(centers))
+	for x := 0; x < cols; x++ {
+		for y := 0; y < rows; y++ {
+			sizes[groups[x][y]]++
+			if x == 0 || y == 0 || x == cols-1 || y == rows-1 {
+				border[groups[x][y]]++
+			}
+		}
+	}
+
+	// the player is alone in its group, the floor showing around it
+	type cell struct{ x, y int }
+	var players []cell
+	for x := 0; x < cols; x++ {
+		for y := 0; y < rows; y++ {
+			if sizes[groups[x][y]] == 1 {
+				players = append(players, cell{x, y})
+			}
+		}
+	}
+	likeCommon := func(p cell) float64 {
+		l := 0.0
+		for i, c := range centers {
+			if sizes[i] >= 3 {
+				l = math.Max(l, cells[p.x][p.y].likeness(c))
+			}
+		}
+		return l
+	}
+	sort.SliceStable(players, func(i, j int) bool { return likeCommon(players[i]) > likeCommon(players[j]) })
+
+	walls := make([]int, 0, len(centers))
+	for i := range centers {
+		if border[i] > 0 {
+			walls = append(walls, i)
+		}
+	}
+	sort.SliceStable(walls, func(i, j int) bool { return border[walls[i]] > border[walls[j]] })
+
+	// readings without a box or the player on a goal come first, those
+	// are the ambiguous ones
+	for _, onGoals := range []bool{false, true} {
+		for _, p := range players {
+			for _, wall := range walls {
+				inside := insideOf(groups, wall, p.x, p.y)
+				if inside == nil {
+					continue
+				}
+				if level := assignRoles(groups, centers, inside, wall, p.x, p.y, onGoals); level != nil {
+					return level
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// assignRoles tells floor, goals and boxes apart inside the walls, with
+// boxes or the player on goals when onGoals is set
+func assignRoles(groups [][]int, centers []cellColor, inside [][]bool, wall int, px int, py int, onGoals bool) []string {
+
+	cols, rows := len(groups), len(groups[0])
+
+	count := make([]int, len(centers))
+	for x := 0; x < cols; x++ {
+		for y := 0; y < rows; y++ {
+			if inside[x][y] && (x != px || y != py) {
+				count[groups[x][y]]++
+			}
+		}
+	}
+	floor := 0
+	for i := range count {
+		if count[i] > count[floor] {
+			floor = i
+		}
+	}
+
+	var goals, boxes []int
+	for i := range count {
+		switch {
+		case i == floor || count[i] == 0:
+		case centers[i].likeness(centers[floor]) >= importFloorLike:
+			goals = append(goals, i)
+		default:
+			boxes = append(boxes, i)
+		}
+	}
+	if len(boxes) == 0 {
+		return nil
+	}
+
+	// each group of boxes may be the boxes on goals, tried before the
+	// player on a goal
+	tries := []int{-1}
+	if onGoals {
+		tries = make([]int, 0, len(boxes)+1)
+		for i := range boxes {
+			tries = append(tries, i)
+		}
+		tries = append(tries, -1)
+	}
+	for _, placed := range tries {
+		tiles := make(map[int]byte)
+		for _, g := range goals {
+			tiles[g] = '.'
+		}
+		for i, g := range boxes {
+			tiles[g] = '$'
+			if i == placed {
+				tiles[g] = '*'
+			}
+		}
+
+		nBoxes, nGoals := 0, 0
+		for g, t := range tiles {
+			switch t {
+			case '$':
+				nBoxes += count[g]
+			case '.':
+				nGoals += count[g]
+			}
+		}
+
+		player := byte('@')
+		if onGoals && nGoals+1 == nBoxes {
+			player = '+'
+		} else if nGoals != nBoxes {
+			continue
+		}
+
+		level := make([]string, rows)
+		for y := 0; y < rows; y++ {
+			var row strings.Builder
+			for x := 0; x < cols; x++ {
+				t, ok := tiles[groups[x][y]]
+				switch {
+				case x == px && y == py:
+					t = player
+				case groups[x][y] == wall:
+					t = '#'
+				case !inside[x][y] || !ok:
+					t = ' '
+				}
+				row.WriteByte(t)
+			}
+			level[y] = strings.TrimRight(row.String(), " ")
+		}
+		if _, err := xsbToLevel(level); err == nil {
+			return level
+		}
+	}
+	return nil
+}
+
+// runImportImage prints the level of the screenshot in file, cut in the
+// cells of grid, given as COLSxROWS
+func runImportImage(file string, grid string) error {
+
+	var cols, rows int
+	if _, err := fmt.Sscanf(grid, "%dx%d", &cols, &rows); err != nil || cols < 3 || rows < 3 {
+		return fmt.Errorf("-grid %q: give the columns and rows of the board, e.g. 12x9", grid)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	if img.Bounds().Dx() < cols || img.Bounds().Dy() < rows {
+		return fmt.Errorf("%s is smaller than the grid", file)
+	}
+
+	cells := cellColors(img, cols, rows)
+	groups, centers := clusterCells(cells)
+	level := guessLevel(cells, groups, centers)
+	if level == nil {
+		return fmt.Errorf("%s: cannot make out a level, check the grid size and that the image is cropped to the board", file)
+	}
+
+	for _, row := range level {
+		fmt.Println(row)
+	}
+	fmt.Println()
+	return nil
+}
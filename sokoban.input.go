@@ -0,0 +1,141 @@
+// Sokoban game
+//
+// Input sources: Update reads the keyboard, mouse and touches through an
+// InputSource, which is ebiten in the game and a script when the game runs
+// headless, for instance to replay recorded input (see -regress).
+
+package main
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type InputSource interface {
+	IsKeyPressed(k ebiten.Key) bool
+	IsKeyJustPressed(k ebiten.Key) bool
+	AppendPressedKeys(keys []ebiten.Key) []ebiten.Key
+	AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key
+	AppendInputChars(chars []rune) []rune
+
+	CursorPosition() (int, int)
+	IsMouseButtonPressed(b ebiten.MouseButton) bool
+	IsMouseButtonJustPressed(b ebiten.MouseButton) bool
+	Wheel() (float64, float64)
+
+	AppendTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID
+	AppendJustPressedTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID
+	TouchPosition(id ebiten.TouchID) (int, int)
+
+	IsFocused() bool
+}
+
+// ebitenInput polls ebiten
+type ebitenInput struct{}
+
+func (ebitenInput) IsKeyPressed(k ebiten.Key) bool     { return ebiten.IsKeyPressed(k) }
+func (ebitenInput) IsKeyJustPressed(k ebiten.Key) bool { return inpututil.IsKeyJustPressed(k) }
+func (ebitenInput) AppendPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return inpututil.AppendPressedKeys(keys)
+}
+func (ebitenInput) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return inpututil.AppendJustPressedKeys(keys)
+}
+func (ebitenInput) AppendInputChars(chars []rune) []rune { return ebiten.AppendInputChars(chars) }
+
+func (ebitenInput) CursorPosition() (int, int) { return ebiten.CursorPosition() }
+func (ebitenInput) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return ebiten.IsMouseButtonPressed(b)
+}
+func (ebitenInput) IsMouseButtonJustPressed(b ebiten.MouseButton) bool {
+	return inpututil.IsMouseButtonJustPressed(b)
+}
+func (ebitenInput) Wheel() (float64, float64) { return ebiten.Wheel() }
+
+func (ebitenInput) AppendTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID {
+	return ebiten.AppendTouchIDs(ids)
+}
+func (ebitenInput) AppendJustPressedTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID {
+	return inpututil.AppendJustPressedTouchIDs(ids)
+}
+func (ebitenInput) TouchPosition(id ebiten.TouchID) (int, int) { return ebiten.TouchPosition(id) }
+
+func (ebitenInput) IsFocused() bool { return ebiten.IsFocused() }
+
+// inputFrame is what happens during one update of a script: the keys are
+// pressed at its start and released at its end
+type inputFrame struct {
+	Keys  []ebiten.Key
+	Chars []rune
+	Click *image.Point // left click or touch
+}
+
+// scriptedInput plays frames, one per update; advance moves to the next one
+type scriptedInput struct {
+	frames []inputFrame
+	frame  int
+}
+
+func newScriptedInput(frames ...inputFrame) *scriptedInput {
+	return &scriptedInput{frames: frames}
+}
+
+func (in *scriptedInput) current() inputFrame {
+
+	if in.frame < len(in.frames) {
+		return in.frames[in.frame]
+	}
+	return inputFrame{}
+}
+
+func (in *scriptedInput) advance() { in.frame++ }
+
+// done is true once every frame has been played
+func (in *scriptedInput) done() bool { return in.frame >= len(in.frames) }
+
+func (in *scriptedInput) IsKeyPressed(k ebiten.Key) bool {
+
+	for _, key := range in.current().Keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (in *scriptedInput) IsKeyJustPressed(k ebiten.Key) bool { return in.IsKeyPressed(k) }
+func (in *scriptedInput) AppendPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return append(keys, in.current().Keys...)
+}
+func (in *scriptedInput) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return in.AppendPressedKeys(keys)
+}
+func (in *scriptedInput) AppendInputChars(chars []rune) []rune {
+	return append(chars, in.current().Chars...)
+}
+
+func (in *scriptedInput) CursorPosition() (int, int) {
+
+	if c := in.current().Click; c != nil {
+		return c.X, c.Y
+	}
+	return 0, 0
+}
+
+func (in *scriptedInput) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return b == ebiten.MouseButtonLeft && in.current().Click != nil
+}
+func (in *scriptedInput) IsMouseButtonJustPressed(b ebiten.MouseButton) bool {
+	return in.IsMouseButtonPressed(b)
+}
+func (in *scriptedInput) Wheel() (float64, float64) { return 0, 0 }
+
+func (in *scriptedInput) AppendTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID { return ids }
+func (in *scriptedInput) AppendJustPressedTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID {
+	return ids
+}
+func (in *scriptedInput) TouchPosition(id ebiten.TouchID) (int, int) { return 0, 0 }
+
+func (in *scriptedInput) IsFocused() bool { return true }
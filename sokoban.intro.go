@@ -0,0 +1,92 @@
+// Sokoban game
+//
+// Level intro: when another level is loaded its number, difficulty and box
+// count show in a banner for a second, then fade out. The first key, click
+// or touch during that second hides the banner and is not played.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	introHold = time.Second
+	introFade = 400 * time.Millisecond
+)
+
+var (
+	introLevel  = -1
+	introStart  time.Time
+	introBanner *ebiten.Image
+)
+
+func introPackChanged(e GameEvent) {
+
+	introLevel = -1
+}
+
+// updateIntro starts the banner of a new level; it returns true when the
+// banner took the input
+func updateIntro(s *GameState, in InputSource, mouseOrTouch bool) bool {
+
+	if s.currentLevelNumber != introLevel {
+		introLevel = s.currentLevelNumber
+		introStart = time.Now()
+	}
+
+	if time.Since(introStart) >= introHold {
+		return false
+	}
+
+	if mouseOrTouch || len(in.AppendJustPressedKeys(nil)) > 0 {
+		// jump to the end of the banner
+		introStart = time.Now().Add(-introHold - introFade)
+		return true
+	}
+
+	return false
+}
+
+// skipIntro counts the banner of level n as already shown
+func skipIntro(n int) {
+
+	introLevel, introStart = n, time.Time{}
+}
+
+func drawIntro(screen *ebiten.Image, s *GameState) {
+
+	if introLevel != s.currentLevelNumber {
+		return
+	}
+
+	alpha := 1.0
+	if elapsed := time.Since(introStart); elapsed > introHold {
+		fade := float64(introFade) * motionScale()
+		if fade == 0 {
+			return
+		}
+		alpha = 1 - float64(elapsed-introHold)/fade
+		if alpha <= 0 {
+			return
+		}
+	}
+
+	info := getLevelInfo(s.currentLevelNumber)
+	msg := fmt.Sprintf("Level %d\n\n%s, %d boxes", s.currentLevelNumber, difficultyLabel(info.difficulty), info.boxes)
+
+	if introBanner == nil {
+		introBanner = ebiten.NewImage(260, 70)
+	}
+	introBanner.Fill(color.RGBA{0, 0, 0, 200})
+	drawText(introBanner, msg, 20, 12)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(screenWidth/2-130, screenHeight/2-35)
+	op.ColorM.Scale(1, 1, 1, alpha)
+	screen.DrawImage(introBanner, op)
+}
@@ -0,0 +1,66 @@
+// Sokoban game
+//
+// Jump to a level: G opens a prompt, type the level number then Enter
+// (Esc cancels)
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var (
+	jumpPromptOpen = false
+	jumpPromptText string
+)
+
+// updateJumpPrompt returns true while the prompt takes the input
+func updateJumpPrompt(s *GameState, in InputSource) bool {
+
+	if !jumpPromptOpen {
+		if in.IsKeyJustPressed(ebiten.KeyG) && solverRun == nil && len(playback) == 0 {
+			jumpPromptOpen = true
+			jumpPromptText = ""
+			return true
+		}
+		return false
+	}
+
+	for _, r := range in.AppendInputChars(nil) {
+		if r >= '0' && r <= '9' && len(jumpPromptText) < 3 {
+			jumpPromptText += string(r)
+		}
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyBackspace) && len(jumpPromptText) > 0 {
+		jumpPromptText = jumpPromptText[:len(jumpPromptText)-1]
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEnter) {
+		if n, err := strconv.Atoi(jumpPromptText); err == nil {
+			s.switchLevel(n)
+		}
+		jumpPromptOpen = false
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) {
+		jumpPromptOpen = false
+	}
+
+	return true
+}
+
+func drawJumpPrompt(screen *ebiten.Image) {
+
+	if !jumpPromptOpen {
+		return
+	}
+
+	ebitenutil.DrawRect(screen, 750, 460, 400, 60, color.RGBA{40, 40, 60, 230})
+	drawText(screen, fmt.Sprintf("Go to level (0-%d): %s_\n\nEnter to go, Esc to cancel", lastLevel(), jumpPromptText), 770, 470)
+}
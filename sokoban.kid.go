@@ -0,0 +1,171 @@
+// Sokoban game
+//
+// Kid mode (settings > gameplay), for very young players: only the small
+// and easy levels are played, the next level and the level select skipping
+// the others, the board is cropped to its walls so that the tiles come out
+// as large as possible, and the placed boxes and the solved levels are
+// cheered with bright stars and a banner.
+//
+// Holding Shift while moving pulls the box behind the player along, an
+// "oops" for a box pushed where it should not be. A level solved with
+// pulls does not count in the records.
+
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	kidMaxBoxes      = 6
+	kidMaxDifficulty = 20 // the easy levels, see difficultyLabel
+	kidBannerScale   = 6
+	kidBannerTime    = 2500 * time.Millisecond
+)
+
+var kidCheers = []string{"Great job!", "Well done!", "You did it!", "Super!"}
+
+var (
+	kidMode = false
+
+	kidBanner      string
+	kidBannerUntil time.Time
+	kidBannerImage *ebiten.Image
+)
+
+// kidLevel tells if level n is small and easy enough for kid mode
+func kidLevel(n int) bool {
+
+	d, _ := levelDifficulty(n)
+	return boxCount(levelTemplate(n)) <= kidMaxBoxes && d < kidMaxDifficulty
+}
+
+// kidStepLevel returns the kid level step kid levels after level n in the
+// playthrough, staying on n when there is none
+func kidStepLevel(n int, step int) int {
+
+	dir := 1
+	if step < 0 {
+		dir, step = -1, -step
+	}
+
+	pos := levelPosition(n)
+	for ; step > 0; step-- {
+		next := pos + dir
+		for next >= 0 && next <= lastLevel() && !kidLevel(levelAt(next)) {
+			next += dir
+		}
+		if next < 0 || next > lastLevel() {
+			break
+		}
+		pos = next
+	}
+	return levelAt(pos)
+}
+
+// wallsFrame returns the part of the board of l inside its walls, walls
+// included, in cells of view v
+func wallsFrame(l Level, v int) (int, int, int, int) {
+
+	outside := outsideCells(l)
+	x0, y0, x1, y1 := math.MaxInt, math.MaxInt, -1, -1
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			if outside[x][y] {
+				continue
+			}
+			vx, vy := cellToView(l, v, x, y)
+			if vx < x0 {
+				x0 = vx
+			}
+			if vy < y0 {
+				y0 = vy
+			}
+			if vx > x1 {
+				x1 = vx
+			}
+			if vy > y1 {
+				y1 = vy
+			}
+		}
+	}
+
+	if x1 < 0 {
+		w, h := viewSize(l, v)
+		return 0, 0, w, h
+	}
+	return x0, y0, x1 - x0 + 1, y1 - y0 + 1
+}
+
+// kidStars bursts bright stars of every colour from a placed box
+func kidStars(e GameEvent) {
+
+	if !kidMode {
+		return
+	}
+
+	x, y, tile := cellCenter(e.State, e.X, e.Y)
+	for i := 0; i < 30; i++ {
+		a := 2 * math.Pi * (float64(i) + rand.Float64()) / 30
+		v := tile * (1.5 + 2*rand.Float64())
+		spawnParticle(particle{
+			x: x, y: y,
+			vx:      math.Cos(a) * v,
+			vy:      math.Sin(a) * v,
+			gravity: tile * 2,
+			size:    tile / 5,
+			c:       confettiColors[i%len(confettiColors)],
+			life:    time.Duration(800+rand.Intn(400)) * time.Millisecond,
+		})
+	}
+}
+
+func kidLevelCompleted(e GameEvent) {
+
+	if !kidMode {
+		return
+	}
+	kidBanner = kidCheers[rand.Intn(len(kidCheers))]
+	kidBannerUntil = time.Now().Add(kidBannerTime)
+	kidBannerImage = nil
+}
+
+// kidDeadlock tells how to take the box back
+func kidDeadlock(e GameEvent) {
+
+	if kidMode {
+		showMessage("Oops! Hold Shift and walk away from the box to pull it back")
+	}
+}
+
+func drawKidBanner(screen *ebiten.Image) {
+
+	if !kidMode || time.Now().After(kidBannerUntil) {
+		return
+	}
+
+	if kidBannerImage == nil {
+		kidBannerImage = ebiten.NewImage(6*len(kidBanner)+4, 16)
+		drawText(kidBannerImage, kidBanner, 2, 0)
+	}
+
+	// a bounce in the colours of the confetti
+	t := time.Until(kidBannerUntil).Seconds()
+	c := confettiColors[int(t*6)%len(confettiColors)]
+	scale := kidBannerScale * (1 + 0.08*math.Sin(t*10)*motionScale())
+
+	w, h := kidBannerImage.Size()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(screenWidth/2-float64(w)*scale/2, screenHeight/2-float64(h)*scale/2)
+	op.ColorM.Scale(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, 1)
+
+	ebitenutil.DrawRect(screen, 0, screenHeight/2-float64(h)*scale, screenWidth, 2*float64(h)*scale, color.RGBA{0, 0, 0, 150})
+	screen.DrawImage(kidBannerImage, op)
+}
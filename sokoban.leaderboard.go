@@ -0,0 +1,182 @@
+// Sokoban game
+//
+// Leaderboard: with -leaderboard URL and -nickname NAME (both kept in the
+// settings), each solved level sends the best moves, pushes and time of the
+// player to a leaderboard server, then fetches its top 10, shown on the
+// screen of a solved level when "stay on solved levels" is on. Levels are
+// known by their identity (see levelIdentity), so the same level in another
+// pack or format shares its board.
+//
+// The server speaks JSON over HTTPS (plain HTTP only for localhost):
+//
+//	POST URL/levels/HASH/scores  {"nickname": "...", "moves": 1, "pushes": 1, "time": 1.5}
+//	GET  URL/levels/HASH/top     [{"nickname": "...", "moves": 1, "pushes": 1, "time": 1.5}, ...]
+//
+// the top being sorted by moves, then pushes, then time.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const leaderboardTop = 10
+
+type leaderboardScore struct {
+	Nickname string  `json:"nickname"`
+	Moves    int     `json:"moves"`
+	Pushes   int     `json:"pushes"`
+	Time     float64 `json:"time"` // seconds
+}
+
+var (
+	leaderboardURL  = ""
+	nickname        = ""
+	leaderboardOn   = true
+	leaderboardWait = 10 * time.Second
+
+	leaderboardLock   sync.Mutex
+	leaderboardLevel  = -1 // level of the top below
+	leaderboardTopTen []leaderboardScore
+	leaderboardError  string
+)
+
+func leaderboardPackChanged(e GameEvent) {
+
+	leaderboardLock.Lock()
+	leaderboardLevel = -1
+	leaderboardLock.Unlock()
+}
+
+// leaderboardBase returns the base URL of the server, checked, or "" when
+// there is none to use
+func leaderboardBase() string {
+
+	if !leaderboardOn || leaderboardURL == "" || nickname == "" {
+		return ""
+	}
+
+	u, err := url.Parse(leaderboardURL)
+	if err != nil {
+		return ""
+	}
+	local := u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1"
+	if u.Scheme != "https" && !(u.Scheme == "http" && local) {
+		return ""
+	}
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// submitScore sends the best results of the level just solved and fetches
+// its top 10, without blocking the game
+func submitScore(s *GameState) {
+
+	base := leaderboardBase()
+	if base == "" || s.assisted {
+		return
+	}
+	p, ok := levelsProgress()[s.currentLevelNumber]
+	if !ok {
+		return
+	}
+
+	n := s.currentLevelNumber
+	score := leaderboardScore{nickname, p.BestMoves, p.BestPushes, p.BestTime}
+	levelURL := base + "/levels/" + levelIdentity(n)
+
+	leaderboardLock.Lock()
+	leaderboardLevel, leaderboardTopTen, leaderboardError = n, nil, "fetching the leaderboard..."
+	leaderboardLock.Unlock()
+
+	go func() {
+		top, err := exchangeScore(levelURL, score)
+
+		leaderboardLock.Lock()
+		defer leaderboardLock.Unlock()
+		if leaderboardLevel != n {
+			return
+		}
+		leaderboardTopTen, leaderboardError = top, ""
+		if err != nil {
+			log.Printf("leaderboard: %v", err)
+			leaderboardError = "leaderboard unavailable"
+		}
+	}()
+}
+
+func exchangeScore(levelURL string, score leaderboardScore) ([]leaderboardScore, error) {
+
+	client := http.Client{Timeout: leaderboardWait}
+
+	body, err := json.Marshal(score)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Post(levelURL+"/scores", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("sending the score: %s", resp.Status)
+	}
+
+	resp, err = client.Get(levelURL + "/top")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the top: %s", resp.Status)
+	}
+
+	var top []leaderboardScore
+	if err := json.NewDecoder(resp.Body).Decode(&top); err != nil {
+		return nil, err
+	}
+	if len(top) > leaderboardTop {
+		top = top[:leaderboardTop]
+	}
+	return top, nil
+}
+
+// drawLeaderboard shows the top 10 of level n next to the solved summary
+func drawLeaderboard(screen *ebiten.Image, n int, x int, y int) {
+
+	leaderboardLock.Lock()
+	level, top, msg := leaderboardLevel, leaderboardTopTen, leaderboardError
+	leaderboardLock.Unlock()
+
+	if level != n || (top == nil && msg == "") {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Top 10\n\n")
+	if msg != "" {
+		b.WriteString(msg)
+	}
+	for i, sc := range top {
+		mark := " "
+		if sc.Nickname == nickname {
+			mark = "*"
+		}
+		fmt.Fprintf(&b, "%2d.%s%-14.14s %5d %5d %8s\n", i+1, mark, sc.Nickname, sc.Moves, sc.Pushes,
+			time.Duration(sc.Time*float64(time.Second)).Round(time.Second))
+	}
+
+	ebitenutil.DrawRect(screen, float64(x), float64(y), 300, 196, color.RGBA{0, 0, 0, 180})
+	drawText(screen, b.String(), x+15, y+15)
+}
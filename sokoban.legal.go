@@ -0,0 +1,99 @@
+// Sokoban game
+//
+// Legal moves: the moves and the pushes the player can make from a
+// position. A move is one step of the player; a push is a box pushed one
+// cell, with the walk that brings the player behind it first.
+//
+// The gym asks LegalMoves for its actions; the solver, the send box mover
+// and the auto-finish check their pushes with canPush. Level.move still
+// applies the rules itself when the player plays.
+
+package main
+
+// LegalMove is a step of the player in direction Dir
+type LegalMove struct {
+	Dir  byte
+	Push bool // the step pushes the box in front of the player
+}
+
+// LegalPush is the box on BoxX, BoxY pushed one cell in direction Dir
+type LegalPush struct {
+	BoxX, BoxY int
+	Dir        byte
+	Walk       []byte // moves to the cell behind the box, pushing nothing
+	OntoGoal   bool
+}
+
+// Moves returns the walk and the push
+func (p LegalPush) Moves() []byte {
+
+	return append(append([]byte(nil), p.Walk...), p.Dir)
+}
+
+// canMove tells if the player of l can step in direction d and if it
+// pushes a box doing so, like move but leaving l as it is
+func (l *Level) canMove(d byte) (bool, bool) {
+
+	dx, dy := dirDelta(d)
+	switch l.grid[l.px+dx][l.py+dy] {
+	case EMPTY, GOAL:
+		return true, false
+	case BOX, PLACED_BOX:
+		if t := l.grid[l.px+2*dx][l.py+2*dy]; t == EMPTY || t == GOAL {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// LegalMoves returns the steps the player of l can make, in the order of
+// directions
+func LegalMoves(l Level) []LegalMove {
+
+	var moves []LegalMove
+	for _, d := range directions {
+		if ok, push := l.canMove(d); ok {
+			moves = append(moves, LegalMove{d, push})
+		}
+	}
+	return moves
+}
+
+// canPush tells if the box on cell c can be pushed in direction d, the
+// player standing on the cell from and the box going to the cell to; the
+// player still has to walk to from
+func (b *solverBoard) canPush(c int, d byte, occupied []bool) (int, int, bool) {
+
+	from, to := b.step(c, oppositeDir(d)), b.step(c, d)
+	if !b.floor(from) || occupied[from] || !b.floor(to) || occupied[to] {
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// LegalPushes returns the pushes the player of l can walk to, box by box
+// from the top left, each with the shortest walk
+func LegalPushes(l Level) []LegalPush {
+
+	b, boxes, player := newSolverBoard(l, nil)
+	for _, c := range boxes {
+		b.occupied[c] = true
+	}
+	b.reach(player, b.occupied)
+
+	var pushes []LegalPush
+	for _, c := range boxes {
+		for _, d := range directions {
+			from, to, ok := b.canPush(int(c), d, b.occupied)
+			if !ok || !b.reached(from) {
+				continue
+			}
+			pushes = append(pushes, LegalPush{
+				BoxX: int(c) % b.w, BoxY: int(c) / b.w, Dir: d,
+				Walk:     b.path(player, from, b.occupied),
+				OntoGoal: b.goal[to],
+			})
+		}
+	}
+	return pushes
+}
@@ -0,0 +1,83 @@
+// Sokoban game
+//
+// Level identity: a level is known by the Zobrist hash of its smallest
+// form, without the tiles outside its walls and cropped (see
+// normalizeLevel), so that the same level read from an XSB, SLC or RLE
+// collection, with more or less floor and walls around it, has the same
+// identity. The leaderboard and the solution hub know the levels by it,
+// and the progress, notes included, and the replays carry it: a pack
+// converted to another format or renamed finds them back.
+
+package main
+
+import "fmt"
+
+// level identities of the pack being played, by level number
+var levelIdentities = make(map[int]string)
+
+// canonicalLevelHash returns the identity of l
+func canonicalLevelHash(l Level) string {
+
+	return fmt.Sprintf("%016x", zobristLevel(normalizeLevel(l)))
+}
+
+// levelIdentity returns the identity of level n of the pack being played
+func levelIdentity(n int) string {
+
+	id, ok := levelIdentities[n]
+	if !ok {
+		id = canonicalLevelHash(levelTemplate(n))
+		levelIdentities[n] = id
+	}
+	return id
+}
+
+// adoptProgress gives the levels of pack id, being played, without
+// progress the progress kept for the same level in another pack, the pack
+// having count levels; the progress kept before the identities were gets
+// them on the way
+func adoptProgress(id string, count int) {
+
+	for n, p := range progress.Levels {
+		if p.Hash == "" && n <= LEVEL_MAX {
+			p.Hash = canonicalLevelHash(embeddedLevel(n))
+		}
+	}
+	for n, p := range packProgress(id) {
+		if p.Hash == "" && n < count {
+			p.Hash = levelIdentity(n)
+		}
+	}
+
+	known := make(map[string]*levelProgress)
+	add := func(levels map[int]*levelProgress) {
+		for _, p := range levels {
+			if old, ok := known[p.Hash]; p.Hash != "" && (!ok || p.TimesSolved > old.TimesSolved) {
+				known[p.Hash] = p
+			}
+		}
+	}
+	add(progress.Levels)
+	for _, levels := range progress.Packs {
+		add(levels)
+	}
+	if len(known) == 0 {
+		return
+	}
+
+	levels := packProgress(id)
+	adopted := 0
+	for n := 0; n < count; n++ {
+		if _, ok := levels[n]; ok {
+			continue
+		}
+		if p, ok := known[levelIdentity(n)]; ok {
+			c := *p
+			levels[n] = &c
+			adopted++
+		}
+	}
+	if adopted > 0 {
+		saveProgress()
+	}
+}
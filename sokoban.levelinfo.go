@@ -0,0 +1,132 @@
+// Sokoban game
+//
+// Level facts shown in the info panel (toggled with I)
+//
+// The difficulty estimate grows with the number of boxes and with the
+// square root of the floor a box can usefully stand on.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+type levelInfo struct {
+	boxes, goals int
+	floor        int // tiles the player can reach, boxes ignored
+	liveFloor    int // floor tiles from which a box can still reach a goal
+	difficulty   int
+}
+
+var (
+	levelInfos    = make(map[int]levelInfo)
+	showLevelInfo = false
+)
+
+func levelInfoPackChanged(e GameEvent) {
+
+	levelInfos = make(map[int]levelInfo)
+}
+
+func computeLevelInfo(l Level) levelInfo {
+
+	var info levelInfo
+
+	b, boxes, player := newSolverBoard(l, nil)
+
+	info.boxes = len(boxes)
+	for c := range b.goal {
+		if b.goal[c] {
+			info.goals++
+		}
+	}
+
+	b.reach(player, make([]bool, b.w*b.h))
+	for c := range b.wall {
+		if b.reached(c) {
+			info.floor++
+			if !b.dead[c] {
+				info.liveFloor++
+			}
+		}
+	}
+
+	info.difficulty = int(math.Round(float64(info.boxes) * math.Sqrt(float64(info.liveFloor)) / 4))
+
+	return info
+}
+
+// getLevelInfo returns the facts of embedded level n, computed once
+func getLevelInfo(n int) levelInfo {
+
+	info, ok := levelInfos[n]
+	if !ok {
+		info = computeLevelInfo(levelTemplate(n))
+		levelInfos[n] = info
+	}
+	return info
+}
+
+func difficultyLabel(d int) string {
+
+	switch {
+	case d < 5:
+		return "trivial"
+	case d < 20:
+		return "easy"
+	case d < 50:
+		return "medium"
+	case d < 90:
+		return "hard"
+	}
+	return "expert"
+}
+
+func (info levelInfo) String() string {
+
+	return fmt.Sprintf("boxes:      %d\ngoals:      %d\nfloor:      %d tiles\ndifficulty: %d (%s)",
+		info.boxes, info.goals, info.floor, info.difficulty, difficultyLabel(info.difficulty))
+}
+
+func updateLevelInfo(in InputSource) {
+
+	if in.IsKeyJustPressed(ebiten.KeyI) {
+		showLevelInfo = !showLevelInfo
+	}
+}
+
+func drawLevelInfo(screen *ebiten.Image, s *GameState) {
+
+	if !showLevelInfo {
+		return
+	}
+
+	msg := fmt.Sprintf("Level %d\n%s", s.currentLevelNumber, getLevelInfo(s.currentLevelNumber))
+	height := 100.0
+
+	if _, measured := levelDifficulty(s.currentLevelNumber); measured {
+		d := levelScores[s.currentLevelNumber]
+		msg += fmt.Sprintf("\nmeasured:   %d (%s)", d.Score, difficultySummary(s.currentLevelNumber))
+		height += 16
+	}
+
+	if hints := hintSummary(s.currentLevelNumber); hints != "" {
+		msg += "\n" + hints
+		height += 16
+	}
+
+	if p, ok := levelsProgress()[s.currentLevelNumber]; ok && p.Note != "" {
+		note := wrapText("note: "+p.Note, 32)
+		msg += "\n\n" + note
+		height += float64(32 + 16*strings.Count(note, "\n"))
+	}
+
+	ebitenutil.DrawRect(screen, 10, 90, 220, height, color.RGBA{0, 0, 0, 160})
+	drawText(screen, msg, 20, 100)
+}
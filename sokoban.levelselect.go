@@ -0,0 +1,185 @@
+// Sokoban game
+//
+// Level select: L shows every level in a grid, arrows and Enter or a click
+// pick one, Esc or L goes back to the game. D sorts the levels by
+// difficulty and F filters them by difficulty label.
+//
+// Each level is rendered once into a small off-screen image, the grid then
+// only draws those thumbnails.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	selectColumns = 9
+	selectRows    = 7
+
+	thumbWidth  = 190
+	thumbHeight = 110
+)
+
+var (
+	levelSelectOpen = false
+	selectedLevel   = 0
+
+	thumbnails = make(map[int]*ebiten.Image)
+)
+
+func thumbnailsPackChanged(e GameEvent) {
+
+	thumbnails = make(map[int]*ebiten.Image)
+}
+
+// levelThumbnail returns the cached rendering of level n
+func levelThumbnail(sh *spriteSheets, n int) *ebiten.Image {
+
+	if img, ok := thumbnails[n]; ok {
+		return img
+	}
+
+	img := ebiten.NewImage(thumbWidth, thumbHeight)
+	sh.drawBoard(img, levelTemplate(n), 0, 0, thumbWidth, thumbHeight)
+
+	thumbnails[n] = img
+
+	return img
+}
+
+// selectTop returns the position of the first level shown, scrolling by
+// rows so that the selected level stays on the screen
+func selectTop(order []int) int {
+
+	row := 0
+	for i, n := range order {
+		if n == selectedLevel {
+			row = i / selectColumns
+		}
+	}
+	if row < selectRows {
+		return 0
+	}
+	return (row - selectRows + 1) * selectColumns
+}
+
+func selectCell(n int) (int, int, int, int) {
+
+	w, h := screenWidth/selectColumns, screenHeight/selectRows
+	x, y := (n%selectColumns)*w, (n/selectColumns)*h
+
+	return x, y, w, h
+}
+
+// updateLevelSelect returns true while the grid takes the input
+func updateLevelSelect(s *GameState, in InputSource, mouseOrTouch bool, eventX int, eventY int) bool {
+
+	if !levelSelectOpen {
+		if in.IsKeyJustPressed(ebiten.KeyL) && solverRun == nil {
+			levelSelectOpen = true
+			selectedLevel = s.currentLevelNumber
+			return true
+		}
+		return false
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) || in.IsKeyJustPressed(ebiten.KeyL) {
+		levelSelectOpen = false
+		return true
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyD) {
+		selectByDifficulty = !selectByDifficulty
+	}
+	if in.IsKeyJustPressed(ebiten.KeyF) {
+		selectFilter = (selectFilter + 1) % len(selectFilters)
+	}
+
+	order := selectOrder()
+	if len(order) == 0 {
+		return true
+	}
+
+	// the position of the selected level, the first one when filtered out
+	i := 0
+	for j, n := range order {
+		if n == selectedLevel {
+			i = j
+		}
+	}
+
+	n := i
+	if in.IsKeyJustPressed(ebiten.KeyArrowRight) {
+		n++
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+		n--
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		n += selectColumns
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		n -= selectColumns
+	}
+	if n >= 0 && n < len(order) {
+		i = n
+	}
+	selectedLevel = order[i]
+
+	pick := in.IsKeyJustPressed(ebiten.KeyEnter)
+
+	if mouseOrTouch {
+		n := selectTop(order) + (eventY/(screenHeight/selectRows))*selectColumns + eventX/(screenWidth/selectColumns)
+		if n >= 0 && n < len(order) {
+			selectedLevel = order[n]
+			pick = true
+		}
+	}
+
+	if pick {
+		playback = nil
+		s.switchLevel(selectedLevel)
+		levelSelectOpen = false
+	}
+
+	return true
+}
+
+func drawLevelSelect(screen *ebiten.Image, sh *spriteSheets) {
+
+	order := selectOrder()
+	top := selectTop(order)
+
+	for i, n := range order {
+
+		if i < top || i >= top+selectColumns*selectRows {
+			continue
+		}
+		x, y, w, h := selectCell(i - top)
+
+		if n == selectedLevel {
+			ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h), color.RGBA{80, 80, 120, 255})
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(x+(w-thumbWidth)/2), float64(y+4))
+		screen.DrawImage(levelThumbnail(sh, n), op)
+
+		info := getLevelInfo(n)
+		drawText(screen, fmt.Sprintf("%2d  %d boxes, %s", n, info.boxes, difficultySummary(n)),
+			x+(w-thumbWidth)/2, y+thumbHeight+8)
+	}
+
+	by := "number"
+	if selectByDifficulty {
+		by = "difficulty"
+	}
+	msg := fmt.Sprintf("%s: by %s  F: %s levels", presetKeyNames("D"), by, selectFilters[selectFilter])
+	ebitenutil.DrawRect(screen, screenWidth-300, screenHeight-16, 300, 16, color.RGBA{0, 0, 0, 200})
+	drawText(screen, msg, screenWidth-290, screenHeight-17)
+}
@@ -0,0 +1,62 @@
+// Sokoban game
+//
+// Moves written in LURD notation: one letter per move, l u r or d, in
+// uppercase for the moves pushing a box
+
+package main
+
+import "fmt"
+
+func movesToLURD(moves []byte) string {
+
+	s := make([]byte, len(moves))
+
+	for i, m := range moves {
+		switch m {
+		case LEFT:
+			s[i] = 'l'
+		case UP:
+			s[i] = 'u'
+		case RIGHT:
+			s[i] = 'r'
+		case DOWN:
+			s[i] = 'd'
+		}
+	}
+	return string(s)
+}
+
+func historyToLURD(moves []moveRecord) string {
+
+	s := make([]byte, len(moves))
+
+	for i, m := range moves {
+		s[i] = movesToLURD([]byte{m.dir})[0]
+		if m.pushed {
+			s[i] -= 'a' - 'A'
+		}
+	}
+	return string(s)
+}
+
+func lurdToMoves(s string) ([]byte, error) {
+
+	moves := make([]byte, 0, len(s))
+
+	for i, c := range s {
+		switch c {
+		case 'l', 'L':
+			moves = append(moves, LEFT)
+		case 'u', 'U':
+			moves = append(moves, UP)
+		case 'r', 'R':
+			moves = append(moves, RIGHT)
+		case 'd', 'D':
+			moves = append(moves, DOWN)
+		case ' ', '\t', '\n', '\r':
+		default:
+			return nil, fmt.Errorf("invalid move %q at position %d", c, i)
+		}
+	}
+	return moves, nil
+}
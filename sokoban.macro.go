@@ -0,0 +1,163 @@
+// Sokoban game
+//
+// Move macro: Insert starts recording the moves, a repositioning loop for
+// instance, and stops it again; End plays the macro from where the player
+// stands. The moves are kept as pressed on the screen, so a macro follows
+// the board when it is turned. Before anything is played the macro is
+// tried move by move on a copy of the level, and refused when a move would
+// bump into a wall or a box that cannot be pushed. The macro is kept in
+// macro.json.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	macroFile     = "macro.json"
+	macroMaxMoves = 100
+)
+
+type macroData struct {
+	Moves string `json:"moves"` // LURD, in the directions of the screen
+}
+
+var (
+	macroRecording = false
+	macroMoves     []byte // as pressed, see viewDir
+	macroLoaded    = false
+)
+
+func loadMacro() {
+
+	if macroLoaded {
+		return
+	}
+	macroLoaded = true
+
+	var data macroData
+	if err := loadJSON(macroFile, &data); err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("cannot read the macro: %v", err)
+		}
+		return
+	}
+	if m, err := lurdToMoves(data.Moves); err == nil {
+		macroMoves = m
+	}
+}
+
+func saveMacro() {
+
+	if err := saveJSON(macroFile, macroData{movesToLURD(macroMoves)}); err != nil {
+		log.Printf("cannot save the macro: %v", err)
+	}
+}
+
+func stopMacroRecording() {
+
+	macroRecording = false
+	saveMacro()
+	showMessage(fmt.Sprintf("macro of %d moves recorded, End plays it", len(macroMoves)))
+}
+
+func macroMoved(e GameEvent) {
+
+	if !macroRecording || !e.Moved {
+		return
+	}
+	macroMoves = append(macroMoves, viewDir(e.Dir, levelView(e.State.currentLevelNumber)))
+	if len(macroMoves) >= macroMaxMoves {
+		stopMacroRecording()
+	}
+}
+
+func macroUndone(e GameEvent) {
+
+	if macroRecording && len(macroMoves) > 0 {
+		macroMoves = macroMoves[:len(macroMoves)-1]
+	}
+}
+
+func macroLevelStarted(e GameEvent) {
+
+	if macroRecording {
+		stopMacroRecording()
+	}
+}
+
+// macroBlocked returns the number of the first move of moves that does not
+// move the player from the position of l, 0 when they all do
+func macroBlocked(l Level, moves []byte) int {
+
+	c := l.clone()
+	for i, d := range moves {
+		if moved, _ := c.play(d); !moved {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func playMacro(s *GameState) {
+
+	loadMacro()
+	if len(macroMoves) == 0 {
+		showMessage("no macro yet, Insert records one")
+		return
+	}
+
+	moves := make([]byte, len(macroMoves))
+	for i, d := range macroMoves {
+		moves[i] = levelDir(d, camera.view)
+	}
+	if n := macroBlocked(s.curLev, moves); n > 0 {
+		showMessage(fmt.Sprintf("the macro would be blocked at its move %d, nothing played", n))
+		return
+	}
+
+	playback = moves
+	playbackTick = 0
+}
+
+func updateMacro(s *GameState, in InputSource) {
+
+	if in.IsKeyJustPressed(ebiten.KeyInsert) {
+		switch {
+		case macroRecording:
+			stopMacroRecording()
+		case reverseMode:
+			reverseRefused()
+		default:
+			macroRecording = true
+			macroMoves = nil
+			showMessage("recording a macro, Insert again to stop")
+		}
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEnd) && !macroRecording {
+		if reverseMode {
+			reverseRefused()
+			return
+		}
+		playMacro(s)
+	}
+}
+
+func drawMacro(screen *ebiten.Image) {
+
+	if !macroRecording {
+		return
+	}
+
+	msg := fmt.Sprintf("REC macro: %d moves", len(macroMoves))
+	ebitenutil.DrawRect(screen, screenWidth-260, 110, 240, 24, color.RGBA{140, 0, 0, 200})
+	drawText(screen, msg, screenWidth-250, 114)
+}
@@ -0,0 +1,21 @@
+// Sokoban game
+//
+// Reduced motion: run with -reduced-motion
+//
+// Moves are already instant. With this setting the decorative effects,
+// such as tweens, pulsing tiles, screen transitions and particles, are
+// turned off as well; each of them scales its amplitude or duration with
+// motionScale.
+
+package main
+
+var reducedMotion = false
+
+// motionScale is 1 normally and 0 in reduced motion
+func motionScale() float64 {
+
+	if reducedMotion {
+		return 0
+	}
+	return 1
+}
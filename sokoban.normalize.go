@@ -0,0 +1,126 @@
+// Sokoban game
+//
+// Level normalization: run with -normalize pack.xsb ...
+//
+// The floor the player cannot reach from the outside of the level and the
+// walls not touching the inside are removed and the level is cropped, which
+// prints every level of the packs in its smallest form. With -canonical
+// the levels are also turned to their canonical form, the one used to find
+// duplicates.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// normalizeLevel returns l without the tiles outside its walls and the
+// walls no inside tile touches, cropped
+func normalizeLevel(l Level) Level {
+
+	w, h := l.w, l.h
+
+	// the inside is where the player can go, boxes being moveable, plus
+	// the boxes and goals it cannot reach, which are part of the level
+	inside := make([][]bool, w)
+	for x := range inside {
+		inside[x] = make([]bool, h)
+	}
+
+	stack := [][2]int{{l.px, l.py}}
+	inside[l.px][l.py] = true
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			x, y := c[0]+d[0], c[1]+d[1]
+			if x < 0 || x >= w || y < 0 || y >= h || inside[x][y] || l.grid[x][y] == WALL {
+				continue
+			}
+			inside[x][y] = true
+			stack = append(stack, [2]int{x, y})
+		}
+	}
+
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if t := l.grid[x][y]; t == BOX || t == PLACED_BOX || t == GOAL {
+				inside[x][y] = true
+			}
+		}
+	}
+
+	touchesInside := func(x, y int) bool {
+		for i := x - 1; i <= x+1; i++ {
+			for j := y - 1; j <= y+1; j++ {
+				if i >= 0 && i < w && j >= 0 && j < h && inside[i][j] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	n := l.clone()
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if !inside[x][y] && !(l.grid[x][y] == WALL && touchesInside(x, y)) {
+				n.grid[x][y] = EMPTY
+			}
+		}
+	}
+
+	rows := make([]string, 0, h)
+	for _, row := range cropLevel(n) {
+		rows = append(rows, string(row))
+	}
+
+	cropped, err := xsbToLevel(rows)
+	if err != nil {
+		return l
+	}
+	return cropped
+}
+
+// runNormalize prints the levels of files normalized, or in canonical form
+func runNormalize(files []string, canonical bool) error {
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		levels, err := readXSBCollection(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+
+		for _, xl := range levels {
+			l, err := xsbToLevel(xl.rows)
+			if err != nil {
+				return fmt.Errorf("%s line %d: %v", file, xl.line, err)
+			}
+
+			rows := levelToXSB(normalizeLevel(l))
+			if canonical {
+				rows = rows[:0]
+				for _, row := range canonicalGrid(l) {
+					rows = append(rows, string(row))
+				}
+			}
+			for _, row := range rows {
+				fmt.Println(strings.TrimRight(row, " "))
+			}
+
+			if xl.title != "" {
+				fmt.Printf("Title: %s\n", xl.title)
+			}
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Sokoban game
+//
+// Analysis view: K draws coordinates around the board, files a, b, c... from
+// left to right and ranks 1, 2, 3... from the top as in the XSB text, and
+// lists the pushes of the attempt in coordinate notation, "c4-c6" for a box
+// pushed from c4 to c6, to quote positions and solutions in forums.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const notationListed = 30 // pushes shown in the panel
+
+var showAnalysis = false
+
+// fileName returns the letters of column x: a to z, then aa, ab...
+func fileName(x int) string {
+
+	name := ""
+	for x++; x > 0; x = (x - 1) / 26 {
+		name = string(rune('a'+(x-1)%26)) + name
+	}
+	return name
+}
+
+// cellName returns the coordinates of cell x, y
+func cellName(x int, y int) string {
+
+	return fileName(x) + strconv.Itoa(y+1)
+}
+
+// pushNotation returns the pushes of the attempt in coordinate notation,
+// the pushes of a box in a line making one entry
+func pushNotation(s *GameState) []string {
+
+	type push struct {
+		fx, fy, tx, ty int
+		dir            byte
+	}
+
+	// take the moves back from the current position, which is known even
+	// when the undo limit dropped the first ones
+	l := s.curLev.clone()
+	var pushes []push
+	for i := len(s.moves) - 1; i >= 0; i-- {
+		m := s.moves[i]
+		if m.pushed {
+			dx, dy := dirDelta(m.dir)
+			pushes = append(pushes, push{l.px, l.py, l.px + dx, l.py + dy, m.dir})
+		}
+		l.unplay(m)
+	}
+
+	var list []string
+	var last *push
+	for i := len(pushes) - 1; i >= 0; i-- {
+		p := pushes[i]
+		if last != nil && p.dir == last.dir && p.fx == last.tx && p.fy == last.ty {
+			last.tx, last.ty = p.tx, p.ty
+			list[len(list)-1] = cellName(last.fx, last.fy) + "-" + cellName(last.tx, last.ty)
+			continue
+		}
+		list = append(list, cellName(p.fx, p.fy)+"-"+cellName(p.tx, p.ty))
+		last = &p
+	}
+	return list
+}
+
+func updateAnalysis(in InputSource) {
+
+	if in.IsKeyJustPressed(ebiten.KeyK) {
+		showAnalysis = !showAnalysis
+	}
+}
+
+// drawCoordinates labels the columns and rows of the board, as they are
+// shown when the view is turned
+func drawCoordinates(screen *ebiten.Image, s *GameState) {
+
+	l := s.curLev
+	w, h := viewSize(l, camera.view)
+	sx, sy, factor := boardView(s)
+	tile := baseTileSize * factor
+
+	label := func(x, y int, across bool) string {
+		// a quarter turn shows the rows of the level across
+		if across == (camera.view%2 == 0) {
+			return fileName(x)
+		}
+		return strconv.Itoa(y + 1)
+	}
+
+	for vx := 0; vx < w; vx++ {
+		x, y := viewToCell(l, camera.view, vx, 0)
+		msg := label(x, y, true)
+		cx := int(sx+float64(vx)*tile+tile/2) - 3*len(msg)
+		drawText(screen, msg, cx, int(sy)-18)
+		drawText(screen, msg, cx, int(sy+float64(h)*tile)+2)
+	}
+	for vy := 0; vy < h; vy++ {
+		x, y := viewToCell(l, camera.view, 0, vy)
+		msg := label(x, y, false)
+		cy := int(sy+float64(vy)*tile+tile/2) - 8
+		drawText(screen, msg, int(sx)-6*len(msg)-6, cy)
+		drawText(screen, msg, int(sx+float64(w)*tile)+6, cy)
+	}
+}
+
+// drawAnalysis shows the coordinates and the pushes in coordinate notation
+func drawAnalysis(screen *ebiten.Image, s *GameState) {
+
+	if !showAnalysis {
+		return
+	}
+
+	drawCoordinates(screen, s)
+
+	list := pushNotation(s)
+	first := 0
+	if len(list) > notationListed {
+		first = len(list) - notationListed
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pushes: %d\n\n", s.pushCount)
+	for i := first; i < len(list); i++ {
+		fmt.Fprintf(&b, "%4d. %s\n", i+1, list[i])
+	}
+	if len(list) == 0 {
+		b.WriteString("no pushes yet")
+	}
+
+	ebitenutil.DrawRect(screen, screenWidth-200, 300, 180, float64(16*(notationListed+3)), color.RGBA{0, 0, 0, 160})
+	drawText(screen, b.String(), screenWidth-190, 308)
+}
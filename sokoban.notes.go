@@ -0,0 +1,105 @@
+// Sokoban game
+//
+// Level notes: N opens a text entry to write a note on the current level
+// ("push left box first"), Enter keeps it, Esc cancels. Notes are saved with
+// the progress and shown in the level info panel.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const maxNoteLength = 200
+
+var (
+	noteEntryOpen = false
+	noteText      []rune
+)
+
+// updateNoteEntry returns true while the text entry takes the input
+func updateNoteEntry(s *GameState, in InputSource) bool {
+
+	if !noteEntryOpen {
+		if in.IsKeyJustPressed(ebiten.KeyN) && solverRun == nil && len(playback) == 0 {
+			noteEntryOpen = true
+			noteText = nil
+			if p, ok := levelsProgress()[s.currentLevelNumber]; ok {
+				noteText = []rune(p.Note)
+			}
+			return true
+		}
+		return false
+	}
+
+	for _, r := range in.AppendInputChars(nil) {
+		if len(noteText) < maxNoteLength {
+			noteText = append(noteText, r)
+		}
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyBackspace) && len(noteText) > 0 {
+		noteText = noteText[:len(noteText)-1]
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEnter) {
+		levelProgressFor(s.currentLevelNumber).Note = strings.TrimSpace(string(noteText))
+		saveProgress()
+		noteEntryOpen = false
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) {
+		noteEntryOpen = false
+	}
+
+	return true
+}
+
+// wrapText breaks s in lines of at most width characters
+func wrapText(s string, width int) string {
+
+	var lines []string
+	line := ""
+
+	for _, word := range strings.Fields(s) {
+		for len([]rune(word)) > width {
+			r := []rune(word)
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string(r[:width]))
+			word = string(r[width:])
+		}
+		if line == "" {
+			line = word
+		} else if len([]rune(line))+1+len([]rune(word)) <= width {
+			line += " " + word
+		} else {
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func drawNoteEntry(screen *ebiten.Image, s *GameState) {
+
+	if !noteEntryOpen {
+		return
+	}
+
+	text := wrapText(string(noteText)+"_", 150)
+
+	ebitenutil.DrawRect(screen, 450, 440, 1000, float64(100+16*strings.Count(text, "\n")), color.RGBA{40, 40, 60, 230})
+	drawText(screen, fmt.Sprintf("Note for level %d:\n\n%s\n\nEnter to keep, Esc to cancel", s.currentLevelNumber, text), 470, 450)
+}
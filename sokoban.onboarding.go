@@ -0,0 +1,107 @@
+// Sokoban game
+//
+// Onboarding: on the first launch, when there is no progress file yet, a
+// panel walks the player through moving, undoing and changing level, each
+// step waiting for the player to try it. Esc skips it. At the end level 1
+// starts afresh and the progress file is written, so it is shown once.
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	onboardingOff = iota
+	onboardingMove
+	onboardingUndo
+	onboardingLevels
+)
+
+var (
+	onboardingStep  = onboardingOff
+	onboardingMoves = 0 // moves made during the move step
+)
+
+var onboardingText = map[int]string{
+	onboardingMove: "Welcome to Sokoban! Push every box onto a goal.\n\n" +
+		"Move with the arrow keys or the arrow icons at the bottom right,\n" +
+		"or click a cell to walk there. Walk into a box to push it.\n\n" +
+		"Try a few moves.",
+	onboardingUndo: "A box pushed into a corner is stuck for good, but nothing is lost:\n\n" +
+		"Backspace or the icon at the top left undoes the last move.\n\n" +
+		"Try undoing one.",
+	onboardingLevels: "Solving a level opens the next one. PageUp and PageDown, or the\n" +
+		"icons at the top right, change level at any time, L lists them all\n" +
+		"and F1 shows every control.\n\n" +
+		"Press any key or click to start level 1.",
+}
+
+func startOnboarding() {
+
+	if firstLaunch {
+		onboardingStep = onboardingMove
+	}
+}
+
+func finishOnboarding(s *GameState) {
+
+	onboardingStep = onboardingOff
+	saveProgress()
+	s.loadLevel(levelAt(0))
+}
+
+func onboardingMoved(e GameEvent) {
+
+	if onboardingStep == onboardingMove && e.Moved {
+		if onboardingMoves++; onboardingMoves >= 3 {
+			onboardingStep = onboardingUndo
+		}
+	}
+}
+
+func onboardingUndone(e GameEvent) {
+
+	if onboardingStep == onboardingUndo {
+		onboardingStep = onboardingLevels
+	}
+}
+
+// updateOnboarding returns true when it took over the input, which it only
+// does on the last step: the first ones are played on the board
+func updateOnboarding(s *GameState, in InputSource, mouseOrTouch bool) bool {
+
+	if onboardingStep == onboardingOff {
+		return false
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) {
+		finishOnboarding(s)
+		return true
+	}
+
+	if onboardingStep == onboardingLevels {
+		if mouseOrTouch || len(in.AppendJustPressedKeys(nil)) > 0 {
+			finishOnboarding(s)
+		}
+		return true
+	}
+
+	return false
+}
+
+func drawOnboarding(screen *ebiten.Image) {
+
+	text, ok := onboardingText[onboardingStep]
+	if !ok {
+		return
+	}
+
+	x, y := screenWidth/2-300, screenHeight-260
+	ebitenutil.DrawRect(screen, float64(x-20), float64(y-20), 640, 150, color.RGBA{20, 20, 30, 230})
+	drawText(screen, text, x, y)
+	drawText(screen, "Esc skips the introduction", x, y+110)
+}
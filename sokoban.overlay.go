@@ -0,0 +1,173 @@
+// Sokoban game
+//
+// Stream overlay: with -listen the HTTP server also answers
+//
+//	/status     level, moves, pushes and timer as JSON
+//	/board.png  the board as an image, ?tile=32 sets the tile size
+//	/overlay    a page showing both, to use as an OBS browser source
+//
+// They are not shared with other origins: the overlay page is served from
+// the same address, and other web pages have no business reading the game.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type statusInfo struct {
+	remoteState
+	Started   time.Time `json:"started"`
+	Elapsed   float64   `json:"elapsed"` // seconds
+	BestMoves int       `json:"best_moves,omitempty"`
+}
+
+var (
+	tileSheetImage     image.Image
+	tileSheetImageOnce sync.Once
+)
+
+func registerOverlayHandlers() {
+
+	httpMux.HandleFunc("/status", serveStatus)
+	httpMux.HandleFunc("/board.png", serveBoardPNG)
+	httpMux.HandleFunc("/overlay", serveOverlay)
+}
+
+// lastStatus returns the state sent by the last broadcastState
+func lastStatus() (statusInfo, Level, bool) {
+
+	remoteLock.Lock()
+	defer remoteLock.Unlock()
+
+	if remoteLastState == nil {
+		return statusInfo{}, Level{}, false
+	}
+
+	var s statusInfo
+	json.Unmarshal(remoteLastState, &s.remoteState)
+	s.Started = remoteLastStart
+	s.Elapsed = time.Since(remoteLastStart).Seconds()
+	s.BestMoves = remoteLastBest
+
+	return s, remoteLastBoard, true
+}
+
+func serveStatus(w http.ResponseWriter, r *http.Request) {
+
+	s, _, ok := lastStatus()
+	if !ok {
+		http.Error(w, "game not started", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(s)
+}
+
+func serveBoardPNG(w http.ResponseWriter, r *http.Request) {
+
+	_, l, ok := lastStatus()
+	if !ok {
+		http.Error(w, "game not started", http.StatusServiceUnavailable)
+		return
+	}
+
+	tile := 32
+	if t, err := strconv.Atoi(r.URL.Query().Get("tile")); err == nil && t >= 4 && t <= 128 {
+		tile = t
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderBoardImage(l, tile)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
+
+const overlayPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Sokoban</title>
+<style>
+body { margin: 0; background: transparent; color: white; font: bold 24px sans-serif; text-shadow: 2px 2px 2px black; }
+img { display: block; image-rendering: pixelated; }
+</style></head>
+<body>
+<div id="status"></div>
+<img id="board">
+<script>
+async function refresh() {
+	try {
+		const s = await (await fetch("status")).json();
+		const t = Math.floor(s.elapsed);
+		document.getElementById("status").textContent =
+			"Level " + s.level + "  Moves " + s.moves + "  Pushes " + s.pushes +
+			"  " + Math.floor(t / 60) + ":" + String(t % 60).padStart(2, "0");
+		document.getElementById("board").src = "board.png?" + s.moves + "-" + s.level;
+	} catch (e) {}
+}
+refresh();
+setInterval(refresh, 500);
+</script>
+</body></html>
+`
+
+func serveOverlay(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(overlayPage))
+}
+
+// renderBoardImage draws l with tiles of the given size, without ebiten so
+// that it can run outside of the game loop
+func renderBoardImage(l Level, tile int) image.Image {
+
+	tileSheetImageOnce.Do(func() {
+		tileSheetImage, _ = png.Decode(bytes.NewReader(spritePNG))
+	})
+
+	full := image.NewRGBA(image.Rect(0, 0, 64*l.w, 64*l.h))
+
+	drawTile := func(x, y, num int) {
+		src := tileRect(num, baseTileSize).Min
+		dst := image.Rect(x*64, y*64, (x+1)*64, (y+1)*64)
+		draw.Draw(full, dst, tileSheetImage, src, draw.Over)
+	}
+
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			drawTile(x, y, EMPTY)
+			drawTile(x, y, int(l.grid[x][y]))
+		}
+	}
+	if l.grid != nil {
+		drawTile(l.px, l.py, int(l.psprite))
+	}
+
+	if tile == 64 {
+		return full
+	}
+
+	// nearest neighbour keeps the pixel art sharp
+	out := image.NewRGBA(image.Rect(0, 0, tile*l.w, tile*l.h))
+	b := out.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(x, y, full.At(x*64/tile, y*64/tile))
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,157 @@
+// Sokoban game
+//
+// Pack export: run with -export-pack pack.sok file.xsb ...
+//
+// Writes the levels of the XSB files, in the order given and with their
+// titles, as one collection carrying the pack title and author. A name
+// ending in .slc writes the XML collection format instead of plain text.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type packInfo struct {
+	title       string
+	author      string
+	description string
+}
+
+// writeSOK writes the levels as a text collection, each level followed by
+// its title as readXSBCollection reads them
+func writeSOK(w io.Writer, info packInfo, levels []xsbLevel) error {
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Title: %s\n", info.title)
+	if info.author != "" {
+		fmt.Fprintf(&b, "Author: %s\n", info.author)
+	}
+	if info.description != "" {
+		fmt.Fprintf(&b, "%s\n", info.description)
+	}
+
+	for i, xl := range levels {
+		b.WriteString("\n")
+		for _, row := range xl.rows {
+			b.WriteString(strings.TrimRight(row, " ") + "\n")
+		}
+		title := xl.title
+		if title == "" {
+			title = fmt.Sprintf("%s %d", info.title, i+1)
+		}
+		fmt.Fprintf(&b, "Title: %s\n", title)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// slcCollection is the layout of the .slc XML format
+type slcCollection struct {
+	XMLName     xml.Name `xml:"SokobanLevels"`
+	Title       string   `xml:"Title"`
+	Description string   `xml:"Description"`
+	Collection  struct {
+		Copyright string     `xml:"Copyright,attr"`
+		Levels    []slcLevel `xml:"Level"`
+	} `xml:"LevelCollection"`
+}
+
+type slcLevel struct {
+	ID     string   `xml:"Id,attr"`
+	Width  int      `xml:"Width,attr"`
+	Height int      `xml:"Height,attr"`
+	Rows   []string `xml:"L"`
+}
+
+func writeSLC(w io.Writer, info packInfo, levels []xsbLevel) error {
+
+	var c slcCollection
+	c.Title = info.title
+	c.Description = info.description
+	c.Collection.Copyright = info.author
+
+	for i, xl := range levels {
+		l := slcLevel{ID: xl.title, Height: len(xl.rows)}
+		if l.ID == "" {
+			l.ID = fmt.Sprint(i + 1)
+		}
+		for _, row := range xl.rows {
+			row = strings.TrimRight(row, " ")
+			if len(row) > l.Width {
+				l.Width = len(row)
+			}
+			l.Rows = append(l.Rows, row)
+		}
+		c.Collection.Levels = append(c.Collection.Levels, l)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// exportPack reads the levels of files and writes them to out, checking
+// that each one is a valid level first
+func exportPack(out string, info packInfo, files []string) error {
+
+	var levels []xsbLevel
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		list, err := readXSBCollection(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+
+		for _, xl := range list {
+			if _, err := xsbToLevel(xl.rows); err != nil {
+				return fmt.Errorf("%s line %d: %v", file, xl.line, err)
+			}
+		}
+		levels = append(levels, list...)
+	}
+
+	if len(levels) == 0 {
+		return fmt.Errorf("no levels in %s", strings.Join(files, ", "))
+	}
+	if info.title == "" {
+		info.title = strings.TrimSuffix(filepath.Base(out), filepath.Ext(out))
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(out), ".slc") {
+		err = writeSLC(f, info, levels)
+	} else {
+		err = writeSOK(f, info, levels)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		fmt.Printf("%d levels written to %s\n", len(levels), out)
+	}
+	return err
+}
@@ -0,0 +1,336 @@
+// Sokoban game
+//
+// Level packs: P lists the level files found in the levels directory next to
+// the settings (.xsb, .sok or .txt collections), each one with a thumbnail of
+// its first level, its title, its number of levels, its box counts and how
+// many of its levels are already known. Up/Down select a pack, Enter or a
+// click plays it, "built-in levels" goes back to the embedded levels and Esc
+// or P closes the list.
+//
+// Progress and replays are kept apart for each pack, the list showing how
+// much of each one is solved.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	packsDir = "levels"
+
+	packRowHeight = thumbHeight + 12
+	packRows      = 7
+)
+
+type levelPack struct {
+	id     string // the file name, also the key of its progress
+	title  string
+	levels []Level
+
+	minBoxes, maxBoxes int
+	duplicates         int // levels already embedded or earlier in the pack
+	err                error
+
+	thumb *ebiten.Image
+}
+
+var (
+	currentPack *levelPack // nil while the embedded levels are played
+
+	packBrowserOpen = false
+	packList        []*levelPack // nil stands for the embedded levels
+	packSelected    int
+
+	builtinThumb *ebiten.Image
+)
+
+// lastLevel returns the number of the last level of the pack being played
+func lastLevel() int {
+
+	if currentPack == nil {
+		return LEVEL_MAX
+	}
+	return len(currentPack.levels) - 1
+}
+
+// packID returns the id of the pack being played, "" for the embedded levels
+func packID() string {
+
+	if currentPack == nil {
+		return ""
+	}
+	return currentPack.id
+}
+
+// readPack reads the level collection in file
+func readPack(file string, known map[string]string) *levelPack {
+
+	p := &levelPack{id: filepath.Base(file)}
+	p.title = strings.TrimSuffix(p.id, filepath.Ext(p.id))
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		p.err = err
+		return p
+	}
+
+	// the title of the pack is given before its first level
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if isXSBRow(line) {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(line), "title:") {
+			if title := strings.TrimSpace(line[len("title:"):]); title != "" {
+				p.title = title
+			}
+			break
+		}
+	}
+
+	xls, err := readXSBCollection(bytes.NewReader(data))
+	if err == nil && len(xls) == 0 {
+		err = fmt.Errorf("no levels found")
+	}
+	if err != nil {
+		p.err = err
+		return p
+	}
+
+	for _, xl := range xls {
+		l, err := xsbToLevel(xl.rows)
+		if err != nil {
+			p.err = fmt.Errorf("line %d: %v", xl.line, err)
+			return p
+		}
+		boxes := boxCount(l)
+		if len(p.levels) == 0 || boxes < p.minBoxes {
+			p.minBoxes = boxes
+		}
+		if boxes > p.maxBoxes {
+			p.maxBoxes = boxes
+		}
+		p.levels = append(p.levels, l)
+	}
+
+	if dups, err := findDuplicates(known, p.id, xls); err == nil {
+		for _, name := range dups {
+			if name != "" {
+				p.duplicates++
+			}
+		}
+	}
+
+	return p
+}
+
+// boxCount returns the number of boxes of l, placed ones included
+func boxCount(l Level) int {
+
+	n := 0
+	for x := range l.grid {
+		for _, t := range l.grid[x] {
+			if t == BOX || t == PLACED_BOX {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// scanPacks reads every level file of the levels directory
+func scanPacks() []*levelPack {
+
+	list := []*levelPack{nil}
+
+	dir, err := userFilePath(packsDir)
+	if err != nil {
+		return list
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("cannot list the level packs: %v", err)
+		}
+		return list
+	}
+
+	var files []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".xsb", ".sok", ".txt":
+			if !e.IsDir() {
+				files = append(files, e.Name())
+			}
+		}
+	}
+	sort.Strings(files)
+
+	known := knownLevels()
+	for _, name := range files {
+		list = append(list, readPack(filepath.Join(dir, name), known))
+	}
+	return list
+}
+
+// usePack plays the levels of p, or the embedded levels when p is nil,
+// starting from its first level
+func usePack(s *GameState, p *levelPack) {
+
+	currentPack = p
+
+	// everything kept by level number belongs to the previous pack
+	levelIdentities = make(map[int]string)
+	s.events.publish(GameEvent{Kind: PackChanged, State: s})
+
+	adoptProgress(packID(), lastLevel()+1)
+	startShuffle(shuffleSeed)
+
+	// loading the level keeps the attempt left, which is of the old pack
+	s.loadLevel(levelAt(0))
+	s.sessions = nil
+
+	if p == nil {
+		showMessage("playing the built-in levels")
+	} else {
+		showMessage(fmt.Sprintf("playing %s, %d levels", p.title, len(p.levels)))
+	}
+}
+
+// packThumbnail returns the rendering of the first level of p
+func packThumbnail(sh *spriteSheets, p *levelPack) *ebiten.Image {
+
+	if p == nil {
+		if builtinThumb == nil {
+			builtinThumb = ebiten.NewImage(thumbWidth, thumbHeight)
+			sh.drawBoard(builtinThumb, embeddedLevel(0), 0, 0, thumbWidth, thumbHeight)
+		}
+		return builtinThumb
+	}
+
+	if p.thumb == nil {
+		p.thumb = ebiten.NewImage(thumbWidth, thumbHeight)
+		if len(p.levels) > 0 {
+			sh.drawBoard(p.thumb, p.levels[0], 0, 0, thumbWidth, thumbHeight)
+		}
+	}
+	return p.thumb
+}
+
+// packTop returns the first pack shown, keeping the selected one in view
+func packTop() int {
+
+	top := packSelected - packRows + 1
+	if top < 0 {
+		top = 0
+	}
+	return top
+}
+
+// updatePackBrowser returns true while the list takes the input
+func updatePackBrowser(s *GameState, in InputSource, mouseOrTouch bool, eventY int) bool {
+
+	if !packBrowserOpen {
+		if in.IsKeyJustPressed(ebiten.KeyP) && solverRun == nil {
+			packBrowserOpen = true
+			packList = scanPacks()
+			packSelected = 0
+			for i, p := range packList {
+				if p != nil && currentPack != nil && p.id == currentPack.id {
+					packSelected = i
+				}
+			}
+			return true
+		}
+		return false
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) || in.IsKeyJustPressed(ebiten.KeyP) {
+		packBrowserOpen = false
+		return true
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyArrowUp) && packSelected > 0 {
+		packSelected--
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowDown) && packSelected < len(packList)-1 {
+		packSelected++
+	}
+
+	pick := in.IsKeyJustPressed(ebiten.KeyEnter)
+
+	if mouseOrTouch {
+		i := packTop() + (eventY-60)/packRowHeight
+		if eventY >= 60 && i < len(packList) && i < packTop()+packRows {
+			packSelected = i
+			pick = true
+		}
+	}
+
+	if pick {
+		// the list tells why a pack cannot be played
+		p := packList[packSelected]
+		if p != nil && p.err != nil {
+			return true
+		}
+		packBrowserOpen = false
+		confirmDiscard(s, "Leave this level for the pack?", func() { usePack(s, p) })
+	}
+
+	return true
+}
+
+func drawPackBrowser(screen *ebiten.Image, sh *spriteSheets) {
+
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{20, 20, 30, 255})
+
+	dir, _ := userFilePath(packsDir)
+	drawText(screen, fmt.Sprintf("Level packs in %s   (Up/Down: select, Enter: play, Esc: back)", dir), 40, 30)
+
+	top := packTop()
+	for i := top; i < len(packList) && i < top+packRows; i++ {
+		p := packList[i]
+		y := 60 + (i-top)*packRowHeight
+
+		if i == packSelected {
+			ebitenutil.DrawRect(screen, 30, float64(y), screenWidth-60, packRowHeight-4, color.RGBA{80, 80, 120, 255})
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(40, float64(y+4))
+		screen.DrawImage(packThumbnail(sh, p), op)
+
+		var msg string
+		switch {
+		case p == nil:
+			msg = fmt.Sprintf("Built-in levels\n\n%d levels\n%d%% solved", LEVEL_MAX+1, packCompletion("", LEVEL_MAX+1))
+		case p.err != nil:
+			msg = fmt.Sprintf("%s\n%s\n\ncannot be played: %v", p.title, p.id, p.err)
+		default:
+			msg = fmt.Sprintf("%s\n%s\n\n%d levels, %d to %d boxes\n%d%% solved", p.title, p.id, len(p.levels), p.minBoxes, p.maxBoxes,
+				packCompletion(p.id, len(p.levels)))
+			if p.duplicates > 0 {
+				msg += fmt.Sprintf("\n%d already known levels", p.duplicates)
+			}
+		}
+		drawText(screen, msg, 60+thumbWidth, y+8)
+		if (p == nil && currentPack == nil) || (p != nil && p.id == packID()) {
+			drawText(screen, "playing", screenWidth-140, y+8)
+		}
+	}
+
+	if len(packList) == 1 {
+		drawText(screen, "No level files yet: copy .xsb, .sok or .txt collections in the directory above.", 40, 60+packRowHeight+20)
+	}
+}
@@ -0,0 +1,162 @@
+// Sokoban game
+//
+// Particles: dust when a box is pushed, sparkles when one lands on a goal
+// and confetti when a level is solved. Particles live in a fixed pool and
+// follow a ballistic path computed from their age, in screen coordinates so
+// that confetti outlives the level change. None are spawned in reduced
+// motion.
+
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const maxParticles = 512
+
+type particle struct {
+	alive   bool
+	x, y    float64 // where it started, pixels
+	vx, vy  float64 // pixels per second
+	gravity float64 // pixels per second squared
+	size    float64
+	c       color.RGBA
+	born    time.Time
+	life    time.Duration
+}
+
+var (
+	particles    [maxParticles]particle
+	particleNext = 0 // where spawnParticle looks for a free slot first
+)
+
+var confettiColors = []color.RGBA{
+	{230, 60, 60, 255}, {250, 200, 40, 255}, {60, 190, 90, 255}, {60, 140, 230, 255}, {200, 90, 220, 255},
+}
+
+// spawnParticle puts p in a free slot of the pool, or in place of the
+// oldest one when the pool is full
+func spawnParticle(p particle) {
+
+	if motionScale() == 0 {
+		return
+	}
+
+	i := particleNext
+	for n := 0; n < maxParticles && particles[i].alive; n++ {
+		i = (i + 1) % maxParticles
+	}
+
+	p.alive = true
+	p.born = time.Now()
+	particles[i] = p
+	particleNext = (i + 1) % maxParticles
+}
+
+// updateParticles frees the slots of the particles past their life
+func updateParticles() {
+
+	for i := range particles {
+		if particles[i].alive && time.Since(particles[i].born) >= particles[i].life {
+			particles[i].alive = false
+		}
+	}
+}
+
+func drawParticles(screen *ebiten.Image) {
+
+	for i := range particles {
+		p := &particles[i]
+		if !p.alive {
+			continue
+		}
+
+		age := time.Since(p.born)
+		if age >= p.life {
+			continue
+		}
+		t := age.Seconds()
+
+		x := p.x + p.vx*t
+		y := p.y + p.vy*t + p.gravity*t*t/2
+
+		c := p.c
+		c.A = uint8(float64(c.A) * (1 - float64(age)/float64(p.life)))
+		ebitenutil.DrawRect(screen, x-p.size/2, y-p.size/2, p.size, p.size, c)
+	}
+}
+
+// cellCenter returns where the middle of cell x, y is on the screen and
+// the size of a cell
+func cellCenter(s *GameState, x int, y int) (float64, float64, float64) {
+
+	px, py, tile := cellOnScreen(s, x, y)
+	return px + tile/2, py + tile/2, tile
+}
+
+// pushDust raises dust at the foot of the box just pushed, behind it
+func pushDust(e GameEvent) {
+
+	if !e.Pushed {
+		return
+	}
+
+	// the direction the push goes on the screen
+	dx, dy := dirDelta(viewDir(e.Dir, levelView(e.State.currentLevelNumber)))
+	x, y, tile := cellCenter(e.State, e.State.curLev.px, e.State.curLev.py)
+
+	for i := 0; i < 8; i++ {
+		spawnParticle(particle{
+			x:       x + float64(dx)*tile/2 + (rand.Float64()-0.5)*tile*0.6,
+			y:       y + float64(dy)*tile/2 + tile*0.3,
+			vx:      -float64(dx)*tile*(0.5+rand.Float64()) + (rand.Float64()-0.5)*tile,
+			vy:      -float64(dy)*tile*(0.5+rand.Float64()) - tile*rand.Float64(),
+			gravity: tile * 3,
+			size:    tile / 12,
+			c:       color.RGBA{170, 150, 120, 200},
+			life:    time.Duration(300+rand.Intn(200)) * time.Millisecond,
+		})
+	}
+}
+
+// placeSparkles bursts from a box placed on a goal
+func placeSparkles(e GameEvent) {
+
+	x, y, tile := cellCenter(e.State, e.X, e.Y)
+
+	for i := 0; i < 14; i++ {
+		a := 2 * math.Pi * (float64(i) + rand.Float64()) / 14
+		v := tile * (1 + rand.Float64())
+		spawnParticle(particle{
+			x: x, y: y,
+			vx:   math.Cos(a) * v,
+			vy:   math.Sin(a) * v,
+			size: tile / 10,
+			c:    color.RGBA{255, 230, 120, 255},
+			life: time.Duration(400+rand.Intn(300)) * time.Millisecond,
+		})
+	}
+}
+
+// levelConfetti rains confetti over the whole screen
+func levelConfetti(e GameEvent) {
+
+	for i := 0; i < 150; i++ {
+		spawnParticle(particle{
+			x:       rand.Float64() * screenWidth,
+			y:       -rand.Float64() * screenHeight / 4,
+			vx:      (rand.Float64() - 0.5) * 200,
+			vy:      rand.Float64() * 200,
+			gravity: 600,
+			size:    6 + rand.Float64()*6,
+			c:       confettiColors[rand.Intn(len(confettiColors))],
+			life:    time.Duration(1500+rand.Intn(1000)) * time.Millisecond,
+		})
+	}
+}
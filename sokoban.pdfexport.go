@@ -0,0 +1,208 @@
+// Sokoban game
+//
+// PDF export: run with -export-pdf levels.pdf [file.xsb ...]
+//
+// Writes the embedded levels, or the levels of the XSB files, to a PDF with
+// one A4 page per level, drawn as vector shapes to print and solve on paper:
+// walls are grey squares, goals dots, boxes squares and the player a ring.
+// The file is written by hand, the format being simple enough for that.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	pdfWidth   = 595 // A4, in points
+	pdfHeight  = 842
+	pdfMargin  = 50
+	pdfMaxCell = 36
+)
+
+// pdfLevel is a level and the title printed above it
+type pdfLevel struct {
+	title string
+	level Level
+}
+
+// pdfText escapes s for a PDF string, keeping it to ASCII
+func pdfText(s string) string {
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pdfCircle adds a circle of radius r around x, y to the path
+func pdfCircle(b *bytes.Buffer, x float64, y float64, r float64) {
+
+	k := 0.5523 * r // control points of the quarter arcs
+	fmt.Fprintf(b, "%.2f %.2f m\n", x+r, y)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+r, y+k, x+k, y+r, x, y+r)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-k, y+r, x-r, y+k, x-r, y)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-r, y-k, x-k, y-r, x, y-r)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+k, y-r, x+r, y-k, x+r, y)
+}
+
+// pdfPage returns the drawing of one page
+func pdfPage(pl pdfLevel, page int, pages int) []byte {
+
+	var b bytes.Buffer
+	l := pl.level
+
+	cell := float64(pdfWidth-2*pdfMargin) / float64(l.w)
+	if c := float64(pdfHeight-3*pdfMargin) / float64(l.h); c < cell {
+		cell = c
+	}
+	if cell > pdfMaxCell {
+		cell = pdfMaxCell
+	}
+	left := (pdfWidth - cell*float64(l.w)) / 2
+	top := float64(pdfHeight - 2*pdfMargin)
+
+	fmt.Fprintf(&b, "BT /F1 18 Tf %d %d Td (%s) Tj ET\n", pdfMargin, pdfHeight-pdfMargin-10, pdfText(pl.title))
+	fmt.Fprintf(&b, "BT /F1 9 Tf %d %d Td (%s) Tj ET\n", pdfMargin, pdfMargin/2, pdfText(fmt.Sprintf("Sokoban - %d/%d", page, pages)))
+
+	outside := outsideCells(l)
+	corner := func(x, y int) (float64, float64) {
+		return left + float64(x)*cell, top - float64(y+1)*cell
+	}
+
+	// floor grid, then walls
+	b.WriteString("0.75 G 0.5 w\n")
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			if !outside[x][y] && l.grid[x][y] != WALL {
+				cx, cy := corner(x, y)
+				fmt.Fprintf(&b, "%.2f %.2f %.2f %.2f re S\n", cx, cy, cell, cell)
+			}
+		}
+	}
+	b.WriteString("0.45 g\n")
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			if l.grid[x][y] == WALL {
+				cx, cy := corner(x, y)
+				fmt.Fprintf(&b, "%.2f %.2f %.2f %.2f re f\n", cx, cy, cell, cell)
+			}
+		}
+	}
+
+	// goals, boxes and the player in black
+	fmt.Fprintf(&b, "0 g 0 G %.2f w\n", cell*0.07)
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			cx, cy := corner(x, y)
+			t := l.grid[x][y]
+			if t == GOAL || t == PLACED_BOX {
+				pdfCircle(&b, cx+cell/2, cy+cell/2, cell*0.12)
+				b.WriteString("f\n")
+			}
+			if t == BOX || t == PLACED_BOX {
+				fmt.Fprintf(&b, "%.2f %.2f %.2f %.2f re S\n", cx+cell*0.18, cy+cell*0.18, cell*0.64, cell*0.64)
+			}
+		}
+	}
+	px, py := corner(l.px, l.py)
+	pdfCircle(&b, px+cell/2, py+cell/2, cell*0.3)
+	b.WriteString("S\n")
+
+	return b.Bytes()
+}
+
+// writePDF writes the levels, one per page
+func writePDF(path string, levels []pdfLevel) error {
+
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 1 catalog, 2 page tree, 3 font, then a page and its content for each
+	// level
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids []string
+	for i := range levels {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(levels)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+	for i, pl := range levels {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfWidth, pdfHeight, 5+2*i))
+		content := pdfPage(pl, i+1, len(levels))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// exportPDF writes the embedded levels, or the levels of files, to out
+func exportPDF(out string, files []string) error {
+
+	var levels []pdfLevel
+
+	if len(files) == 0 {
+		for n := 0; n <= LEVEL_MAX; n++ {
+			levels = append(levels, pdfLevel{fmt.Sprintf("Level %d", n), embeddedLevel(n)})
+		}
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		list, err := readXSBCollection(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+
+		for i, xl := range list {
+			l, err := xsbToLevel(xl.rows)
+			if err != nil {
+				return fmt.Errorf("%s line %d: %v", file, xl.line, err)
+			}
+			levels = append(levels, pdfLevel{xsbLevelName(filepath.Base(file), i, xl), l})
+		}
+	}
+
+	if len(levels) == 0 {
+		return fmt.Errorf("no levels in %s", strings.Join(files, ", "))
+	}
+
+	if err := writePDF(out, levels); err != nil {
+		return err
+	}
+	fmt.Printf("%d levels written to %s\n", len(levels), out)
+	return nil
+}
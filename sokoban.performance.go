@@ -0,0 +1,39 @@
+// Sokoban game
+//
+// Performance settings: the number of updates per second, vsync and an
+// overlay showing the frame and update rates
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	tickRate        = ebiten.DefaultTPS
+	vsyncEnabled    = true
+	showFPS         = false
+	tickRateChoices = []int{30, 60, 120, 144, 240}
+)
+
+func applyPerformanceSettings() {
+
+	if tickRate <= 0 {
+		tickRate = ebiten.DefaultTPS
+	}
+	if !idle {
+		ebiten.SetTPS(tickRate)
+	}
+	ebiten.SetVsyncEnabled(vsyncEnabled)
+}
+
+func drawFPSOverlay(screen *ebiten.Image) {
+
+	if !showFPS {
+		return
+	}
+	drawText(screen, fmt.Sprintf("FPS: %0.1f  TPS: %0.1f", ebiten.CurrentFPS(), ebiten.CurrentTPS()),
+		screenWidth-200, screenHeight-20)
+}
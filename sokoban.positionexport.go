@@ -0,0 +1,93 @@
+// Sokoban game
+//
+// Position export: F12 writes the board as it stands to a PNG file in the
+// positions directory next to the settings, without the HUD and with tiles
+// of 32 pixels, a size that suits forum posts. With the analysis view on (K)
+// the coordinates are drawn around it.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	positionsDir   = "positions"
+	positionTile   = 32
+	positionMargin = 24 // room for the coordinates
+)
+
+// positionImage returns the board of l, with its coordinates when asked
+func positionImage(l Level, coordinates bool) image.Image {
+
+	board := renderBoardImage(l, positionTile)
+	if !coordinates {
+		return board
+	}
+
+	w, h := l.w*positionTile, l.h*positionTile
+	img := ebiten.NewImage(w+2*positionMargin, h+2*positionMargin)
+	img.Fill(color.RGBA{20, 20, 30, 255})
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(positionMargin, positionMargin)
+	img.DrawImage(ebiten.NewImageFromImage(board), op)
+
+	for x := 0; x < l.w; x++ {
+		msg := fileName(x)
+		cx := positionMargin + x*positionTile + positionTile/2 - 3*len(msg)
+		drawText(img, msg, cx, 4)
+		drawText(img, msg, cx, positionMargin+h+4)
+	}
+	for y := 0; y < l.h; y++ {
+		msg := fmt.Sprint(y + 1)
+		cy := positionMargin + y*positionTile + positionTile/2 - 8
+		drawText(img, msg, positionMargin-6*len(msg)-4, cy)
+		drawText(img, msg, positionMargin+w+4, cy)
+	}
+
+	return img
+}
+
+// exportPosition writes the current board to a new PNG file
+func exportPosition(s *GameState) {
+
+	name := fmt.Sprintf("level-%d-%s.png", s.currentLevelNumber, time.Now().Format("20060102-150405"))
+	path, err := userFilePath(filepath.Join(positionsDir, name))
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	var f *os.File
+	if err == nil {
+		f, err = os.Create(path)
+	}
+	if err != nil {
+		showMessage(fmt.Sprintf("cannot export the position: %v", err))
+		return
+	}
+
+	err = png.Encode(f, positionImage(s.curLev, showAnalysis))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		showMessage(fmt.Sprintf("cannot export the position: %v", err))
+		return
+	}
+	showMessage("position written to " + path)
+}
+
+func updatePositionExport(s *GameState, in InputSource) {
+
+	if in.IsKeyJustPressed(ebiten.KeyF12) {
+		exportPosition(s)
+	}
+}
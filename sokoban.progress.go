@@ -0,0 +1,195 @@
+// Sokoban game
+//
+// Progress: the best results of every solved level and the player's notes,
+// kept in progress.json for each pack by level index, with the identity of
+// the level; results of the embedded levels are exportable as CSV with
+// -export-csv
+//
+// Levels finished by the solver are not recorded.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const progressFile = "progress.json"
+
+type levelProgress struct {
+	BestMoves   int       `json:"best_moves"`
+	BestPushes  int       `json:"best_pushes"`
+	BestTime    float64   `json:"best_time"` // seconds
+	TimesSolved int       `json:"times_solved"`
+	FirstSolved time.Time `json:"first_solved"`
+	LastSolved  time.Time `json:"last_solved"`
+	Note        string    `json:"note,omitempty"`
+	View        int       `json:"view,omitempty"`        // see levelView
+	HintPoints  int       `json:"hint_points,omitempty"` // steps of the hint ladder taken
+	Hash        string    `json:"hash,omitempty"`        // see levelIdentity
+}
+
+type progressData struct {
+	Levels map[int]*levelProgress            `json:"levels"`
+	Packs  map[string]map[int]*levelProgress `json:"packs,omitempty"` // of the custom packs, by pack id
+	Recent []recentPlay                      `json:"recent,omitempty"`
+}
+
+var (
+	progress progressData
+
+	// no progress file was found: the game has never been played here
+	firstLaunch = false
+)
+
+func loadProgress() {
+
+	err := loadJSON(progressFile, &progress)
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("cannot read progress: %v", err)
+	}
+	firstLaunch = os.IsNotExist(err)
+
+	if progress.Levels == nil {
+		progress.Levels = make(map[int]*levelProgress)
+	}
+}
+
+func saveProgress() {
+
+	if err := saveJSON(progressFile, &progress); err != nil {
+		log.Printf("cannot save progress: %v", err)
+	}
+}
+
+// packProgress returns the progress of the levels of pack id, by level
+// index; "" is the embedded levels
+func packProgress(id string) map[int]*levelProgress {
+
+	if id == "" {
+		return progress.Levels
+	}
+	if progress.Packs == nil {
+		progress.Packs = make(map[string]map[int]*levelProgress)
+	}
+	m, ok := progress.Packs[id]
+	if !ok {
+		m = make(map[int]*levelProgress)
+		progress.Packs[id] = m
+	}
+	return m
+}
+
+// levelsProgress returns the progress of the levels of the pack being
+// played
+func levelsProgress() map[int]*levelProgress {
+
+	return packProgress(packID())
+}
+
+// packCompletion returns the percentage of the levels of pack id solved,
+// the pack having count levels
+func packCompletion(id string, count int) int {
+
+	if count == 0 {
+		return 0
+	}
+	levels := progress.Levels
+	if id != "" {
+		// reading does not add the pack to the file
+		levels = progress.Packs[id]
+	}
+	solved := 0
+	for n, p := range levels {
+		if n < count && p.TimesSolved > 0 {
+			solved++
+		}
+	}
+	return solved * 100 / count
+}
+
+// levelProgressFor returns the progress of level n, creating it if needed
+func levelProgressFor(n int) *levelProgress {
+
+	levels := levelsProgress()
+	p, ok := levels[n]
+	if !ok {
+		p = &levelProgress{}
+		levels[n] = p
+	}
+	if p.Hash == "" {
+		p.Hash = levelIdentity(n)
+	}
+	return p
+}
+
+// recordCompletion updates the progress of the level just solved
+func recordCompletion(s *GameState) {
+
+	if s.assisted {
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(s.levelStart).Seconds()
+	moves := s.moveCount()
+
+	p := levelProgressFor(s.currentLevelNumber)
+	if p.TimesSolved == 0 {
+		p.BestMoves, p.BestPushes, p.BestTime = moves, s.pushCount, elapsed
+		p.FirstSolved = now
+	}
+
+	if moves < p.BestMoves {
+		p.BestMoves = moves
+	}
+	if s.pushCount < p.BestPushes {
+		p.BestPushes = s.pushCount
+	}
+	if elapsed < p.BestTime {
+		p.BestTime = elapsed
+	}
+	p.TimesSolved++
+	p.LastSolved = now
+
+	saveProgress()
+}
+
+func exportProgressCSV(path string) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"level", "solved", "best_moves", "best_pushes", "best_time_seconds", "times_solved", "first_solved", "last_solved"})
+
+	for n := 0; n <= LEVEL_MAX; n++ {
+		p, ok := progress.Levels[n]
+		if !ok || p.TimesSolved == 0 {
+			w.Write([]string{strconv.Itoa(n), "no", "", "", "", "0", "", ""})
+			continue
+		}
+		w.Write([]string{
+			strconv.Itoa(n), "yes",
+			strconv.Itoa(p.BestMoves),
+			strconv.Itoa(p.BestPushes),
+			fmt.Sprintf("%.1f", p.BestTime),
+			strconv.Itoa(p.TimesSolved),
+			p.FirstSolved.Format(time.RFC3339),
+			p.LastSolved.Format(time.RFC3339),
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
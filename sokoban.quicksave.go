@@ -0,0 +1,111 @@
+// Sokoban game
+//
+// Quick-save slots: F6, F7 and F8 select slot 1, 2 or 3, F5 saves the game
+// in the selected slot and F9 loads it back
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	quickSaveFile  = "quicksave.json"
+	quickSaveSlots = 3
+)
+
+type quickSave struct {
+	Level int       `json:"level"`
+	Pack  string    `json:"pack,omitempty"` // see packID
+	Moves string    `json:"moves"`          // LURD, replayed from the start of the level
+	Saved time.Time `json:"saved"`
+}
+
+var quickSaveSlot = 0
+
+func loadQuickSaves() []*quickSave {
+
+	saves := make([]*quickSave, quickSaveSlots)
+
+	if err := loadJSON(quickSaveFile, &saves); err != nil && !os.IsNotExist(err) {
+		log.Printf("cannot read quick saves: %v", err)
+	}
+
+	for len(saves) < quickSaveSlots {
+		saves = append(saves, nil)
+	}
+	return saves
+}
+
+func quickSaveGame(s *GameState) {
+
+	if !s.historyComplete() {
+		showMessage("cannot save: the first moves were dropped by the undo limit")
+		return
+	}
+	if s.hasPulls() {
+		showMessage("cannot save: a box was pulled back")
+		return
+	}
+
+	saves := loadQuickSaves()
+	saves[quickSaveSlot] = &quickSave{s.currentLevelNumber, packID(), historyToLURD(s.moves), time.Now()}
+
+	if err := saveJSON(quickSaveFile, saves); err != nil {
+		showMessage(fmt.Sprintf("quick save failed: %v", err))
+		return
+	}
+	quickSavedLevel, quickSavedMoves = s.currentLevelNumber, s.moveCount()
+	showMessage(fmt.Sprintf("saved in slot %d", quickSaveSlot+1))
+}
+
+func quickLoadGame(s *GameState) {
+
+	save := loadQuickSaves()[quickSaveSlot]
+	if save == nil {
+		showMessage(fmt.Sprintf("slot %d is empty", quickSaveSlot+1))
+		return
+	}
+
+	if save.Pack != packID() {
+		showMessage(fmt.Sprintf("slot %d was saved in another level pack", quickSaveSlot+1))
+		return
+	}
+
+	m, err := lurdToMoves(save.Moves)
+	if err != nil || save.Level < 0 || save.Level > lastLevel() {
+		showMessage(fmt.Sprintf("slot %d is damaged", quickSaveSlot+1))
+		return
+	}
+
+	playback = nil
+	s.loadLevel(save.Level)
+	for _, d := range m {
+		s.playMove(d)
+	}
+
+	showMessage(fmt.Sprintf("loaded slot %d (saved %s)", quickSaveSlot+1, save.Saved.Format("Jan 2 15:04")))
+}
+
+func updateQuickSave(s *GameState, in InputSource) {
+
+	for i, k := range []ebiten.Key{ebiten.KeyF6, ebiten.KeyF7, ebiten.KeyF8} {
+		if in.IsKeyJustPressed(k) {
+			quickSaveSlot = i
+			showMessage(fmt.Sprintf("quick save slot %d selected", i+1))
+		}
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyF5) {
+		quickSaveGame(s)
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyF9) {
+		confirmDiscard(s, "Load the quick save?", func() { quickLoadGame(s) })
+	}
+}
@@ -0,0 +1,215 @@
+// Sokoban game
+//
+// Player reachability: the floor cells the player can walk to, kept up to
+// date as the level is played instead of being flood filled again whenever
+// they are needed. A walk changes nothing. A push frees the cell the box
+// leaves, from which the cells grow again, and takes the cell it goes to,
+// which is all it does unless that cell is the only way between the cells
+// around it: only then are the cells filled again from the player.
+//
+// Click to move turns down the cells outside of them before looking for a
+// path, and the "reachable squares" setting tints them. The solver grows
+// its areas the same way (see floorBits.grow) but fills them for each of
+// its positions, since it also needs how far each cell is from the player.
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// the cells the player of a level can walk to
+type playerReach struct {
+	floorBits
+	h                           int
+	level                       int // the number of the level
+	player                      int
+	boxes, area, frontier, next bitboard
+}
+
+var (
+	gameReach = playerReach{level: -1}
+
+	// the reachable squares are tinted
+	showReach = false
+)
+
+// reset finds the cells of level n, l, from scratch
+func (r *playerReach) reset(n int, l Level) {
+
+	lb := bitsOfLevel(l)
+	r.floorBits = newFloorBits(lb)
+	r.h, r.level, r.player = l.h, n, lb.player
+	r.boxes = lb.boxes
+	r.area = newBitboard(l.w * l.h)
+	r.frontier = newBitboard(l.w * l.h)
+	r.next = newBitboard(l.w * l.h)
+	r.fill()
+}
+
+// fill finds the cells again from the player
+func (r *playerReach) fill() {
+
+	r.area.clear()
+	r.spread(r.player)
+}
+
+// spread adds cell c and the cells that can be walked to from it
+func (r *playerReach) spread(c int) {
+
+	r.area.set(c)
+	r.frontier.clear()
+	r.frontier.set(c)
+	for r.grow(r.area, r.frontier, r.next, r.boxes) {
+		r.frontier, r.next = r.next, r.frontier
+	}
+}
+
+// neighbour returns the cell next to c by dx, dy, or -1 off the board
+func (r *playerReach) neighbour(c int, dx int, dy int) int {
+
+	x, y := c%r.w+dx, c/r.w+dy
+	if x < 0 || y < 0 || x >= r.w || y >= r.h {
+		return -1
+	}
+	return y*r.w + x
+}
+
+// splits tells if taking cell c from the cells may cut them in two: its
+// neighbours among them are not all joined by the ring of eight cells
+// around c
+func (r *playerReach) splits(c int) bool {
+
+	ring := [8][2]int{{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}}
+	in := func(k int) bool {
+		n := r.neighbour(c, ring[k%8][0], ring[k%8][1])
+		return n >= 0 && r.area.has(n)
+	}
+
+	// the runs of cells of the ring, those holding a neighbour counted
+	start := 0
+	for start < 8 && in(start) {
+		start++
+	}
+	if start == 8 {
+		return false
+	}
+	runs := 0
+	for k := start + 1; k <= start+8; k++ {
+		if !in(k) {
+			continue
+		}
+		side := false
+		for ; k <= start+8 && in(k); k++ {
+			side = side || k%2 == 0
+		}
+		if side {
+			runs++
+		}
+	}
+	return runs > 1
+}
+
+// boxMoved follows a box going from cell from to cell to, the player now
+// standing on cell player
+func (r *playerReach) boxMoved(from int, to int, player int) {
+
+	r.boxes.unset(from)
+	r.boxes.set(to)
+	r.player = player
+
+	if r.area.has(to) {
+		if r.splits(to) {
+			r.fill()
+			return
+		}
+		r.area.unset(to)
+	}
+
+	for _, d := range directions {
+		dx, dy := dirDelta(d)
+		if n := r.neighbour(from, dx, dy); n >= 0 && r.area.has(n) {
+			r.spread(from)
+			break
+		}
+	}
+	if !r.area.has(player) {
+		r.fill()
+	}
+}
+
+// sync makes sure the cells are those of the level of s, finding them
+// again if it changed in a way they did not follow
+func (r *playerReach) sync(s *GameState) {
+
+	l := s.curLev
+	if r.level != s.currentLevelNumber || r.w != l.w || r.h != l.h {
+		r.reset(s.currentLevelNumber, l)
+		return
+	}
+	for _, c := range r.boxes.appendCells(nil) {
+		if t := l.grid[int(c)%r.w][int(c)/r.w]; t != BOX && t != PLACED_BOX {
+			r.reset(s.currentLevelNumber, l)
+			return
+		}
+	}
+
+	r.player = l.py*r.w + l.px
+	if !r.area.has(r.player) {
+		r.fill()
+	}
+}
+
+// reachable tells if the player of s can walk to cell x, y
+func (r *playerReach) reachable(s *GameState, x int, y int) bool {
+
+	r.sync(s)
+	return r.area.has(y*r.w + x)
+}
+
+func reachLevelStarted(e GameEvent) {
+
+	gameReach.reset(e.State.currentLevelNumber, e.State.curLev)
+}
+
+func reachMoved(e GameEvent) {
+
+	s := e.State
+	if !e.Moved || len(s.moves) == 0 || gameReach.level != s.currentLevelNumber {
+		return
+	}
+
+	// the box went from the cell the player stands on, or followed it
+	last := s.moves[len(s.moves)-1]
+	dx, dy := dirDelta(last.dir)
+	px, py := s.curLev.px, s.curLev.py
+	switch {
+	case last.pushed:
+		gameReach.boxMoved(py*gameReach.w+px, (py+dy)*gameReach.w+px+dx, py*gameReach.w+px)
+	case last.pulled:
+		gameReach.boxMoved((py-2*dy)*gameReach.w+px-2*dx, (py-dy)*gameReach.w+px-dx, py*gameReach.w+px)
+	default:
+		gameReach.player = py*gameReach.w + px
+	}
+}
+
+func reachUndone(e GameEvent) {
+
+	gameReach.sync(e.State)
+}
+
+func drawReach(screen *ebiten.Image, s *GameState) {
+
+	if !showReach || blindHidden(s) != blindOff {
+		return
+	}
+	gameReach.sync(s)
+
+	for _, c := range gameReach.area.appendCells(nil) {
+		x, y, tile := cellOnScreen(s, int(c)%gameReach.w, int(c)/gameReach.w)
+		ebitenutil.DrawRect(screen, x, y, tile, tile, color.RGBA{120, 200, 255, 60})
+	}
+}
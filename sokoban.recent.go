@@ -0,0 +1,177 @@
+// Sokoban game
+//
+// Recently played: the last packs played, each with the level left there,
+// are kept in progress.json. When there are some, the game starts on a title
+// screen listing them: Up/Down and Enter, a click or the number of an entry
+// resume it, Esc or Space start from the first level as usual.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	recentMax = 6
+
+	titleScale = 8
+	recentTop  = 340 // where the list starts on the title screen
+	recentRow  = 40
+)
+
+type recentPlay struct {
+	Pack   string    `json:"pack,omitempty"` // see packID
+	Title  string    `json:"title"`
+	Level  int       `json:"level"`
+	Played time.Time `json:"played"`
+}
+
+var (
+	titleOpen     = false
+	titleSelected = 0
+	titleNotice   string
+	titleImage    *ebiten.Image
+)
+
+// startTitle shows the title screen when there is something to resume
+func startTitle() {
+
+	titleOpen = !firstLaunch && len(progress.Recent) > 0
+}
+
+// recentLevelStarted puts the pack being played first in the list
+func recentLevelStarted(e GameEvent) {
+
+	if titleOpen || onboardingStep != onboardingOff {
+		return
+	}
+
+	title := "Built-in levels"
+	if currentPack != nil {
+		title = currentPack.title
+	}
+	list := []recentPlay{{packID(), title, e.State.currentLevelNumber, time.Now()}}
+	for _, r := range progress.Recent {
+		if r.Pack != packID() && len(list) < recentMax {
+			list = append(list, r)
+		}
+	}
+	progress.Recent = list
+	saveProgress()
+}
+
+// resumeRecent loads the pack of r at its level
+func resumeRecent(s *GameState, r recentPlay) bool {
+
+	if r.Pack != packID() {
+		if r.Pack == "" {
+			usePack(s, nil)
+		} else {
+			dir, err := userFilePath(packsDir)
+			if err != nil {
+				titleNotice = err.Error()
+				return false
+			}
+			file := filepath.Join(dir, r.Pack)
+			if _, err := os.Stat(file); err != nil {
+				titleNotice = fmt.Sprintf("%s is no longer in %s", r.Pack, dir)
+				return false
+			}
+			p := readPack(file, knownLevels())
+			if p.err != nil {
+				titleNotice = fmt.Sprintf("%s cannot be played: %v", r.Pack, p.err)
+				return false
+			}
+			usePack(s, p)
+		}
+	}
+
+	playback = nil
+	s.loadLevel(r.Level)
+	return true
+}
+
+// updateTitle returns true while the title screen takes the input
+func updateTitle(s *GameState, in InputSource, mouseOrTouch bool, eventY int) bool {
+
+	if !titleOpen {
+		return false
+	}
+
+	list := progress.Recent
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) || in.IsKeyJustPressed(ebiten.KeySpace) {
+		titleOpen = false
+		return true
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyArrowUp) && titleSelected > 0 {
+		titleSelected--
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowDown) && titleSelected < len(list)-1 {
+		titleSelected++
+	}
+
+	pick := in.IsKeyJustPressed(ebiten.KeyEnter)
+
+	for i := range list {
+		if in.IsKeyJustPressed(ebiten.KeyDigit1 + ebiten.Key(i)) {
+			titleSelected, pick = i, true
+		}
+	}
+
+	if mouseOrTouch {
+		i := (eventY - recentTop) / recentRow
+		if eventY >= recentTop && i < len(list) {
+			titleSelected, pick = i, true
+		}
+	}
+
+	if pick && titleSelected < len(list) {
+		titleOpen = false
+		if !resumeRecent(s, list[titleSelected]) {
+			titleOpen = true
+		}
+	}
+
+	return true
+}
+
+func drawTitle(screen *ebiten.Image) {
+
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{20, 20, 30, 255})
+
+	if titleImage == nil {
+		titleImage = ebiten.NewImage(48, 16)
+		drawText(titleImage, "SOKOBAN", 2, 0)
+	}
+	w, _ := titleImage.Size()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(titleScale, titleScale)
+	op.GeoM.Translate(screenWidth/2-float64(w*titleScale)/2, 120)
+	screen.DrawImage(titleImage, op)
+
+	x := screenWidth/2 - 250
+	drawText(screen, "Recently played", x, recentTop-40)
+
+	for i, r := range progress.Recent {
+		y := recentTop + i*recentRow
+		if i == titleSelected {
+			ebitenutil.DrawRect(screen, float64(x-20), float64(y-8), 540, recentRow-4, color.RGBA{80, 80, 120, 255})
+		}
+		drawText(screen, fmt.Sprintf("%d. %-36.36s level %-4d %s", i+1, r.Title, r.Level, r.Played.Format("Jan 2 15:04")), x, y)
+	}
+
+	y := recentTop + len(progress.Recent)*recentRow + 30
+	drawText(screen, "Enter, a click or 1-"+fmt.Sprint(len(progress.Recent))+" resumes, Esc or Space starts from the first level", x, y)
+	if titleNotice != "" {
+		drawText(screen, titleNotice, x, y+30)
+	}
+}
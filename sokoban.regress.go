@@ -0,0 +1,197 @@
+// Sokoban game
+//
+// Replay regression check: run with -regress, or go test
+//
+// Plays a recorded LURD sequence on every embedded level and compares the
+// final board with the recorded one, so that changes to the level data,
+// decompressLevel or the move rules are noticed. The sequences are
+// solutions where the solver found one quickly and a fixed walk elsewhere.
+// Run with -regress-record to print a new table after an intended change.
+//
+// Each sequence is also played as the arrow keys a player would press,
+// through Game.Update with a scripted InputSource, which must end the same
+// way: the input handling is checked along with the rules.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type regressionCase struct {
+	level  int
+	lurd   string
+	pushes int
+	solved bool
+	hash   string // levelHash of the final board
+}
+
+// regressionResult plays c and returns what the engine ended with
+func regressionResult(c regressionCase) (regressionCase, error) {
+
+	moves, err := lurdToMoves(c.lurd)
+	if err != nil {
+		return c, err
+	}
+
+	s := newGameState(c.level)
+	for _, d := range moves {
+		s.playMove(d)
+	}
+
+	got := c
+	got.pushes = s.pushCount
+	got.solved = s.nBoxesLeft() == 0
+	got.hash = levelHash(s.curLev)
+
+	return got, nil
+}
+
+// lurdFrames returns the frames pressing the arrow keys of moves, one move
+// every other update
+func lurdFrames(moves []byte) []inputFrame {
+
+	keys := map[byte]ebiten.Key{
+		UP:    ebiten.KeyArrowUp,
+		DOWN:  ebiten.KeyArrowDown,
+		LEFT:  ebiten.KeyArrowLeft,
+		RIGHT: ebiten.KeyArrowRight,
+	}
+	frames := make([]inputFrame, 0, 2*len(moves))
+	for _, d := range moves {
+		frames = append(frames, inputFrame{Keys: []ebiten.Key{keys[d]}}, inputFrame{})
+	}
+	return frames
+}
+
+// headlessGame returns a game on level n reading in, with the arrow keys
+// and staying on the level once solved; the title screen and the
+// introduction are only opened by main
+func headlessGame(n int, in InputSource) *Game {
+
+	stayOnSolved, controlPreset = true, "standard"
+	skipIntro(n)
+	return &Game{state: newGameState(n), input: in, sheets: newSpriteSheets()}
+}
+
+// scriptedResult plays c through Game.Update and returns what the game
+// ended with
+func scriptedResult(c regressionCase) (regressionCase, error) {
+
+	moves, err := lurdToMoves(c.lurd)
+	if err != nil {
+		return c, err
+	}
+
+	in := newScriptedInput(lurdFrames(moves)...)
+	g := headlessGame(c.level, in)
+	for !in.done() {
+		if err := g.Update(); err != nil {
+			return c, err
+		}
+		in.advance()
+	}
+
+	got := c
+	got.pushes = g.state.pushCount
+	got.solved = g.state.nBoxesLeft() == 0
+	got.hash = levelHash(g.state.curLev)
+
+	return got, nil
+}
+
+func runRegression() bool {
+
+	ok := true
+
+	// what the game saves while the sequences are played goes nowhere
+	dir, err := os.MkdirTemp("", "sokoban-regress")
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	defer os.RemoveAll(dir)
+	dataDir = dir
+
+	if len(regressionCases) != LEVEL_MAX+1 {
+		fmt.Printf("%d recorded sequences for %d levels\n", len(regressionCases), LEVEL_MAX+1)
+		ok = false
+	}
+
+	for _, c := range regressionCases {
+
+		status := checkRegression(c)
+		if status != "ok" {
+			ok = false
+		}
+
+		fmt.Printf("level %2d: %4d moves  %s\n", c.level, len(c.lurd), status)
+	}
+
+	return ok
+}
+
+// checkRegression plays c directly and through Game.Update, and returns
+// "ok" or what went wrong
+func checkRegression(c regressionCase) string {
+
+	got, err := regressionResult(c)
+	played, perr := scriptedResult(c)
+
+	switch {
+	case err != nil:
+		return "INVALID SEQUENCE: " + err.Error()
+	case perr != nil:
+		return "UPDATE FAILED: " + perr.Error()
+	case got.solved != c.solved:
+		return fmt.Sprintf("REGRESSION (solved %v, expected %v)", got.solved, c.solved)
+	case got.pushes != c.pushes:
+		return fmt.Sprintf("REGRESSION (%d pushes, expected %d)", got.pushes, c.pushes)
+	case got.hash != c.hash:
+		return "REGRESSION (different final board)"
+	case played != got:
+		return fmt.Sprintf("REGRESSION (through Update: %d pushes, solved %v, same board %v)",
+			played.pushes, played.solved, played.hash == got.hash)
+	}
+	return "ok"
+}
+
+// recordRegression prints the table of regressionCases for the current
+// engine, solving each level within nodes positions
+func recordRegression(nodes int) {
+
+	fmt.Println("var regressionCases = []regressionCase{")
+
+	for n := 0; n <= LEVEL_MAX; n++ {
+
+		c := regressionCase{level: n}
+
+		res := solveLevel(levelTemplate(n), optimizePushes, nodes, nil)
+		if res.solved {
+			c.lurd = movesToLURD(res.moves)
+		} else {
+			c.lurd = regressionWalk(n, 300)
+		}
+
+		c, _ = regressionResult(c)
+		fmt.Printf("\t{%d, %q, %d, %v, %q},\n", c.level, c.lurd, c.pushes, c.solved, c.hash)
+	}
+
+	fmt.Println("}")
+}
+
+// regressionWalk returns a fixed pseudo random sequence of n moves
+func regressionWalk(seed int, n int) string {
+
+	moves := make([]byte, n)
+	x := uint32(seed)*2654435761 + 1
+
+	for i := range moves {
+		x = x*1664525 + 1013904223
+		moves[i] = directions[x>>30]
+	}
+	return movesToLURD(moves)
+}
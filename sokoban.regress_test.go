@@ -0,0 +1,28 @@
+// Sokoban game
+//
+// The replay regression check of -regress, under go test
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRegressionCases(t *testing.T) {
+
+	// what the game saves while the sequences are played goes nowhere
+	dataDir = t.TempDir()
+
+	if len(regressionCases) != LEVEL_MAX+1 {
+		t.Errorf("%d recorded sequences for %d levels", len(regressionCases), LEVEL_MAX+1)
+	}
+
+	for _, c := range regressionCases {
+		t.Run(fmt.Sprintf("level %d", c.level), func(t *testing.T) {
+			if status := checkRegression(c); status != "ok" {
+				t.Error(status)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Sokoban game
+//
+// Recorded sequences of the replay regression check, printed by
+// -regress-record
+
+package main
+
+var regressionCases = []regressionCase{
+	{0, "rrrr", 3, true, "01760671a11a24c7af91b0e8632ab330"},
+	{1, "rrrlllllll", 4, true, "07ac79d236e51dc19c16ab92a2a4827d"},
+	{2, "lddddrrruuudddllluuuurrrrr", 5, true, "8ebe98e6f1b561a87e04daf55472c3ff"},
+	{3, "rurlrlurldurlrdllulrlrlruruurldrduuddlrruuurudlrlluduululrrullurrrudlrdlududurrrurdludldulrrdlrldddrrrurddururlrluullruudldlurrdrruurldldurduldulldlllldrulurududlulduuuduurlrduurrurdlluluduldrdruudlllrrrudrdduduuuullldlluurduuuruududldrlururululdddurlldddudlurdrlludludlludrlurduudrldulduudrddudrrdru", 5, false, "c93084eceaf84b69909d553d57b72fb7"},
+	{4, "rlrrdrllurlrllduldldrulrrlrdrllluluuddrlurlurudrluddrudurdururldrlrrrudrdrullrldrlrulrrlrlrurduddurududuululrududdurlududruudlrldrlddrldddlurllurrrurlruudurdddddllrulurldlurudrlduulrdrdddrruuuluuulddulrdduuuudldldrrllrldllrrurdrdlluduluuduuddruludlurlrrlddduldrddlllruurdruuuldddldrrurddldldrudrlldrd", 0, false, "168e9277184ffca8e11d8c1a4fa289c1"},
+	{5, "rlrldldududuurdruuullldrdrdudluuddlrludrurlulrrrldllduduldldrlrlurllulllrlrrlddllrurludrrddlururdduuldlllluudurudullllrrdudrrddulrluddurludrldurllruddddlurrlurrdllldruduurdulddrllurrrurrrrddrduurlldrurrurllrrlrlrrlldlldrrrrrudldrluulrruruullllrdddrldldrldlrurluddlluuudlrrruudllurrrlrdrrdurluduldudrl", 5, false, "ceaaa01fa8afc5f19a02de379f4a3324"},
+	{6, "rluulrdrlluuullluduurddrlllldlrdurllllduudlurlurlluluudurlrldruuulurdllrlrrddduuruddddudddldluuldrluurrrruudllulrrldllldddrdurlrruddlurulruludrdrruuldluddrdlluldudruuruddlrldlluulrlrullululllururllrururdurdlludrulrrrlrruulruuludldrrlldlddulrurrdrdddldluudurruudddddrluuuuddurruudurrdduurddduurddudddr", 11, false, "f0df16121d4f80675d8a720bd1a5f5a8"},
+	{7, "rdudldrdurlurrluuuuruddrururdlrurululrldudlulrlrurrurududluruldrurrlrdllrudurddrldrldrrudrlddluddldlruduurululululluldrdduludluddudulrdlllludrdddlurrdudurdluruddurlrlrrlurldrludrdruuurdlduurrldurllrlddrlduruurullrdlulurllrduuuddddrruullluuldrddrldlrldrurdruddrrlddrrrlddlluuduurudrruurlrrlurlluullddd", 14, false, "fc239b8956c0bd7c47cbd2424681ae06"},
+	{8, "rduuluuurururdurulrddrdudlrldldrldddluuuudlurldduddrllduuuldrrrdlllrlrlrlddrrludruuurudrlrurrduuddrldluldrurrdldluddldlldlrrrdrllurdulldlrddlrllurlrdrdrlllurlludurrldddudlduulrrdrddulurdrlrddrluddlrdludrrdlrdlruruuruldudrldlurldurddudrlldudldudrddrruddurdllduduldduduluullrudlrrrrurdrddurrrddrdlruddl", 15, false, "91bc6057b03bb3e309a890088d673001"},
+	{9, "rdurudlrdluuruudurrluurulrddlullrrdlududuldudrrduluruldudurldlullruudrllrulluldddllrrlulluuuurulduullrrdrduldddddddulrrudrldurduuuruuuurululruuuduldurlldrurdddlduulrrdururrllldllrduldllrllduuluuddlurlldluudluulduddlllrururdlldullrldrldduuudrldduuddurdulddulllullrdlldllrdululdddlluruduruullldulrudddr", 2, false, "4db81e25d9119384b1a689ce244303f4"},
+	{10, "rruluuddudluddululrudlruulduluuuludrurruulduuludurrdrlduuruulrdudlrduuurllluululurrdudruuurlluudldllluluuluulrrrrurdlrlrdlrudulrruulrdruuulrduruudddrrurrlrrlurrdruulllrddulddllduuddldrduddlrddrlldluuurdudlrurruululrdllludlddlldlruudruldrdurduululdllrdrlddrdlrrrlrrlurlrlruulurllrrlddlruulruurdrudldld", 8, false, "7e33d9d051d84ba642b831cf1b8fd767"},
+	{11, "rrlrrddlrrruduruurddudrurrldlurdrlrdulrlrldudrlduddllldudrlrrlrrdrlullulrrurlududluluuldulrddluulrddululduudururrrrulurrddurrduddlurrudludrdullrdurlludludrdldrudrldrdldluddrrluurllulruulrrrluudllruldrlddrrudlddrrlrdrlddlrddrlulllurlddrrduururdllrdrddrldlddruuduurrdrudlrurrluuulluldurdlulldruuldrrdlu", 2, false, "ae7be87f161db9889a636fc92b77a899"},
+	{12, "ruldrurrdluuldrduudllruulluulududdrurddrrudludddrllluldruddldrlrrludrlurllulluururlulluurlddrdllllrdrdddruulruluulrlllldduddurrdlllldrlduuuurludldrluulrludluruddrdudruuuduuuulrldllddulldlrdurdlllrulrrdduullluullurlurludruulrlruudldldldrlrlrrllulldddlrudldurrdllurrrrddrdlrllrluurdldddudlduldllrrlddlr", 16, false, "b63f6c6c524671e8e7b64666ea1252cb"},
+	{13, "duluddudlddllurlrdduruuuurrllulrurrrrrdlrurlrurdrruudldrdduuldddrrduldulrdrududddudrldrrrldrudlrlruddulrlrurdldulrurllrldduldudluldrdlurrddrddrdruulduulddlurllrdrdduuurrudldlldrldlldurrrduldlruluruludulrddrrdrrulurduldruddluldduullllulrllluduruddllrlrdrudruruudurrudrdluldulddrrlrddulrrlrdrllrlldudll", 8, false, "4b7339dc19c15a8cfe8631577330a376"},
+	{14, "dulrdullurrluddurulrlluurudruuulduulrllrrurlldulrddrlldrullruurlullldrrrlurddruludrddrllddlulrlululrllrlddudulduddullduudrdrrlludldllururlrdurdllduullrduuurdrlldrrudludddurrdlluururdluuuruulrlrluuudlllllruudldddrddullrulrululllulduuldruurluurlrdulruurlruddudlruruulludrrdlrldrdrrldddrlulrududurdurduu", 15, false, "be1806930ca094e408ae7dc85210c860"},
+	{15, "dlllldduduuluudrrdldrduuddlluuuruduurdulrrrlrullrllrudrrdlddddluurururrlrddlrrlurulldluudduldullldrruddduduurdrlrulrlddudludururllrruddlrruurrlurrluullulludllddddrdurrluuduurludrrulrdlllllrrdrdluuuddurlrldllrlluuuudllludldlldrurrdruuldurlllrdrrrlldurrrurdlllrdlruuludrllrlllluldldddrduldududrdlulddud", 12, false, "3f8af355de336a6aeeab271972d17254"},
+	{16, "dldrlurrlddludldrrlllrlululduurddrldduudrrrllddldrudddrruuuuludruldllurrlrdrrrrrlldurdddlrudrllrlrurrrurlludddulurlllrurdrlldurdulrluuldrldrluuulllrdluddrrdldrudduururrrdrllulrrduurrrrrddldduuldruurrullddrdrdrrrlldudldlrdulldddrurrruuludrlldlldrrlllrrdurdrdluldruudrrrrruuudululrurdllrddulrluurdrudul", 9, false, "c7eb596421250e02a93badff017e5ab4"},
+	{17, "dlddudrlurrlrulurluurrlurduuurdululldlrurrrlrurlddrdldrrdulrudrdlrlrrlrlrlldudlrrrrrrrlllrrrudlulllrdlruruullrlduldrlrdddlrurldlrdudrrurrrrluurrurdrldrurldluuulddldlldddulrrlldlllrlrruurudluddudrlururdlurlududdldrldrludurduddllrdrdrrdrllllduuudullrddullddddudurruurrlrlduuddudruddrlrrlrdlrluuldrurdrr", 5, false, "acf00ce1d19a3ecc07d64b0345409780"},
+	{18, "ddduuuuurullrdlrrruruuludurdrrldrllrdrrdrduldduldudlrdrrurddrullllruuddrurluudrdlludruuruurrlrldlrduddldurlrurddlrduluulddldudludduurlruddludldddlrdrdddudlurdurddlurddlldruudlldulrruuldulruruurddlrrlduudlrludluuulruulddllrudduudrulrrldlurldrrdludldrlurlddlrurrldlluddrrulrldrrruurrllduuddlurlruldldrd", 13, false, "64ace01cdb8b52b0f0cbd0beadb4b57d"},
+	{19, "ddddrdlrdldlduudrluddldulddurrullddddududdululuudruuddrrrrrllddudrdddddlduurllllrddluddluududulrlurulrurdrldrurrddddlldllurldulrldlddudlduddllldurrddduuluurdrludldrlulruuldlrluurdrlulrrldrddrldddlruddduldudrlurdldldulrrddlurdrddluuddrlduldddlullulurluddlduurldddllllrulddruddudrdlulrlrlrdrdddlddrudru", 11, false, "9370792504eb9e1f8f85d3784b8062fb"},
+	{20, "dddlruddudrdddulrduludduuullrrrrrrdulddddluddrluldruldrrldlruuurdllurrdrudulllruduruurlurldlrldllduuuudurdluduurrurulluulduurlududluddldddrlrduldludurrlddudlldldlrlrlldrdrrdulrldrduululduululrldddrurlrurrdrlrdluduuulllrrrrurddlddurdddrdrrdruudulllruuuudlddudrlrdlllulurlrddddlldudullrldrrlllrduulddrr", 0, false, "560858be13abeb910f4bcb1b51d7fc02"},
+	{21, "drrrddrurulddurudurudrdurdurrrdddudrlrlrdludulduludrrdrrrdddrdldrrrdludldurudllrudurluudrlldudddlrllrdllullrlluuudrdlddrlrdrudrlrdddllurdulrddruldulrrdrrurdlrdrdlrrddllduululldrlrddldldrluurrludldrluululluuudluruldrdlduulluurlululdllldddldrrruudrldlrurrudllduuudlldrdulrulldldudduulrdurruururudldlddl", 13, false, "168b19564c2000ceb6cb0d1a435dd060"},
+	{22, "drrldlrrdlddldrrddrruudulurlrrluulrllllldluddrrulrldddrrllrldudlrldudulrulrrdurdduldlldlddlrlrdrlldldrrrdllludlulluuldurlluldrdulrdulrrulrddurlurulldrlllldluurudlulururldddldlluuudulrrrudlrlddrrldrllrduudldruudllrulrlulldrrlrudldllllrlrluduldrrduludrllrudulldrdlldrrrurluludurrludllllruuuddruduruuddu", 1, false, "d8bb36aeccd1d9c8b9413013be0e4aab"},
+	{23, "durulrudldudlurldurdllruudrddruddrruldurduldlluuldudlrrrrluuuduurrulullldrdlrullulrdddlrddurdudllrrlludurulrrddllrudlrldlrdurllrurrduldllluurrurldluuurrdrlurdudduurduuduuuudrludruldduullulduuudrlrrldrurdurrlrrlrdlrrrllldulrlrrllrduludrrudduullrrdlrddluurlrdurdrlddudllluludruurudrllrrlluluudlrduldddd", 6, false, "7cd8db485e975e441940874a1bfaf14c"},
+	{24, "lurdllllurlduddudddurdrurududruluurduurlduldrrluludlrrrrludrrulrudrrldlrulduuurudruldurdlrruruddluululullrlddrrlddurlrrlluurudurrrruuuldlrlrlurdrudurudlrlurdulrdulludrlrddlruurudlludulddldldddlrurddrddrulluullrdudllulrdrldrdrduuldruuldruudlrurduuldrlldurllrullulddlldlrddulrrlduulllududuldrlllldrlddl", 10, false, "b6d7edab917ea3d3082d6a467055dbc4"},
+	{25, "luruurdrdldduudrdrdrlrrudlldddrrdlululrrduldlldrurludrrrrurlddrduudlrdldddlduruuullurldulrrlulduldldudrddrlulrudrulllululdlllrrddrulrdurllrduuddldrrlulrurudlllldudrrrrrluudlludlldlddlrurdrulurururddulrrrddlduudulurrlllrudurdrldudrrurrlurddllrldulluullludlurrruluddluulllrrrrddlrdddudldrldududrrrurdlr", 0, false, "ef3526e2984a77394e06ea17fdc174c4"},
+	{26, "llurulrdldududdddlddruuulruudddllduruddudrldrurruduuurudlruuluulldurlruuuulllrdrrddrrdlrluddlddlluddruluudlruuldudlrllrulururudllrurrlruuuuudllluurruluulurllrdddudlluddudduddulrudulrduluurrrrlrruudrlllrluudldrurdldllldrludrrruluurdurdrudurlulrllrlrlulrldlrurudrudrdrllrrurdrdruduuduurlulrrurrulldddld", 7, false, "3b102f57c4f9222d13dca5b5e062256a"},
+	{27, "llulrrulurlrrulldrlllluurlrlddlurrudrudddrldlduruurrrrudrrdrrdluluruduudddurlrudlurdurrluudrdrddllrddlrlllllduddlldllllrlllrudluurlldrdludlrlluudluddldddddlulrudrrrrldlrurlrruuurrurrdldlluddlrdurudrdurrrldurudrlururdluudludrururlulrdudlldrdrudlluldlrldlllduddluudrrrddlludlrlurdrlduddullrlrdudrdrudlu", 7, false, "25c6a4f9b31ee4153c36c21600fe859c"},
+	{28, "llurrluururrrdlrdllurluudrdrldudlulurllulrdruulrurdddrudldrddurrddlduuuuuruddddlrdlluldruulrrurulrurldddrululldrdrdrldrdlrrdlrurrulrdllruurludruurudlllurulurdrldrrlldduddlruuurddurlrrrudruuurulurudrrrurddrldrudrlldlrlllrrdduuddrruurdrlluurdlruuddludrdudulullrulurrududrullrrllrllrruulddlurllurlulrdlr", 18, false, "b35840177efee88d37862dbc1ba7ef47"},
+	{29, "ldudrrlrdlurduudldlruduullllldrlrllrrrldlddrdddrrludurudrduuldudduuulluddlrudduulrduldudrlluulrllulrururuuldulrrdldlldlllllururddudlluuuuduudddrdlldududldurduurdruuruldlurulludrlurruuulrulrrdduudlduuddduuldllrudddlrulrlluuduullrulrdldrludrruddudulrrddrdulrdllrdrrulllduddlduuddurlrudrlrdlluullrldldul", 21, false, "b37dee265a726a98964afafdc461b9cb"},
+	{30, "lduudldlurlrdrulluuddrluurldlddrlrllruuulddruurrrurlruudlllruudlrdrdrdruurrrrddrrlrrlrrurlulldrrlddrrlrldrlurduururdlrrllddruldluudrudrlulddlrldurllddrudrudldludrlddllludldrdullulrluudrulldluurudlduldrddlrrrudruuurlullddlddluuuddlddluddrurrrluurlldulddrrldduddurrulurddurulurrlrldruuduudlrdddrlruuduu", 26, false, "12c9b436a66b4bb0fddd50863b262dd6"},
+	{31, "lrlddrrururrdluulduluuludluuldlluududdrllddrddudrrdlluudulrdddruruluudrdluddrludlruddudrddrddrrulurrddldrdlrdrluudrulululuudlrlururlulddrrrlrrudrldlldldrlrluulddrluuduururruruudrddrulrulrdludlduddludllduduuddlldldlullddrrrllurddrdldurldddrrludrrrluuldurrlurrullrruduururrururuurruuudldldddllruuulrdud", 5, false, "cd43aee3b30a8d3c681fe56a45742b02"},
+	{32, "lrllllurdlurlrrrluuudllulrrduduudldddrrrlldrluldrlluuuudduulluurrduldrrurdduuldluldldlulddrrrurllluudrurudlllrdllludlurrlddlruurduudrrlrrllrdurllrdurrururdluldrdddddrudddluluuruddddlduddurudurluldllrurdrudduluuldrrddluruulldudldlduludrrluruurlruulrludrudlrurdudduurrlrdlurdudlrdllururldrdururdddrldul", 9, false, "7dd035716d8463033b1c876f54192b7b"},
+	{33, "lrlrlrudlddrllrdllrruddlulduulududdldldlllrrrrddduurruuduuluurdduudrrurdlulrululddrurrrulrduulrrlrdulududlduuurldruudludlrurulrdluuurduurrdduudurlrudrrllulurrrlddduuurlludddludllrdulrdrrlrrlrlulldlluuudllurrrddrrldudlluldrlrluulrrrlrudruruuddrdudlddrdludldudrrrduludrrurlruulddlrrurldurrrddruuuuuudrr", 3, false, "06bb46228b9e28461d70dbf16bdca922"},
+	{34, "lullulluuuruurdulrrddrdlrrllulrudrrrdddrlurrllrddrrrluuddrddrlrludlllururlllluduuuurruldlrdullruluruudldruddruudrluddlddllldldrlulldduulrdrurluuldururlrddlrdludddrdrdruudurudulruuldlrruurudrldrllrldlrdlrrluldlldldudrlrudrllrlrdlurdlrrlrrlulluldlrlurrrullllldldldulllurddddrulrdllulrrlduruuudlldlddrrd", 18, false, "ab2f29fa91c25c4890abd9060b4b68de"},
+	{35, "uuluurdrdluuuldrllrlrudldluruldruurdlulllurrrruddllduuudurullruuluurdlddlruulurrddldululuullddrdldulrrrrlrdldludruluddullrrlrudlrlluddrddulrllrrruurruulruudldurdduulrrddudllruuuruluduudluuldrudlurlddduldurluuurududuululrurluldlldududlrurrulurrllllrrdrdlllrlldlddulluluuurldluulurdlrlrlluudrldrurrlrru", 11, false, "3aae9ac29b7db4c2921d54738163b751"},
+	{36, "uuddrdrdldduurddurrrlldlurrlulllrlrullldlurullllduulduudddluuldrldduuddurludduldurdludrruuldrrurlulldududrdrlllrudlddddululrulludlddlldrddddudddddlrlurrldrluulldludrudlldudduurddllddldrdllrlddllurldrlllullrddrddrdldllddudluulluuruludududluddddldrldulrlduldduuurluldurudrrlulrlurlulrrdrdullludldulrrrr", 4, false, "2684eeaac84b37ff4d1c4a6651d93c26"},
+	{37, "ulduruulurrurllluuddrdrlrurdrluuldurlruuurruurllldrllulduddrrrrrduldddddldrldrrlrluulullrluruuuuuldllluluddluddrludrdrurldrdlduruldulrluduuuddlduulduudldrduuddddllullduuudurludrlduurlrlrrldrurulrulrulrldrdulllullrrulludlrdulludullurlrlulrudlluudulullrrdullrudrulldrruuuluurlrdrruldrlldrulrurrdudddrdl", 6, false, "b214e4f3ca48147ad37c466de5a8734f"},
+	{38, "uldrddlrrllurrluuddllrrldddurlrdruullurduruudldlllduullddluldlldddrurrdururrrrlulrlrlluurluulludurrlurrrlldurdrrdrdldudrlullrrrdrlrduduldrlrlrulddddrlurrllurrdudlldurldrdulldullududrduduudlddlrlruurluulluulururrdllddllrduuullrludlrrllrluluduudurdlrlurdrrlurrrdlllduddudrludldrdddrdrrulrudldduudrulrdu", 7, false, "8e04fb76a11442fc5509b5bdd2eb3d08"},
+	{39, "uddldulddddudllduudurrrlluldrldullluulruuruuurrllulrdlllulluurdlruddluldldddrrrrruddddddddrudduruuuduulurldddruurlrrduudllduuldlddruuurdlldlrrruuudlllruurlrdlrldldldulldulddruudrrulrrduldruuurddruuudrdurlddddrllrduurlrurdduddduruddruudlrrurdrurrruddruurrldudllruldllrluddrulludluudrldrulrrlllllllrrdd", 9, false, "14f438b4a1f9b9ea5c79fbb99b0a1cd5"},
+	{40, "uddrlddlurrudruludlruuuluduurllrrdldurdduduuddulldrrlllldudrrlrurdludulurrduudldldrldrlldddlrruludldrluluudlurluuurldldllrudldluldulrrdrlruudududlrlulddlludlrurdurruluuldruruurudurruurldrrrrrulddluuuruulrrrluduuludduluuuruuddlrrldldurldrllrlddruluurrurudllldduuuldluuldurrrlllluddrdrldllrlrulrrdrdrdl", 3, false, "2fbfc0edfbfa2b39ece79898b3b9f15d"},
+	{41, "udddlururuludluuuulddluldurldulldrlluudruduuuullllddrllluurddrlrrurlulldlllrudrdruluruurlrdduuuduudddddddrdrrulludrrdlullldlrrurudurrllulllduulrlruuddluddrluuludurldduruulluludllurluuudruudllduddluuldluuullrrudddduuuldllldrrdulrrdudrlrddrluruldururudududlullurlulrdudludrdlluduuuurduuldluudrdllulurlr", 15, false, "15a7db895eaf074bc5f9132f94fd9007"},
+	{42, "urruudurdldllrrrullludullddrduuruudrudlluduurdduuulldllldrlllldduddrldlurrldldlllddrrdddlrlrllurulrrlulurrdddldlluulddlulduuuurdrdlldrullurlrludrluuldrdruduudldduururrlrdrrldrlrulrrulduudulururddluldlruddrddlrllrrrrllrlddurrdrudurrlruddllludrrdluuduluuullrdllddudrrrrldluludrrrrdludddrrdldudrdrldrrld", 17, false, "e2798b2c56f746e634973773d72801f1"},
+	{43, "urrruulllrulllrduruudruluulldurddlddrrlrulluluruudulllllurdrrruluullrrudluuudlruurrdrrluurluddulururulrllddullrddrudddrrludrlddlddlrddrdldurdlrdlrlrrdduudlurudrduulrlrudulurrruurddlldrllrluddlddldulruluurururdurlllldlldrrdruddrddrdlddlruululdlllduullurlllldurlurdrududurllrdrudduruduldudlurlrulrulrlu", 10, false, "4570c6ee65e04052cbc68470fa1fee27"},
+	{44, "urrlrdduuullurrlrluruullrdldduduuddurlululllrduuulrurlllrdrddlluldrruruurdurdllrdluluuuduuuurdldullrrddddlddududrlludrldldrlrrlulddlludruudduddlrllrddlddulrdlrldulrldrrldrduurrddddrldurduldlurldlduduudrrudurdldldrrrdlrrulrrldllduudldlrrrdllrlrldrurduullulurrlrlrdrlldddldulddlddduuddulldddluudrulurur", 12, false, "42875658378c9ec6c458ca7779dcd8a9"},
+	{45, "uurrrudrdldluldrrruddlllduuudulrrurrrdrrrullluluuuludlllldullrdrlududuuduurlrlrdurluuldlruulurluuddrdrurrudlrdlrurlddurdlrluuuurrrdrurluudrurduulrdrlrurrludlrrddrdlrrdlruurllrdulrddlrdurddlrdlurudrdlrurldrldurlurudlrlurddlrlruullululudrdulldulurludrrludulrurdddrdulurduuduudldlludldudrdddludlrldrrrul", 9, false, "60d9303c6204a85ea851558b5c4bc5b2"},
+	{46, "uurdddrdldulurddrurlrdlludrdluulllrldururulluddurdurudllrlduuludddudrluuddruruuldurrlrlrrlrdlulluurullrlludrdrdrldludullllrrllrrdrrlululuuurlrruuuddrrdlurrluluudrdrlududdduddrllurludurdurrududrrurrddddrrrlrurdrdlduruldururddrrrlrluuldluddldlruurruurrlddrldudulrdruduldddrrrdlruudrlddldrrrrdldlurulruu", 0, false, "df8a9b0271a4051b824e35bf0b3d0906"},
+	{47, "rluuduuuuullrlllrdrulrdlrudulurrrdrudlddrulldlrurlrdrdllllrddrlldurullrdurdduuduududlurddlrrdllduluuldlddrddlrruludddlrulrllrrddlrrdrrrdurddurdrddrddrlrllduurlldrrlullrluudurrurrulddlurlurrurudrurrrrdrrdururlldldrdlllrlullddrdllulruulruluddrddruuurudllrrlullluldrurrdrulurlduurulllluuuurulludrdldurud", 13, false, "8c60175b0c14778cd9bb23c7eaf7187d"},
+	{48, "rludddlrrlrlrrlururdrudldlllluddlruddrdurrdlururrrdddddlruullldurdddddrudldluuurdulldldudddurdluurluururudruuurudrdudllrluduuulluruurddrrlrudulduuuluuuldrdurudrdruudrlluddrlurrudluurlduddudrldlrrrrrullrudulluuurrlurllllldrdrrluudddruudluddrdlurududlllrrdlrllrrddruudrrdrldurrldrrrdlddrlrurrrruudrdrrl", 18, false, "0fd6b1d06be4cca26963a0d41dfdbfd9"},
+	{49, "rlulludddduddllrrddlludllrurlrluruulduldrrdldllrrdlluddlluduururruuuurrdurlrlrddllduddlrldllurlluldlruduldrrruulrlrlddrrldurlllurruddulurrlrluudddulrurrrulrdddurduduuuuruuldlrddlludrdrdrrullrrurruruludddrdduddrlurrldlrdduldrrururdlrrdllrudrlrddlruudlldudldduldrdrllllrrldldrrdldludlulddulldduddullrrr", 19, false, "1540c7abd1785697e4786be291a9e8c8"},
+	{50, "rdurlrdlurlddrulrrdurldlrlulurlddlurlllurrdlurdrdlrurddlrrrrrlldrdrldrruduldlrudrrurrurllrlllulrudrlddllrlrldlllurrrddldluddrdurduludrulrldlrlrllululullududlurlrdludlurddddrdrlruduurrurulluudlrrrurudrudluuudllludulrrlurrlrdurrlulrudrlrldddrrdudllurduduulllrudlulrllldrludllrdrldrddlluuruduulluuddrrrd", 7, false, "3ed95bcdf22289740d7633d43e78a64e"},
+	{51, "rdululrrrurddluurldrudrldrrduruludldlrulrdddrlrrdrdulddllrudlrrluulrrudduduudrdlllldrlduuruddlluuuullrrruurrlddlldrldrdluduuuurdluddllrdruuudddurdluuluddurlulurrdldudullurulrrulrrrdurdlldlrdurdudururrddrdlllruudrdrluldruduluuluudrrddrdddududlrdddulrrdrluurrruudluldururdruurluullrrlrdruuddrulldrudrru", 17, false, "0e5d8b5685c66ce0094bcb178a98bb11"},
+	{52, "rdluurudddudlurrdrdddrrlllduurrrdrluluurrdddlrurddlruddlrdluuduuudulllduduurdduurddlududuuurrddluldduududrrdudrdduurduuuurdrlldluuduludrddldudlulrdurlrurlduuruurddururuudlldurrddrrluurddrddlduludldludrdlrrdulrduuuduulruludlluurruuddddudldduuullduuuurddluududlrrlulrrluluuudrllrurlrllldllrulrdrulllrdr", 3, false, "c7da9e47b7901485f0ca055aba322d01"},
+	{53, "rrldrllllrldldrddllluurlurldurddullrldrlrdrdrllddludrrduldrrrulrlurrdlddulrdrddrluruurrururuurdrurrdrlulrrrurrudddulduduulrdddludurdudlududlrrurrldrllddurdrrlldrlrdllrrrurrrlrdulurrulurrlruruduulldlllldullrdullrdludllludlullurlrludlllrduldlrrrlrdurlddudrulldrduuududdurrlrlruddrldrlruudlrdudrurdrrrdl", 8, false, "3237b72a6086a1d39126c81ab35e3cd5"},
+	{54, "rrlurrduuurduurlddludlulrluuurludddlurdrdlrdluddlrrdlrdurludldrdldluuddudrrurdududurludrrlrllrduuludrrddldrrlrlrrllrdlururlluuurluruuuulddurlrrdlrrruduulllrdddrrlrurdddldluudrlluldllldludrrdrurulldddlrlddduldurlrrdudldlrrdldudurrllllrdrrrrlddlurrullldrdruullulluudlluulllruurrlrruulldrrluudludluudrdu", 11, false, "952dc2fa6a002a6f46f6d652fede4009"},
+	{55, "rulrdlrddlududdudulrudullrrlrrudlrduuldldlrdrdddldllurdulllluuuldludlrlduldruldlduddlduldldlduddudldduludlrluulrurludldduurrllrduuulrrrddllduuddulrdddrddrudludlrludlrduuurldrrurrldrldrdlrudlllduldddruulurudrlrduuulddluduuuurulrdlluludurdlrlulruuluudldlrdudduduruldllllrrddduruldlduurldullrluuurldurdd", 9, false, "d48d9c6ec4a00db22a257c4c8e724179"},
+	{56, "rulldrrllrdruudddduddrululdrrrrlruddudlddlrddurdlluldrduuurrrddudrdurrludddlulruulrldrrudlldrldruudrllrlrlruruduldldddududlddddlrlurrldrdrruduuldrudlddururludrdrluuuulrrdlrrurrudddllrludlulrrrlludddurdlrldrdrdldddrurlddllduruuldddruulrudrrdrrdrudurrururdulduuruulddudlldrlludluluruuluuldllrrlllrrrrll", 9, false, "b48afa0544de26dc3c8f740d3482d071"},
+	{57, "rudrlluuuurrrddlduulruulrrdlrddrllrluuludurdududlrrulrduduuddurrdllduulduululllrdruuduldldlrudduudrrudddlurddlrudududrdluurlurlldllldullllurllruulllrruludduuruuruldddldduduulrddlrlrdudlrdluddluluddrldrlldruudururuldulrrdrruulrududdurrdullrdddurluulrrrduluurrldlrlrrrulruuluuldrldllurdrdddrdddrrullrlr", 16, false, "0d9bd583790c552bb611a423e04d9bcb"},
+	{58, "dlddlrlrrlurruludduulllddllrrddlrdrrrludduudduldlddrurduurllududrruudlluldldlurrullrrlullduulrddururruurdrrulluldddddruuuluulluulllrddudlrldulduddlldrrrdudrrlllrudlurluldudldrlrurlddurrurdruudrlurlrddllrrllrurdruluullluruluuldrllrlrrduuurrrulddlduuurrluludurrldrlrudlllrludulrduudlulllrdrluldllddurld", 18, false, "b5d2e98cbe3846e216159d15d4a4d58d"},
+	{59, "dlduuldddddrddlrdrrrrdldlruuddlulrrdrruudruruddluulrdrrudrdurudlrldlrlldrrulduldddrdrrrruruldudrulludlrlurrrudllrlrududrurddddrruldllurrldruddlruuduurdlrdlrdrlrrudrduuruudrdrrulruludllullddrdudlrrlurlrullrddrduldrddllduudrulllllrrurdlrlrlrrruuddrurldrruuuluduuurlrllrdrlluluuulrduluduuurrrrurdrrudrlu", 12, false, "ceffc15ce5a0eedd662f70ee2f4e5b14"},
+	{60, "dlddurruurrrduudllrdldldrurddduduluururddrurdurlurrdlrruudurddruurlrlduullurrurlluuluullurrlrlrludduldldldrlrrddurrddurrululurddrldrurduluurldudrddurulrurudlldururluludrduluudrddludrddlddrlludllrrluuuuuuduuullrrruuudlullrlrlluulurrrdrdldrrudrdldluldlrdluuulldrlrdrlludlrdrrurllrudduudrlrudlruudlllrur", 1, false, "4e9ad2062050cfb166764d427abb1a5d"},
+	{61, "dddlrlrrrllrdduulrrurrddddduddruddurdddrdrurlruluddlruruddlllulrulrlrrudrrrdrrlurdluuluururdudrduurulrurrlrudrrdllurdllduddulullllrlullluddlrrrdlurudurlllrludrdrurrrdrududdllddulluurdrrrurrurruldululududrdlrrrldudrdrldddurrdlrrududdldudlluuudllrduudlrulrurdlulddduduldrdrddlrduddrdudlldruuulldudrurul", 15, false, "859630e9baa39f36b4bccb73246b30d4"},
+	{62, "ddrrrruddddrluurlldruuddlulldddruruldrdlddurrllluullduruuldurddrlrdruruuluruurrrlrdrldrdrudrlrruuduuulduuurdlurrduuldlrluurddduuudrdruuduurudrdlrdrruudrdrdururrrrullurrldurdddllududurluuluddllrddulldruuuludldduudullrlrdudlrdldluruddlurdlruurlruruurruurdruldulurddurrrdluudlldrrluldruuurrlddulrduudruu", 15, false, "626edea54f246b6502d934c42100f5a1"},
+}
@@ -0,0 +1,298 @@
+// Sokoban game
+//
+// Remote control: with -listen addr the game accepts WebSocket connections
+// on ws://addr/ so that bots and stream tools can drive it. Clients receive
+// the whole board as JSON when they connect or the level changes, and after
+// a move the cells that changed, as a diff of the state numbered from:
+//
+//	{"seq": 8, "from": 7, "diff": [{"x": 3, "y": 2, "t": "@"}, ...], "player": [3, 2], "moves": 12, ...}
+//
+// A client that falls behind gets the whole board again. Clients can send
+// commands:
+//
+//	{"cmd": "move", "dir": "up"}      up, down, left or right
+//	{"cmd": "moves", "lurd": "uurd"}  several moves at once
+//	{"cmd": "undo"}
+//	{"cmd": "undo-push"}              back to before the last push
+//	{"cmd": "restart"}
+//	{"cmd": "level", "level": 12}
+//
+// Commands are queued and run by Update, like the keyboard.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type remoteCommand struct {
+	Cmd   string `json:"cmd"`
+	Dir   string `json:"dir,omitempty"`
+	LURD  string `json:"lurd,omitempty"`
+	Level int    `json:"level,omitempty"`
+}
+
+type remoteState struct {
+	Seq       int      `json:"seq"`
+	Level     int      `json:"level"`
+	Width     int      `json:"width"`
+	Height    int      `json:"height"`
+	Board     []string `json:"board"` // XSB rows
+	Player    [2]int   `json:"player"`
+	Moves     int      `json:"moves"`
+	Pushes    int      `json:"pushes"`
+	BoxesLeft int      `json:"boxes_left"`
+}
+
+// the changes since state From, see diffBoards
+type remoteDiff struct {
+	Seq       int          `json:"seq"`
+	From      int          `json:"from"`
+	Diff      []cellChange `json:"diff"`
+	Player    [2]int       `json:"player"`
+	Moves     int          `json:"moves"`
+	Pushes    int          `json:"pushes"`
+	BoxesLeft int          `json:"boxes_left"`
+}
+
+type remoteClient struct {
+	ws    *wsConn
+	send  chan []byte
+	stale bool // missed a state, the next one is sent whole
+}
+
+var (
+	remoteCommands = make(chan remoteCommand, 64)
+
+	// handlers of the embedded HTTP server
+	httpMux       = http.NewServeMux()
+	httpListening = false
+
+	remoteLock      sync.Mutex
+	remoteClients   = make(map[*remoteClient]bool)
+	remoteLastState []byte
+	remoteStateKey  string
+	remoteSeq       int
+
+	// the rest of the last state, for the stream overlay
+	remoteLastBoard Level
+	remoteLastStart time.Time
+	remoteLastBest  int
+)
+
+// startHTTPServer serves httpMux on addr in the background
+func startHTTPServer(addr string) {
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	httpMux.HandleFunc("/", serveRemote)
+	registerOverlayHandlers()
+	httpListening = true
+
+	go func() {
+		log.Fatal(http.Serve(ln, httpMux))
+	}()
+
+	log.Printf("remote control on ws://%s/", ln.Addr())
+	log.Printf("stream overlay on http://%s/overlay", ln.Addr())
+}
+
+func serveRemote(w http.ResponseWriter, r *http.Request) {
+
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+
+	c := &remoteClient{ws: ws, send: make(chan []byte, 16)}
+
+	remoteLock.Lock()
+	remoteClients[c] = true
+	if remoteLastState != nil {
+		c.send <- remoteLastState
+	}
+	remoteLock.Unlock()
+
+	go func() {
+		for msg := range c.send {
+			if ws.writeMessage(wsText, msg) != nil {
+				ws.Close()
+			}
+		}
+	}()
+
+	for {
+		msg, err := ws.readMessage()
+		if err != nil {
+			break
+		}
+
+		var cmd remoteCommand
+		if err := json.Unmarshal(msg, &cmd); err != nil {
+			remoteReply(c, fmt.Sprintf("invalid command: %v", err))
+			continue
+		}
+
+		select {
+		case remoteCommands <- cmd:
+		default:
+			remoteReply(c, "too many commands queued")
+		}
+	}
+
+	remoteLock.Lock()
+	delete(remoteClients, c)
+	close(c.send)
+	remoteLock.Unlock()
+
+	ws.Close()
+}
+
+func remoteReply(c *remoteClient, msg string) {
+
+	data, _ := json.Marshal(map[string]string{"error": msg})
+
+	remoteLock.Lock()
+	defer remoteLock.Unlock()
+
+	if remoteClients[c] {
+		select {
+		case c.send <- data:
+		default:
+		}
+	}
+}
+
+func parseDirection(s string) (byte, bool) {
+
+	switch s {
+	case "up":
+		return UP, true
+	case "down":
+		return DOWN, true
+	case "left":
+		return LEFT, true
+	case "right":
+		return RIGHT, true
+	}
+	return 0, false
+}
+
+// applyRemoteCommands runs the commands received since the last update
+func applyRemoteCommands(s *GameState) {
+
+	for {
+		select {
+		case cmd := <-remoteCommands:
+			runRemoteCommand(s, cmd)
+		default:
+			return
+		}
+	}
+}
+
+func runRemoteCommand(s *GameState, cmd remoteCommand) {
+
+	playback = nil
+
+	switch cmd.Cmd {
+	case "move":
+		if d, ok := parseDirection(cmd.Dir); ok {
+			s.playMove(d)
+		}
+	case "moves":
+		if m, err := lurdToMoves(cmd.LURD); err == nil {
+			for _, d := range m {
+				s.playMove(d)
+			}
+		}
+	case "undo":
+		s.undoMove()
+	case "undo-push":
+		s.undoPush()
+	case "restart":
+		s.loadLevel(s.currentLevelNumber)
+	case "level":
+		s.switchLevel(cmd.Level)
+	}
+}
+
+func currentRemoteState(s *GameState) remoteState {
+
+	return remoteState{
+		Level:     s.currentLevelNumber,
+		Width:     s.curLev.w,
+		Height:    s.curLev.h,
+		Board:     levelToXSB(s.curLev),
+		Player:    [2]int{s.curLev.px, s.curLev.py},
+		Moves:     s.moveCount(),
+		Pushes:    s.pushCount,
+		BoxesLeft: s.nBoxesLeft(),
+	}
+}
+
+// broadcastState sends the board to every client when it changed
+func broadcastState(s *GameState) {
+
+	if !httpListening {
+		return
+	}
+
+	key := fmt.Sprint(s.currentLevelNumber, s.moveCount(), s.pushCount, s.curLev.px, s.curLev.py, s.levelStart.UnixNano())
+	if key == remoteStateKey {
+		return
+	}
+	remoteStateKey = key
+
+	state := currentRemoteState(s)
+	state.Seq = remoteSeq + 1
+	data, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+
+	remoteLock.Lock()
+	defer remoteLock.Unlock()
+
+	// a move of the same attempt goes as a diff
+	var diff []byte
+	if remoteLastState != nil && remoteLastStart.Equal(s.levelStart) {
+		var last remoteState
+		json.Unmarshal(remoteLastState, &last)
+		if changes, ok := diffBoards(last.Board, state.Board); ok && last.Level == state.Level {
+			diff, _ = json.Marshal(remoteDiff{state.Seq, last.Seq, changes, state.Player, state.Moves, state.Pushes, state.BoxesLeft})
+		}
+	}
+
+	remoteSeq = state.Seq
+	remoteLastState = data
+	remoteLastBoard = s.curLev.clone()
+	remoteLastStart = s.levelStart
+	remoteLastBest = 0
+	if p, ok := levelsProgress()[s.currentLevelNumber]; ok {
+		remoteLastBest = p.BestMoves
+	}
+
+	for c := range remoteClients {
+		msg := diff
+		if msg == nil || c.stale {
+			msg = data
+		}
+		select {
+		case c.send <- msg:
+			c.stale = false
+		default:
+			// slow client, it will catch up with the next state
+			c.stale = true
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Sokoban game
+//
+// Replay notes: a replay can carry notes on some of its moves, to make
+// tutorial replays for other players. In the replay browser A writes a note
+// on the selected replay, "12 push this box first" for a note shown once
+// its move 12 has been played, 0 before the first move; a move number alone
+// removes the note of that move.
+//
+// Watching the replay, the playback waits a moment on each note, which then
+// stays under the board until the next one.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	noteHoldTicks   = 90 // updates the playback waits on a note
+	noteHoldPerChar = 3  // and more for each letter of it
+	noteMaxLength   = 120
+)
+
+var (
+	// the notes of the replay being watched, by move
+	watchedNotes  map[int]string
+	watchedPlayed int
+	watchedNote   string
+	watchedHold   int
+
+	replayAnnotating bool
+	replayNoteText   []rune
+	replayNoteError  string
+)
+
+// startReplayNotes follows the notes of a replay about to be played back
+func startReplayNotes(notes map[int]string) {
+
+	watchedNotes, watchedPlayed, watchedNote, watchedHold = notes, 0, "", 0
+	reachReplayNote()
+}
+
+// reachReplayNote shows the note of the move just played, if any
+func reachReplayNote() {
+
+	if note, ok := watchedNotes[watchedPlayed]; ok {
+		watchedNote = note
+		watchedHold = noteHoldTicks + noteHoldPerChar*len(note)
+	}
+}
+
+// replayNoteMoved follows a move of the playback
+func replayNoteMoved() {
+
+	if watchedNotes == nil {
+		return
+	}
+	watchedPlayed++
+	reachReplayNote()
+}
+
+// holdReplayNote tells if the playback waits on a note
+func holdReplayNote() bool {
+
+	if watchedHold > 0 {
+		watchedHold--
+		return true
+	}
+	return false
+}
+
+// endReplayNotes lets the last note be read once the playback is over
+func endReplayNotes() {
+
+	if !holdReplayNote() {
+		stopReplayNotes()
+	}
+}
+
+func stopReplayNotes() {
+
+	watchedNotes, watchedNote, watchedHold = nil, "", 0
+}
+
+// parseReplayNote reads "move text" for a replay of moves moves
+func parseReplayNote(text string, moves int) (int, string, error) {
+
+	number, note := strings.TrimSpace(text), ""
+	if i := strings.IndexAny(number, " \t"); i >= 0 {
+		number, note = number[:i], strings.TrimSpace(number[i+1:])
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return 0, "", fmt.Errorf("start with the number of the move")
+	}
+	if n < 0 || n > moves {
+		return 0, "", fmt.Errorf("the replay has moves 1 to %d", moves)
+	}
+	return n, note, nil
+}
+
+// setReplayNote writes note on move n of r, removing it when note is ""
+func setReplayNote(r *replay, n int, note string) {
+
+	if note == "" {
+		delete(r.Notes, n)
+	} else {
+		if r.Notes == nil {
+			r.Notes = make(map[int]string)
+		}
+		r.Notes[n] = note
+	}
+	saveReplays()
+}
+
+// updateReplayNoteEntry takes the input while a note of r is written
+func updateReplayNoteEntry(in InputSource, r *replay) {
+
+	for _, c := range in.AppendInputChars(nil) {
+		if len(replayNoteText) < noteMaxLength {
+			replayNoteText = append(replayNoteText, c)
+		}
+	}
+	if in.IsKeyJustPressed(ebiten.KeyBackspace) && len(replayNoteText) > 0 {
+		replayNoteText = replayNoteText[:len(replayNoteText)-1]
+	}
+	if in.IsKeyJustPressed(ebiten.KeyEnter) {
+		moves, err := lurdToMoves(r.Moves)
+		if err != nil {
+			replayNoteError = fmt.Sprintf("replay %q is damaged", r.Name)
+			return
+		}
+		n, note, err := parseReplayNote(string(replayNoteText), len(moves))
+		if err != nil {
+			replayNoteError = err.Error()
+			return
+		}
+		setReplayNote(r, n, note)
+		replayAnnotating = false
+	}
+	if in.IsKeyJustPressed(ebiten.KeyEscape) {
+		replayAnnotating = false
+	}
+}
+
+// replayNotesText lists the notes of r for the replay browser
+func replayNotesText(r *replay) string {
+
+	if replayAnnotating {
+		msg := fmt.Sprintf("\nNote: %s_   (the move, then the note; Enter: save, Esc: cancel)\n", string(replayNoteText))
+		if replayNoteError != "" {
+			msg += "      " + replayNoteError + "\n"
+		}
+		return msg
+	}
+	if len(r.Notes) == 0 {
+		return ""
+	}
+
+	moves := make([]int, 0, len(r.Notes))
+	for n := range r.Notes {
+		moves = append(moves, n)
+	}
+	sort.Ints(moves)
+	msg := fmt.Sprintf("\nNotes of %s:\n", r.Name)
+	for _, n := range moves {
+		msg += fmt.Sprintf("   move %4d: %s\n", n, r.Notes[n])
+	}
+	return msg
+}
+
+func drawReplayNote(screen *ebiten.Image) {
+
+	if watchedNote == "" {
+		return
+	}
+	ebitenutil.DrawRect(screen, screenWidth/2-320, screenHeight-60, 640, 28, color.RGBA{0, 0, 0, 200})
+	drawText(screen, watchedNote, screenWidth/2-310, screenHeight-54)
+}
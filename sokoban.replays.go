@@ -0,0 +1,302 @@
+// Sokoban game
+//
+// Replay library: every solution the player finds is kept in replays.json
+//
+// R opens the replays of the current level: Up/Down select one, Left/Right
+// change level, Enter watches the replay, F2 renames it, Delete removes it,
+// C compares it with another one, A writes a note on one of its moves (see
+// sokoban.replaynotes.go) and Esc or R goes back to the game. U and O
+// upload it to and list the solution hub, see sokoban.solutionhub.go.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const replaysFile = "replays.json"
+
+type replay struct {
+	Level    int       `json:"level"`
+	Pack     string    `json:"pack,omitempty"` // see packID
+	Hash     string    `json:"hash,omitempty"` // see levelIdentity
+	Name     string    `json:"name"`
+	Moves    string    `json:"moves"` // LURD
+	Pushes   int       `json:"pushes"`
+	Time     float64   `json:"time"` // seconds
+	Recorded time.Time `json:"recorded"`
+
+	Notes map[int]string `json:"notes,omitempty"` // by move, see sokoban.replaynotes.go
+}
+
+var (
+	replays []*replay
+
+	replayBrowserOpen = false
+	replayLevel       int
+	replaySelected    int
+
+	replayRenaming   = false
+	replayRenameText []rune
+)
+
+func loadReplays() {
+
+	if err := loadJSON(replaysFile, &replays); err != nil && !os.IsNotExist(err) {
+		log.Printf("cannot read replays: %v", err)
+	}
+}
+
+func saveReplays() {
+
+	if err := saveJSON(replaysFile, replays); err != nil {
+		log.Printf("cannot save replays: %v", err)
+	}
+}
+
+// recordReplay keeps the solution of the level just solved
+func recordReplay(s *GameState) {
+
+	if s.assisted || !s.historyComplete() {
+		return
+	}
+
+	now := time.Now()
+	replays = append(replays, &replay{
+		Level:    s.currentLevelNumber,
+		Pack:     packID(),
+		Hash:     levelIdentity(s.currentLevelNumber),
+		Name:     now.Format("2006-01-02 15:04"),
+		Moves:    historyToLURD(s.moves),
+		Pushes:   s.pushCount,
+		Time:     now.Sub(s.levelStart).Seconds(),
+		Recorded: now,
+	})
+
+	saveReplays()
+}
+
+// levelReplays returns the replays of level n of the pack being played,
+// and of the same level in other packs, oldest first
+func levelReplays(n int) []*replay {
+
+	id := levelIdentity(n)
+	var list []*replay
+	for _, r := range replays {
+		if r.Level == n && r.Pack == packID() || r.Hash == id {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+func deleteReplay(r *replay) {
+
+	for i := range replays {
+		if replays[i] == r {
+			replays = append(replays[:i], replays[i+1:]...)
+			break
+		}
+	}
+	saveReplays()
+}
+
+func watchReplay(s *GameState, r *replay) {
+
+	m, err := lurdToMoves(r.Moves)
+	if err != nil {
+		showMessage(fmt.Sprintf("replay %q is damaged", r.Name))
+		return
+	}
+
+	s.loadLevel(r.Level)
+	playback = m
+	playbackTick = 0
+	s.assisted = true
+	startReplayNotes(r.Notes)
+}
+
+// updateReplayBrowser returns true while the browser takes the input
+func updateReplayBrowser(s *GameState, in InputSource) bool {
+
+	if !replayBrowserOpen {
+		if in.IsKeyJustPressed(ebiten.KeyR) && solverRun == nil {
+			replayBrowserOpen = true
+			replayLevel = s.currentLevelNumber
+			replaySelected = 0
+			replayOnline = false
+			setHubNotice("")
+			playback = nil
+			return true
+		}
+		return false
+	}
+
+	if updateCompare(in) {
+		return true
+	}
+
+	list, _ := browserReplays(replayLevel)
+
+	if replayAnnotating {
+		if replaySelected < len(list) {
+			updateReplayNoteEntry(in, list[replaySelected])
+		} else {
+			replayAnnotating = false
+		}
+		return true
+	}
+
+	if replayRenaming {
+		for _, r := range in.AppendInputChars(nil) {
+			if len(replayRenameText) < 40 {
+				replayRenameText = append(replayRenameText, r)
+			}
+		}
+		if in.IsKeyJustPressed(ebiten.KeyBackspace) && len(replayRenameText) > 0 {
+			replayRenameText = replayRenameText[:len(replayRenameText)-1]
+		}
+		if in.IsKeyJustPressed(ebiten.KeyEnter) {
+			if name := strings.TrimSpace(string(replayRenameText)); name != "" && replaySelected < len(list) {
+				list[replaySelected].Name = name
+				saveReplays()
+			}
+			replayRenaming = false
+		}
+		if in.IsKeyJustPressed(ebiten.KeyEscape) {
+			replayRenaming = false
+		}
+		return true
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) || in.IsKeyJustPressed(ebiten.KeyR) {
+		replayBrowserOpen = false
+		return true
+	}
+
+	level := replayLevel
+	if in.IsKeyJustPressed(ebiten.KeyArrowLeft) && replayLevel > 0 {
+		replayLevel--
+		replaySelected = 0
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowRight) && replayLevel < lastLevel() {
+		replayLevel++
+		replaySelected = 0
+	}
+	if in.IsKeyJustPressed(ebiten.KeyO) {
+		replayOnline = !replayOnline
+		replaySelected = 0
+		if replayOnline {
+			fetchSolutions(replayLevel)
+		}
+	} else if replayOnline && replayLevel != level {
+		fetchSolutions(replayLevel)
+	}
+	if replayLevel != level || in.IsKeyJustPressed(ebiten.KeyO) {
+		list, _ = browserReplays(replayLevel)
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowUp) && replaySelected > 0 {
+		replaySelected--
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowDown) && replaySelected < len(list)-1 {
+		replaySelected++
+	}
+
+	if replaySelected < len(list) {
+		r := list[replaySelected]
+
+		if in.IsKeyJustPressed(ebiten.KeyEnter) {
+			replayBrowserOpen = false
+			if r.Level == s.currentLevelNumber {
+				confirmDiscard(s, "Watch the replay?", func() { watchReplay(s, r) })
+			} else {
+				watchReplay(s, r)
+			}
+		}
+		if in.IsKeyJustPressed(ebiten.KeyU) && !replayOnline {
+			uploadReplay(r)
+		}
+		if in.IsKeyJustPressed(ebiten.KeyF2) && !replayOnline {
+			replayRenaming = true
+			replayRenameText = []rune(r.Name)
+		}
+		if in.IsKeyJustPressed(ebiten.KeyA) && !replayOnline {
+			replayAnnotating = true
+			replayNoteText = nil
+			replayNoteError = ""
+		}
+		if in.IsKeyJustPressed(ebiten.KeyC) {
+			markForCompare(r)
+		}
+		if in.IsKeyJustPressed(ebiten.KeyDelete) && !replayOnline {
+			if compareFirst == r {
+				compareFirst = nil
+			}
+			deleteReplay(r)
+			if replaySelected > 0 && replaySelected >= len(list)-1 {
+				replaySelected--
+			}
+		}
+	}
+
+	return true
+}
+
+func drawReplayBrowser(screen *ebiten.Image, sh *spriteSheets) {
+
+	if compareOpen {
+		drawCompare(screen, sh)
+		return
+	}
+
+	list, empty := browserReplays(replayLevel)
+
+	msg := fmt.Sprintf("Replays of level %d   (%s)\n\n", replayLevel,
+		presetKeyNames("Left/Right: level, Enter: watch, F2: rename, C: compare, A: note, Delete: remove, U: upload, O: shared solutions, Esc: back"))
+	if replayOnline {
+		msg = fmt.Sprintf("Shared solutions of level %d   (%s)\n\n", replayLevel,
+			presetKeyNames("Left/Right: level, Enter: watch, C: compare, O: my replays, Esc: back"))
+	}
+	hubLock.Lock()
+	if hubNotice != "" {
+		msg = hubNotice + "\n\n" + msg
+	}
+	hubLock.Unlock()
+	msg += fmt.Sprintf("   %-40s %7s %7s %9s  %s\n", "name", "moves", "pushes", "time", "recorded")
+
+	for i, r := range list {
+		name := r.Name
+		if replayRenaming && i == replaySelected {
+			name = string(replayRenameText) + "_"
+		}
+		cursor := "  "
+		if i == replaySelected {
+			cursor = "> "
+		}
+		if r == compareFirst {
+			cursor = cursor[:1] + "*"
+		}
+		recorded := r.Recorded.Format("2006-01-02 15:04")
+		if r.Recorded.IsZero() {
+			recorded = "shared"
+		}
+		msg += fmt.Sprintf("%s %-40s %7d %7d %8.1fs  %s\n", cursor, name, len(r.Moves), r.Pushes, r.Time, recorded)
+	}
+
+	if len(list) == 0 {
+		msg += "   " + empty + "\n"
+	} else if replaySelected < len(list) {
+		msg += replayNotesText(list[replaySelected])
+	}
+
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{20, 20, 30, 255})
+	drawText(screen, msg, 40, 40)
+}
@@ -0,0 +1,113 @@
+// Sokoban game
+//
+// Pull only mode (settings > gameplay), reverse Sokoban: the levels start
+// solved, every box on a goal, and the player pulls the boxes back to
+// where the level starts them, which are shown as goals. Moving away from a
+// box pulls it, Shift+arrows step away without pulling. Boxes cannot be
+// pushed, so the solver, the hints, finishing and sending boxes are off.
+// The mode applies from the next level started, Home restarts the current
+// one in it.
+//
+// reverseLevel and Level.pull are also what building levels backwards from
+// their solution comes down to.
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var reverseMode = false
+
+// reverseLevel returns l solved, the boxes on its goals and goals where its
+// boxes were, with the player where it starts or, when a box is there now,
+// on the nearest free floor
+func reverseLevel(l Level) Level {
+
+	r := l.clone()
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			switch l.grid[x][y] {
+			case BOX:
+				r.grid[x][y] = GOAL
+			case GOAL:
+				r.grid[x][y] = BOX
+			}
+		}
+	}
+	r.countBoxes()
+
+	if t := r.grid[r.px][r.py]; t == BOX || t == PLACED_BOX {
+		r.px, r.py = nearestFreeCell(r, r.px, r.py)
+	}
+	return r
+}
+
+// nearestFreeCell returns the floor without a box closest to x, y going
+// around the walls, or x, y when there is none
+func nearestFreeCell(l Level, x int, y int) (int, int) {
+
+	seen := make([][]bool, l.w)
+	for i := range seen {
+		seen[i] = make([]bool, l.h)
+	}
+	seen[x][y] = true
+
+	for queue := [][2]int{{x, y}}; len(queue) > 0; queue = queue[1:] {
+		cx, cy := queue[0][0], queue[0][1]
+		if t := l.grid[cx][cy]; t == EMPTY || t == GOAL {
+			return cx, cy
+		}
+		for _, d := range directions {
+			dx, dy := dirDelta(d)
+			nx, ny := cx+dx, cy+dy
+			if nx < 0 || ny < 0 || nx >= l.w || ny >= l.h || seen[nx][ny] || l.grid[nx][ny] == WALL {
+				continue
+			}
+			seen[nx][ny] = true
+			queue = append(queue, [2]int{nx, ny})
+		}
+	}
+	return x, y
+}
+
+// moveOrPull plays d, pulling the box behind the player in pull only mode,
+// unless Shift is held to step away from it, and in kid mode when Shift is
+// held
+func moveOrPull(s *GameState, in InputSource, d byte) {
+
+	shift := in.IsKeyPressed(ebiten.KeyShift)
+
+	switch {
+	case reverseMode && shift:
+		// a step that neither pulls nor pushes
+		dx, dy := dirDelta(d)
+		if t := s.curLev.grid[s.curLev.px+dx][s.curLev.py+dy]; t == EMPTY || t == GOAL {
+			s.playMove(d)
+		}
+	case reverseMode || (kidMode && shift):
+		s.pullMove(d)
+	default:
+		s.playMove(d)
+	}
+}
+
+// reverseRefused tells the player that pushing features are off
+func reverseRefused() {
+
+	showMessage("not in pull only mode")
+}
+
+func drawReverse(screen *ebiten.Image, s *GameState) {
+
+	if !reverseMode || s.nBoxesLeft() == 0 {
+		return
+	}
+
+	msg := "Pull only: walk away from a box to pull it back to a goal"
+	ebitenutil.DrawRect(screen, 20, screenHeight-130, float64(len(msg)*6+20), 24, color.RGBA{0, 0, 0, 180})
+	drawText(screen, msg, 30, screenHeight-126)
+}
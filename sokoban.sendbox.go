@@ -0,0 +1,160 @@
+// Sokoban game
+//
+// Sending a box: right click a box and then a goal to have the player push
+// that box there, walking around as needed, if it can be done without
+// moving any other box. Esc or a right click elsewhere drops the box.
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var (
+	sendBox      bool // a box is selected
+	sendBoxX     int
+	sendBoxY     int
+	sendBoxMoves int // moveCount when it was selected
+	sendBoxLevel int
+)
+
+// a box and the player in the search of boxPath
+type boxNode struct {
+	box, player int
+}
+
+type boxStep struct {
+	from  boxNode
+	moves []byte // walk to the box and the push
+}
+
+// boxPath returns the moves pushing the box on cell box to cell to with
+// the fewest pushes, the player starting on cell player and the occupied
+// cells staying where they are; nil when it cannot be done
+func (b *solverBoard) boxPath(box, player, to int, occupied []bool) []byte {
+
+	start := boxNode{box, player}
+	prev := map[boxNode]boxStep{start: {}}
+	queue := []boxNode{start}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if n.box == to {
+			var steps [][]byte
+			for n != start {
+				steps = append(steps, prev[n].moves)
+				n = prev[n].from
+			}
+			var moves []byte
+			for i := len(steps) - 1; i >= 0; i-- {
+				moves = append(moves, steps[i]...)
+			}
+			return moves
+		}
+
+		occupied[n.box] = true
+
+		for _, d := range directions {
+
+			behind, next, ok := b.canPush(n.box, d, occupied)
+			if !ok || b.dead[next] {
+				continue
+			}
+
+			walk := b.path(n.player, behind, occupied)
+			if walk == nil && n.player != behind {
+				continue
+			}
+
+			m := boxNode{next, n.box}
+			if _, seen := prev[m]; seen {
+				continue
+			}
+			prev[m] = boxStep{n, append(walk, d)}
+			queue = append(queue, m)
+		}
+
+		occupied[n.box] = false
+	}
+
+	return nil
+}
+
+// updateSendBox handles the right clicks; it returns true when it took over
+// the input
+func updateSendBox(s *GameState, in InputSource) bool {
+
+	if sendBox && (s.moveCount() != sendBoxMoves || s.currentLevelNumber != sendBoxLevel) {
+		sendBox = false
+	}
+
+	if sendBox && in.IsKeyJustPressed(ebiten.KeyEscape) {
+		sendBox = false
+		return true
+	}
+
+	if !in.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		return false
+	}
+	if reverseMode {
+		reverseRefused()
+		return true
+	}
+
+	x, y := in.CursorPosition()
+	cx, cy, ok := screenToCell(s, x, y)
+	t := byte(WALL)
+	if ok {
+		t = s.curLev.grid[cx][cy]
+	}
+
+	switch {
+	case t == BOX || t == PLACED_BOX:
+		sendBox, sendBoxX, sendBoxY = true, cx, cy
+		sendBoxMoves, sendBoxLevel = s.moveCount(), s.currentLevelNumber
+
+	case sendBox && t == GOAL:
+		sendBox = false
+
+		b, boxes, player := newSolverBoard(s.curLev, nil)
+		for _, c := range boxes {
+			b.occupied[c] = true
+		}
+		box := sendBoxY*b.w + sendBoxX
+		b.occupied[box] = false
+
+		moves := b.boxPath(box, player, cy*b.w+cx, b.occupied)
+		if moves == nil {
+			showMessage("impossible: that box cannot be pushed to this goal")
+			return true
+		}
+		playback = moves
+		playbackTick = playbackTicks - 1
+
+	default:
+		sendBox = false
+	}
+
+	return true
+}
+
+func drawSendBox(screen *ebiten.Image, s *GameState) {
+
+	if !sendBox {
+		return
+	}
+
+	x, y, tile := cellOnScreen(s, sendBoxX, sendBoxY)
+	w := tile / 16
+	c := color.RGBA{255, 255, 160, 255}
+
+	ebitenutil.DrawRect(screen, x, y, tile, w, c)
+	ebitenutil.DrawRect(screen, x, y+tile-w, tile, w, c)
+	ebitenutil.DrawRect(screen, x, y, w, tile, c)
+	ebitenutil.DrawRect(screen, x+tile-w, y, w, tile, c)
+}
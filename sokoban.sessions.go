@@ -0,0 +1,69 @@
+// Sokoban game
+//
+// Level sessions: leaving a level before solving it keeps the attempt in
+// memory, and going back to that level with the level keys, the level
+// select or the jump prompt resumes it, undo stack and timer included.
+// Restarting a level, loading a save or watching a replay start afresh.
+
+package main
+
+import "time"
+
+// an attempt at a level left before it was solved
+type levelSession struct {
+	lev       Level
+	moves     []moveRecord
+	pushCount int
+	baseMoves int
+	elapsed   time.Duration
+	assisted  bool
+}
+
+// stashSession keeps the attempt at the current level, if there is one
+func (s *GameState) stashSession() {
+
+	if s.moveCount() == 0 || s.nBoxesLeft() == 0 {
+		return
+	}
+
+	if s.sessions == nil {
+		s.sessions = make(map[int]*levelSession)
+	}
+	s.sessions[s.currentLevelNumber] = &levelSession{
+		lev:       s.curLev,
+		moves:     s.moves,
+		pushCount: s.pushCount,
+		baseMoves: s.baseMoves,
+		elapsed:   time.Since(s.levelStart),
+		assisted:  s.assisted,
+	}
+}
+
+// takeSession returns and forgets the attempt kept for level n
+func (s *GameState) takeSession(n int) *levelSession {
+
+	sess := s.sessions[n]
+	delete(s.sessions, n)
+	return sess
+}
+
+func (s *GameState) resumeSession(sess *levelSession) {
+
+	s.curLev = sess.lev
+	s.moves = sess.moves
+	s.pushCount = sess.pushCount
+	s.baseMoves = sess.baseMoves
+	s.levelStart = time.Now().Add(-sess.elapsed)
+	s.assisted = sess.assisted
+}
+
+// switchLevel goes to level n, resuming the attempt left there if any;
+// n is clamped to the existing levels and staying on the same level keeps
+// the current attempt, unless it is solved
+func (s *GameState) switchLevel(n int) {
+
+	if clampLevel(n) == s.currentLevelNumber && s.nBoxesLeft() > 0 {
+		return
+	}
+	s.enterLevel(n, true)
+}
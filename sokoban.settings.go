@@ -0,0 +1,333 @@
+// Sokoban game
+//
+// Settings: F10 or the gear icon opens the list of settings. Up and down
+// pick one, left and right or Enter change it, a click on the left or right
+// half of a line lowers or raises it. Esc or F10 go back to the game.
+//
+// The settings are kept in settings.json and are the defaults of the
+// matching command line flags.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const settingsFile = "settings.json"
+
+type settingsData struct {
+	CueVolume      float64       `json:"cue_volume"`
+	AudioCues      bool          `json:"audio_cues"`
+	Announcer      bool          `json:"announcer"`
+	ReducedMotion  bool          `json:"reduced_motion"`
+	Window         windowOptions `json:"window"`
+	StayOnSolved   bool          `json:"stay_on_solved"`
+	UndoLimit      int           `json:"undo_limit"`
+	ConfirmMoves   int           `json:"confirm_moves"`
+	TPS            int           `json:"tps"`
+	Vsync          bool          `json:"vsync"`
+	ShowFPS        bool          `json:"show_fps"`
+	CheckUpdates   bool          `json:"check_updates"`
+	DeadlockFX     bool          `json:"deadlock_effects"`
+	Decorations    bool          `json:"decorations"`
+	Controls       string        `json:"controls"`
+	BreakAfter     int           `json:"break_after"`
+	BoxLabels      bool          `json:"box_labels"`
+	ShowReach      bool          `json:"reachable_squares"`
+	Leaderboard    bool          `json:"leaderboard"`
+	LeaderboardURL string        `json:"leaderboard_url"`
+	Nickname       string        `json:"nickname"`
+	Blindfold      int           `json:"blindfold"`
+	Zen            bool          `json:"zen"`
+	Kid            bool          `json:"kid"`
+	Reverse        bool          `json:"pull_only"`
+}
+
+// a line of the settings scene
+type settingItem struct {
+	group  string
+	name   string
+	value  func() string
+	change func(step int) // step is -1 or +1
+}
+
+var (
+	settingsScreenZone = screenZone{20, 10, 2, 1}
+
+	settingsOpen    = false
+	selectedSetting = 0
+
+	undoLimitChoices = []int{0, 100, 1000, 10000}
+)
+
+func onOff(b bool) string {
+	return map[bool]string{true: "on", false: "off"}[b]
+}
+
+var settingItems = []settingItem{
+	{"audio", "cue volume", func() string { return fmt.Sprintf("%.0f%%", cueVolume*100) },
+		func(step int) { cueVolume = clampVolume(cueVolume + 0.1*float64(step)) }},
+	{"audio", "audio cues", func() string { return onOff(audioCuesOn) },
+		func(int) { audioCuesOn = !audioCuesOn }},
+
+	{"accessibility", "announcer", func() string { return onOff(announcerOn) },
+		func(int) { announcerOn = !announcerOn }},
+	{"accessibility", "reduced motion", func() string { return onOff(reducedMotion) },
+		func(int) { reducedMotion = !reducedMotion }},
+	{"accessibility", "controls", func() string { return controlPreset }, func(step int) {
+		i := 0
+		for i < len(controlPresets) && controlPresets[i] != controlPreset {
+			i++
+		}
+		controlPreset = controlPresets[(i+step+len(controlPresets))%len(controlPresets)]
+		applyControlPreset()
+	}},
+	{"accessibility", "deadlock shake and flash", func() string { return onOff(deadlockEffects) },
+		func(int) { deadlockEffects = !deadlockEffects }},
+
+	{"graphics", "fullscreen", func() string { return onOff(windowSettings.Fullscreen) },
+		func(int) { windowSettings.Fullscreen = !windowSettings.Fullscreen; applyWindowOptions() }},
+	{"graphics", "borderless window", func() string { return onOff(windowSettings.Borderless) },
+		func(int) { windowSettings.Borderless = !windowSettings.Borderless; applyWindowOptions() }},
+	{"graphics", "always on top", func() string { return onOff(windowSettings.Floating) },
+		func(int) { windowSettings.Floating = !windowSettings.Floating; applyWindowOptions() }},
+	{"graphics", "decorations", func() string { return onOff(showDecorations) },
+		func(int) { showDecorations = !showDecorations }},
+
+	{"performance", "updates per second", func() string { return fmt.Sprint(tickRate) },
+		func(step int) { tickRate = nextChoice(tickRateChoices, tickRate, step); applyPerformanceSettings() }},
+	{"performance", "vsync", func() string { return onOff(vsyncEnabled) },
+		func(int) { vsyncEnabled = !vsyncEnabled; applyPerformanceSettings() }},
+	{"performance", "show FPS", func() string { return onOff(showFPS) },
+		func(int) { showFPS = !showFPS }},
+
+	{"gameplay", "stay on solved levels", func() string { return onOff(stayOnSolved) },
+		func(int) { stayOnSolved = !stayOnSolved }},
+	{"gameplay", "undo limit", func() string {
+		if undoLimit == 0 {
+			return "none"
+		}
+		return fmt.Sprintf("%d moves", undoLimit)
+	}, func(step int) { undoLimit = nextChoice(undoLimitChoices, undoLimit, step) }},
+	{"gameplay", "confirm discarding", func() string {
+		if confirmMoves == 0 {
+			return "never"
+		}
+		return fmt.Sprintf("after %d moves", confirmMoves)
+	}, func(step int) { confirmMoves = nextChoice(confirmMovesChoices, confirmMoves, step) }},
+	{"gameplay", "break reminder", func() string {
+		if breakAfter == 0 {
+			return "off"
+		}
+		return fmt.Sprintf("after %d minutes", breakAfter)
+	}, func(step int) { breakAfter = nextChoice(breakAfterChoices, breakAfter, step) }},
+	{"gameplay", "box and goal labels", func() string { return onOff(boxLabels) },
+		func(int) { boxLabels = !boxLabels }},
+	{"gameplay", "reachable squares", func() string { return onOff(showReach) },
+		func(int) { showReach = !showReach }},
+	{"gameplay", "blindfold", func() string { return blindfoldNames[blindfold] },
+		func(step int) { blindfold = (blindfold + step + len(blindfoldNames)) % len(blindfoldNames) }},
+	{"gameplay", "zen mode", func() string { return onOff(zenMode) },
+		func(int) { zenMode = !zenMode }},
+	{"gameplay", "kid mode", func() string { return onOff(kidMode) },
+		func(int) { kidMode = !kidMode; camera.level = -1 }},
+	{"gameplay", "pull only (reverse)", func() string { return onOff(reverseMode) },
+		func(int) { reverseMode = !reverseMode }},
+
+	{"leaderboard", "send scores", func() string {
+		if leaderboardURL == "" || nickname == "" {
+			return "set -leaderboard and -nickname"
+		}
+		return onOff(leaderboardOn) + ", as " + nickname
+	}, func(int) { leaderboardOn = !leaderboardOn }},
+
+	{"updates", "check for updates at startup", func() string { return onOff(checkUpdates) },
+		func(int) { checkUpdates = !checkUpdates }},
+}
+
+func clampVolume(v float64) float64 {
+
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return float64(int(v*10+0.5)) / 10
+}
+
+// nextChoice returns the choice after v, or before it when step is -1
+func nextChoice(choices []int, v int, step int) int {
+
+	i := 0
+	for i < len(choices)-1 && choices[i] < v {
+		i++
+	}
+	i += step
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(choices) {
+		i = len(choices) - 1
+	}
+	return choices[i]
+}
+
+func loadSettings() {
+
+	data := settingsData{CueVolume: cueVolume, TPS: tickRate, Vsync: vsyncEnabled, ConfirmMoves: confirmMoves,
+		DeadlockFX: deadlockEffects, Decorations: showDecorations, Controls: controlPreset, Leaderboard: leaderboardOn}
+
+	if err := loadJSON(settingsFile, &data); err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("cannot read settings: %v", err)
+		}
+		return
+	}
+
+	cueVolume = clampVolume(data.CueVolume)
+	audioCuesOn = data.AudioCues
+	announcerOn = data.Announcer
+	reducedMotion = data.ReducedMotion
+	windowSettings = data.Window
+	stayOnSolved = data.StayOnSolved
+	undoLimit = data.UndoLimit
+	confirmMoves = data.ConfirmMoves
+	tickRate = data.TPS
+	vsyncEnabled = data.Vsync
+	showFPS = data.ShowFPS
+	checkUpdates = data.CheckUpdates
+	deadlockEffects = data.DeadlockFX
+	showDecorations = data.Decorations
+	controlPreset = data.Controls
+	applyControlPreset()
+	breakAfter = data.BreakAfter
+	boxLabels = data.BoxLabels
+	showReach = data.ShowReach
+	leaderboardOn = data.Leaderboard
+	leaderboardURL = data.LeaderboardURL
+	nickname = data.Nickname
+	if data.Blindfold >= 0 && data.Blindfold < len(blindfoldNames) {
+		blindfold = data.Blindfold
+	}
+	zenMode = data.Zen
+	kidMode = data.Kid
+	reverseMode = data.Reverse
+}
+
+func saveSettings() {
+
+	data := settingsData{
+		CueVolume:      cueVolume,
+		AudioCues:      audioCuesOn,
+		Announcer:      announcerOn,
+		ReducedMotion:  reducedMotion,
+		Window:         windowSettings,
+		StayOnSolved:   stayOnSolved,
+		UndoLimit:      undoLimit,
+		ConfirmMoves:   confirmMoves,
+		TPS:            tickRate,
+		Vsync:          vsyncEnabled,
+		ShowFPS:        showFPS,
+		CheckUpdates:   checkUpdates,
+		DeadlockFX:     deadlockEffects,
+		Decorations:    showDecorations,
+		Controls:       controlPreset,
+		BreakAfter:     breakAfter,
+		BoxLabels:      boxLabels,
+		ShowReach:      showReach,
+		Leaderboard:    leaderboardOn,
+		LeaderboardURL: leaderboardURL,
+		Nickname:       nickname,
+		Blindfold:      blindfold,
+		Zen:            zenMode,
+		Kid:            kidMode,
+		Reverse:        reverseMode,
+	}
+
+	if err := saveJSON(settingsFile, &data); err != nil {
+		log.Printf("cannot save settings: %v", err)
+	}
+}
+
+func settingLine(i int) (int, int, int, int) {
+	return screenWidth/2 - 300, 180 + 30*i, 600, 28
+}
+
+// updateSettings returns true while the settings take the input
+func updateSettings(in InputSource, mouseOrTouch bool, eventX int, eventY int) bool {
+
+	if !settingsOpen {
+		if in.IsKeyJustPressed(ebiten.KeyF10) || (mouseOrTouch && inScreenZone(settingsScreenZone, eventX, eventY)) {
+			settingsOpen = true
+			return true
+		}
+		return false
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyEscape) || in.IsKeyJustPressed(ebiten.KeyF10) {
+		settingsOpen = false
+		saveSettings()
+		return true
+	}
+
+	if in.IsKeyJustPressed(ebiten.KeyArrowDown) && selectedSetting < len(settingItems)-1 {
+		selectedSetting++
+	}
+	if in.IsKeyJustPressed(ebiten.KeyArrowUp) && selectedSetting > 0 {
+		selectedSetting--
+	}
+
+	item := settingItems[selectedSetting]
+	switch {
+	case in.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		item.change(-1)
+	case in.IsKeyJustPressed(ebiten.KeyArrowRight) || in.IsKeyJustPressed(ebiten.KeyEnter):
+		item.change(1)
+	}
+
+	if mouseOrTouch {
+		for i := range settingItems {
+			x, y, w, h := settingLine(i)
+			if eventX >= x && eventX < x+w && eventY >= y && eventY < y+h {
+				selectedSetting = i
+				if eventX < x+w/2 {
+					settingItems[i].change(-1)
+				} else {
+					settingItems[i].change(1)
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func drawSettings(screen *ebiten.Image) {
+
+	drawText(screen, "Settings: arrows or clicks change them, Esc to go back", screenWidth/2-300, 150)
+
+	group := ""
+	for i, item := range settingItems {
+
+		x, y, w, h := settingLine(i)
+
+		if i == selectedSetting {
+			ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h), color.RGBA{80, 80, 120, 255})
+		}
+
+		label := ""
+		if item.group != group {
+			group = item.group
+			label = group
+		}
+
+		drawText(screen, fmt.Sprintf("%-15s %-24s < %s >", label, item.name, item.value()), x+10, y+6)
+	}
+}
@@ -0,0 +1,83 @@
+// Sokoban game
+//
+// Shuffle mode: run with -shuffle SEED, or -shuffle-random for a new seed
+//
+// The next and previous level follow a permutation of the levels drawn from
+// the seed instead of their numbers, so a playthrough of the whole pack
+// comes in a fresh order. The seed is shown under the level number: friends
+// using the same seed get the same order, speedruns included.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+var (
+	shuffleSeed int64 // 0 when the levels come in their own order
+	levelOrder  []int // the level at each position of the shuffled order
+	levelPos    []int // the position of each level in levelOrder
+)
+
+// startShuffle orders the levels by the permutation of seed
+func startShuffle(seed int64) {
+
+	if seed == 0 {
+		shuffleSeed, levelOrder, levelPos = 0, nil, nil
+		return
+	}
+
+	shuffleSeed = seed
+	levelOrder = rand.New(rand.NewSource(seed)).Perm(lastLevel() + 1)
+	levelPos = make([]int, lastLevel()+1)
+	for pos, n := range levelOrder {
+		levelPos[n] = pos
+	}
+}
+
+// randomShuffleSeed returns a seed short enough to be read out to a friend
+func randomShuffleSeed() int64 {
+
+	return 1 + time.Now().UnixNano()%999999
+}
+
+// levelAt returns the level played at position pos of the playthrough
+func levelAt(pos int) int {
+
+	pos = clampLevel(pos)
+	if levelOrder == nil {
+		return pos
+	}
+	return levelOrder[pos]
+}
+
+// levelPosition returns the position of level n in the playthrough
+func levelPosition(n int) int {
+
+	n = clampLevel(n)
+	if levelPos == nil {
+		return n
+	}
+	return levelPos[n]
+}
+
+// stepLevel returns the level step positions after level n in the
+// playthrough, staying on the first or the last one
+func stepLevel(n int, step int) int {
+
+	if kidMode {
+		return kidStepLevel(n, step)
+	}
+	return levelAt(levelPosition(n) + step)
+}
+
+// shuffleStatus describes the shuffled order for the HUD
+func shuffleStatus(n int) string {
+
+	if shuffleSeed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Shuffle seed %d: %d/%d", shuffleSeed, levelPosition(n)+1, lastLevel()+1)
+}
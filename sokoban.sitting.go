@@ -0,0 +1,149 @@
+// Sokoban game
+//
+// Session summary: closing the window first shows what was done since the
+// game started (levels attempted and solved, moves, undos and time), then
+// any key, click or a second close quits. Each summary is also appended to
+// history.jsonl, one JSON object per line. Such a sitting is not to be mixed
+// up with the level sessions kept when leaving a level.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const historyFile = "history.jsonl"
+
+type sittingSummary struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Attempted int       `json:"levels_attempted"`
+	Solved    int       `json:"levels_solved"`
+	Moves     int       `json:"moves"`
+	Undos     int       `json:"undos"`
+}
+
+var (
+	sittingStart     = time.Now()
+	sittingAttempted = make(map[int]bool)
+	sittingSolved    = make(map[int]bool)
+	sittingMoves     = 0
+	sittingUndos     = 0
+
+	sittingEnded *sittingSummary // shown while quitting
+)
+
+func sittingMoved(e GameEvent) {
+
+	if e.Moved {
+		sittingAttempted[e.State.currentLevelNumber] = true
+		sittingMoves++
+	}
+}
+
+func sittingUndone(e GameEvent) {
+
+	sittingUndos++
+}
+
+func sittingLevelSolved(e GameEvent) {
+
+	sittingAttempted[e.State.currentLevelNumber] = true
+	sittingSolved[e.State.currentLevelNumber] = true
+}
+
+func currentSitting() sittingSummary {
+
+	return sittingSummary{sittingStart, time.Now(), len(sittingAttempted), len(sittingSolved), sittingMoves, sittingUndos}
+}
+
+func (t sittingSummary) String() string {
+
+	return fmt.Sprintf("levels attempted: %d\nlevels solved:    %d\nmoves:            %d\nundos:            %d\ntime:             %s",
+		t.Attempted, t.Solved, t.Moves, t.Undos, t.End.Sub(t.Start).Round(time.Second))
+}
+
+// appendHistory adds t to the history log
+func appendHistory(t sittingSummary) error {
+
+	path, err := userFilePath(historyFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// endSitting records the summary of the sitting and returns it
+func endSitting() sittingSummary {
+
+	t := currentSitting()
+	if t.Moves > 0 {
+		if err := appendHistory(t); err != nil {
+			log.Printf("cannot write the session history: %v", err)
+		}
+	}
+	return t
+}
+
+// updateSitting returns ebiten.Termination once the summary was seen, and
+// true while it is shown
+func updateSitting(in InputSource, mouseOrTouch bool) (bool, error) {
+
+	if sittingEnded == nil {
+		if !ebiten.IsWindowBeingClosed() {
+			return false, nil
+		}
+		// nothing to sum up
+		if sittingMoves == 0 {
+			return true, ebiten.Termination
+		}
+		t := endSitting()
+		sittingEnded = &t
+		// the summary is drawn over the board
+		settingsOpen, levelSelectOpen, replayBrowserOpen, packBrowserOpen = false, false, false, false
+		return true, nil
+	}
+
+	if ebiten.IsWindowBeingClosed() || mouseOrTouch || len(in.AppendJustPressedKeys(nil)) > 0 {
+		return true, ebiten.Termination
+	}
+	return true, nil
+}
+
+func drawSitting(screen *ebiten.Image) {
+
+	if sittingEnded == nil {
+		return
+	}
+
+	msg := "This session\n\n" + sittingEnded.String() + "\n\nPress any key or click to quit."
+
+	x, y := screenWidth/2-150, screenHeight/2-90
+	ebitenutil.DrawRect(screen, float64(x-30), float64(y-30), 340, 220, color.RGBA{20, 30, 50, 235})
+	drawText(screen, msg, x, y)
+}
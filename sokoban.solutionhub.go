@@ -0,0 +1,175 @@
+// Sokoban game
+//
+// Solution hub: the leaderboard server (see sokoban.leaderboard.go) also
+// keeps solutions. In the replay browser U uploads the selected replay, and
+// O switches between the player's replays and the best solutions uploaded
+// for the level, which Enter watches and C compares like any replay.
+//
+//	POST URL/levels/HASH/solutions  {"level": "HASH", "nickname": "...", "lurd": "...", "moves": 1, "pushes": 1, "time": 1.5}
+//	GET  URL/levels/HASH/solutions  [{"level": "HASH", "nickname": "...", "lurd": "...", ...}, ...]
+//
+// the solutions being sorted best first.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+const hubListed = 20
+
+type hubSolution struct {
+	Level    string  `json:"level"` // see levelIdentity
+	Nickname string  `json:"nickname"`
+	LURD     string  `json:"lurd"`
+	Moves    int     `json:"moves"`
+	Pushes   int     `json:"pushes"`
+	Time     float64 `json:"time"` // seconds
+}
+
+var (
+	replayOnline = false // the browser lists the hub's solutions
+
+	hubLock    sync.Mutex
+	hubLevel   = -1 // level of the solutions below
+	hubReplays []*replay
+	hubStatus  string
+	hubNotice  string // outcome of the last upload
+)
+
+func hubPackChanged(e GameEvent) {
+
+	hubLock.Lock()
+	hubLevel = -1
+	hubLock.Unlock()
+}
+
+func setHubNotice(msg string) {
+
+	hubLock.Lock()
+	hubNotice = msg
+	hubLock.Unlock()
+}
+
+func solutionsURL(base string, n int) string {
+
+	return base + "/levels/" + levelIdentity(n) + "/solutions"
+}
+
+// uploadReplay sends r to the hub without blocking the game
+func uploadReplay(r *replay) {
+
+	base := leaderboardBase()
+	if base == "" {
+		setHubNotice("set -leaderboard and -nickname to share solutions")
+		return
+	}
+
+	hash := levelIdentity(r.Level)
+	sol := hubSolution{hash, nickname, r.Moves, len(r.Moves), r.Pushes, r.Time}
+	target := solutionsURL(base, r.Level)
+	setHubNotice("uploading " + r.Name + "...")
+
+	go func() {
+		body, err := json.Marshal(sol)
+		if err != nil {
+			return
+		}
+		client := http.Client{Timeout: leaderboardWait}
+		resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("solution upload: %v", err)
+			setHubNotice("the solution could not be uploaded")
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			log.Printf("solution upload: %s", resp.Status)
+			setHubNotice("the solution could not be uploaded")
+			return
+		}
+		setHubNotice("solution uploaded")
+	}()
+}
+
+// fetchSolutions downloads the best solutions of level n in the background
+func fetchSolutions(n int) {
+
+	hubLock.Lock()
+	hubLevel, hubReplays, hubStatus = n, nil, "fetching the solutions..."
+	hubLock.Unlock()
+
+	base := leaderboardBase()
+	if base == "" {
+		hubLock.Lock()
+		hubStatus = "set -leaderboard and -nickname to see shared solutions"
+		hubLock.Unlock()
+		return
+	}
+	target := solutionsURL(base, n)
+
+	go func() {
+		list, err := downloadSolutions(target, n)
+
+		hubLock.Lock()
+		defer hubLock.Unlock()
+		if hubLevel != n {
+			return
+		}
+		hubReplays, hubStatus = list, ""
+		if err != nil {
+			log.Printf("solution hub: %v", err)
+			hubStatus = "the solutions could not be fetched"
+		} else if len(list) == 0 {
+			hubStatus = "no shared solutions yet: U uploads one of yours"
+		}
+	}()
+}
+
+func downloadSolutions(target string, n int) ([]*replay, error) {
+
+	client := http.Client{Timeout: leaderboardWait}
+	resp, err := client.Get(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	var sols []hubSolution
+	if err := json.NewDecoder(resp.Body).Decode(&sols); err != nil {
+		return nil, err
+	}
+
+	var list []*replay
+	for _, sol := range sols {
+		if _, err := lurdToMoves(sol.LURD); err != nil || len(list) == hubListed {
+			continue
+		}
+		list = append(list, &replay{Level: n, Name: sol.Nickname, Moves: sol.LURD, Pushes: sol.Pushes, Time: sol.Time})
+	}
+	return list, nil
+}
+
+// browserReplays returns what the replay browser lists for level n, and a
+// note to show instead when there is nothing
+func browserReplays(n int) ([]*replay, string) {
+
+	if !replayOnline {
+		return levelReplays(n), "no replays yet: solve the level to record one"
+	}
+
+	hubLock.Lock()
+	defer hubLock.Unlock()
+	if hubLevel != n {
+		return nil, ""
+	}
+	return hubReplays, hubStatus
+}
@@ -0,0 +1,947 @@
+// Sokoban game
+//
+// Solver: A* search over box pushes
+//
+// A node of the search is a set of box positions plus the position of the
+// player. Expanding a node tries every push the player can walk to. Nodes
+// are expanded cheapest first, the cost being either the number of pushes
+// or the number of moves, plus a lower bound of the pushes left: the
+// cheapest assignment of the boxes to goals of their own, each box counted
+// as if alone on the board. Among the nodes of the same cost those closest
+// to a solution go first (see solverQueue), ties broken on moves or on
+// pushes. When optimizing pushes only the area the player can reach
+// matters, which keeps the search much smaller.
+//
+// Pushes that leave a box frozen off a goal, or boxes that cannot all reach
+// a goal of their own, are deadlocks and are not searched further.
+//
+// The bound drops by one push at most when a box is pushed, so all the
+// nodes of the same cost and bound can be expanded at once: they are shared
+// between worker goroutines which check positions against a common
+// transposition table, keyed by the Zobrist hash of the boxes and of the
+// player area.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type solverMode int
+
+const (
+	optimizePushes solverMode = iota
+	optimizeMoves
+)
+
+var (
+	// mode used by the game when it asks the solver for a solution
+	solverModeSetting = optimizePushes
+
+	// number of goroutines expanding nodes
+	solverWorkers = runtime.NumCPU()
+
+	// the search measures the memory it holds, see solverStats
+	solverMeasureMemory = false
+)
+
+func (m solverMode) String() string {
+
+	if m == optimizeMoves {
+		return "moves"
+	}
+	return "pushes"
+}
+
+func parseSolverMode(s string) (solverMode, error) {
+
+	switch s {
+	case "pushes":
+		return optimizePushes, nil
+	case "moves":
+		return optimizeMoves, nil
+	}
+	return optimizePushes, fmt.Errorf("unknown solver mode %q (want pushes or moves)", s)
+}
+
+// a flat view of a level where cells are indexed by y*w+x
+type solverBoard struct {
+	w, h int
+	wall []bool
+	goal []bool
+	dead []bool // a box on this cell can never reach a goal
+
+	// pushes bringing a box from each cell to each goal and to the nearest
+	// one, the other boxes aside, see lowerBound; set by search
+	goalCells []int32
+	toGoal    [][]int32
+	goalDist  []int32
+
+	// Zobrist keys of a box and of the player area on each cell
+	zBox, zPlayer []uint64
+
+	floorBits          // see reachBits
+	inside    bitboard // the cells the player could walk to without the boxes
+	goals     bitboard
+
+	// the cells a box may stand on in the search, the live ones and those of
+	// the boxes at the start; a position keeps its boxes as a bitboard of
+	// words words over them, bit k standing for cell boxCells[k]
+	boxCells []int32
+	boxIndex []int32 // index of each cell in boxCells, -1 if none
+	words    int
+	boxGoals bitboard
+
+	// per worker scratch space
+	area, frontier, next bitboard // flood fill bookkeeping
+	dist                 []int32  // walking distance from the player, valid for the cells of area
+	blocked              bitboard
+	cells, packed        []int32 // the boxes, see unpack
+	corral, corralRest   bitboard
+	fence, fenceTry      bitboard // see corralBoxes
+	occupied             []bool
+	match                matcher
+	later                []solverLater // see expand
+	seen, frozenBoxes    []int32       // see freezeDeadlock
+	seenCell             []bool
+}
+
+// a node put back because its position costs more than its bucket
+type solverLater struct {
+	node, cost int32
+}
+
+// a position of the search, its boxes being kept apart (see nodeBoxes)
+type solverNode struct {
+	player int32 // cell the player stands on
+	parent int32
+
+	pushes, moves int32
+	dir           byte // direction of the push that led here
+	run           byte // pushes it stands for, see tunnelRun
+}
+
+// cost returns the number that orders the search and the one breaking ties
+func (n *solverNode) cost(mode solverMode) (int32, int32) {
+
+	if mode == optimizeMoves {
+		return n.moves, n.pushes
+	}
+	return n.pushes, n.moves
+}
+
+const solverTableShards = 64
+
+// solverTable remembers the cheapest cost each position was expanded at
+type solverTable struct {
+	shards [solverTableShards]struct {
+		sync.Mutex
+		best map[uint64][2]int32
+	}
+}
+
+func newSolverTable() *solverTable {
+
+	t := &solverTable{}
+	for i := range t.shards {
+		t.shards[i].best = make(map[uint64][2]int32)
+	}
+	return t
+}
+
+// expanded tells if a position has been expanded at a cost claim would
+// not take
+func (t *solverTable) expanded(key uint64, primary, secondary int32) bool {
+
+	s := &t.shards[key%solverTableShards]
+	s.Lock()
+	defer s.Unlock()
+
+	old, ok := s.best[key]
+	return ok && (old[0] < primary || old[1] <= secondary)
+}
+
+// claim records a position and tells if it has to be expanded: it has not
+// been seen, or only with the same primary cost and a worse secondary one
+func (t *solverTable) claim(key uint64, primary, secondary int32) bool {
+
+	s := &t.shards[key%solverTableShards]
+	s.Lock()
+	defer s.Unlock()
+
+	if old, ok := s.best[key]; ok && (old[0] < primary || old[1] <= secondary) {
+		return false
+	}
+	s.best[key] = [2]int32{primary, secondary}
+
+	return true
+}
+
+// solverProgress lets the game follow, and stop, a search running in the
+// background; its fields are accessed atomically
+type solverProgress struct {
+	nodes     int64 // nodes expanded so far
+	depth     int64 // primary cost of the nodes being expanded
+	cancelled int32
+}
+
+func (p *solverProgress) cancel() {
+	atomic.StoreInt32(&p.cancelled, 1)
+}
+
+func (p *solverProgress) isCancelled() bool {
+	return atomic.LoadInt32(&p.cancelled) != 0
+}
+
+type solverStats struct {
+	nodes   int // nodes expanded
+	elapsed time.Duration
+	stored  int    // nodes kept by the search, expanded or waiting
+	memory  uint64 // bytes the search held at its end, when solverMeasureMemory
+}
+
+type solverResult struct {
+	moves  []byte // UP, RIGHT, DOWN, LEFT like the undo stack
+	pushes int
+	solved bool
+	bound  int // when optimizing pushes, the fewest pushes a solution can take
+	stats  solverStats
+}
+
+var directions = []byte{UP, RIGHT, DOWN, LEFT}
+
+func dirDelta(d byte) (int, int) {
+
+	switch d {
+	case UP:
+		return 0, -1
+	case RIGHT:
+		return 1, 0
+	case DOWN:
+		return 0, 1
+	}
+	return -1, 0
+}
+
+func oppositeDir(d byte) byte {
+
+	switch d {
+	case UP:
+		return DOWN
+	case RIGHT:
+		return LEFT
+	case DOWN:
+		return UP
+	}
+	return RIGHT
+}
+
+// newSolverBoard converts l, reusing dead if it is a dead cell table
+// computed earlier for the same level
+func newSolverBoard(l Level, dead []bool) (*solverBoard, []int32, int) {
+
+	w, h := l.w, l.h
+
+	b := &solverBoard{w: w, h: h}
+	b.wall = make([]bool, w*h)
+	b.goal = make([]bool, w*h)
+	b.zBox = make([]uint64, w*h)
+	b.zPlayer = make([]uint64, w*h)
+
+	var boxes []int32
+
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			c := y*w + x
+			b.zBox[c] = zobristKey(x, y, zobristBox)
+			b.zPlayer[c] = zobristKey(x, y, zobristPlayer)
+			switch l.grid[x][y] {
+			case WALL:
+				b.wall[c] = true
+			case GOAL:
+				b.goal[c] = true
+			case BOX:
+				boxes = append(boxes, int32(c))
+			case PLACED_BOX:
+				b.goal[c] = true
+				boxes = append(boxes, int32(c))
+			}
+		}
+	}
+
+	sort.Slice(boxes, func(i, j int) bool { return boxes[i] < boxes[j] })
+
+	lb := bitsOfLevel(l)
+	b.floorBits = newFloorBits(lb)
+	b.goals = lb.goals
+	b.inside = newBitboard(w * h)
+	b.inside.set(lb.player)
+	frontier, next := append(bitboard(nil), b.inside...), newBitboard(w*h)
+	for b.grow(b.inside, frontier, next, newBitboard(w*h)) {
+		frontier, next = next, frontier
+	}
+
+	if len(dead) == w*h {
+		b.dead = dead
+	} else {
+		b.computeDeadCells()
+	}
+
+	b.boxIndex = make([]int32, w*h)
+	for c := range b.boxIndex {
+		b.boxIndex[c] = -1
+		if !b.wall[c] && (!b.dead[c] || lb.boxes.has(c)) {
+			b.boxIndex[c] = int32(len(b.boxCells))
+			b.boxCells = append(b.boxCells, int32(c))
+		}
+	}
+	b.words = len(newBitboard(len(b.boxCells)))
+	b.boxGoals = newBitboard(len(b.boxCells))
+	for k, c := range b.boxCells {
+		if b.goal[c] {
+			b.boxGoals.set(k)
+		}
+	}
+
+	return b.clone(), boxes, l.py*w + l.px
+}
+
+// clone returns a board sharing the level data with its own scratch space
+func (b *solverBoard) clone() *solverBoard {
+
+	c := *b
+	c.area = newBitboard(b.w * b.h)
+	c.frontier = newBitboard(b.w * b.h)
+	c.next = newBitboard(b.w * b.h)
+	c.dist = make([]int32, b.w*b.h)
+	c.blocked = newBitboard(b.w * b.h)
+	c.cells = nil
+	c.packed = nil
+	c.corral = newBitboard(b.w * b.h)
+	c.corralRest = newBitboard(b.w * b.h)
+	c.fence = newBitboard(b.w * b.h)
+	c.fenceTry = newBitboard(b.w * b.h)
+	c.occupied = make([]bool, b.w*b.h)
+	c.match = matcher{}
+	c.later = nil
+	c.seen, c.frozenBoxes = nil, nil
+	c.seenCell = make([]bool, b.w*b.h)
+
+	return &c
+}
+
+// step returns the neighbour of cell c in direction d, or -1 outside the grid
+func (b *solverBoard) step(c int, d byte) int {
+
+	dx, dy := dirDelta(d)
+	x, y := c%b.w+dx, c/b.w+dy
+
+	if x < 0 || y < 0 || x >= b.w || y >= b.h {
+		return -1
+	}
+	return y*b.w + x
+}
+
+func (b *solverBoard) floor(c int) bool {
+	return c >= 0 && !b.wall[c]
+}
+
+// computeDeadCells pulls a box away from every goal: the cells it can never
+// be pulled to are cells from which it can never be pushed to a goal
+func (b *solverBoard) computeDeadCells() {
+
+	live := make([]bool, b.w*b.h)
+	var queue []int
+
+	for c := range b.goal {
+		if b.goal[c] {
+			live[c] = true
+			queue = append(queue, c)
+		}
+	}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, d := range directions {
+			from := b.step(c, d)
+			player := -1
+			if from >= 0 {
+				player = b.step(from, d)
+			}
+			if b.floor(from) && b.floor(player) && !live[from] {
+				live[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+
+	b.dead = make([]bool, b.w*b.h)
+	for c := range live {
+		b.dead[c] = !live[c] && !b.wall[c]
+	}
+}
+
+// computeGoalDist finds how many pushes a box needs to reach each goal
+func (b *solverBoard) computeGoalDist() {
+
+	b.goalCells, b.toGoal = nil, nil
+	b.goalDist = make([]int32, b.w*b.h)
+	for c := range b.goalDist {
+		b.goalDist[c] = unreachable
+	}
+
+	for g := range b.goal {
+		if !b.goal[g] {
+			continue
+		}
+		dist := make([]int32, b.w*b.h)
+		for c, n := range pushDistances(b, g) {
+			dist[c] = int32(n)
+			if dist[c] < b.goalDist[c] {
+				b.goalDist[c] = dist[c]
+			}
+		}
+		b.goalCells = append(b.goalCells, int32(g))
+		b.toGoal = append(b.toGoal, dist)
+	}
+}
+
+// nearestBound returns the pushes left at least for the boxes of b.cells,
+// each box needing its own pushes to the nearest goal
+func (b *solverBoard) nearestBound() int32 {
+
+	var h int32
+	for _, c := range b.cells {
+		h += b.goalDist[c]
+	}
+	return h
+}
+
+// lowerBound returns the pushes left at least for the boxes of b.cells,
+// each going to a goal of its own: the cheapest assignment of the boxes to
+// the goals. It is unreachable or more when the boxes cannot all reach
+// a goal of their own.
+func (b *solverBoard) lowerBound() int32 {
+
+	n, m := len(b.cells), len(b.goalCells)
+	if n > m {
+		return unreachable
+	}
+	cost := b.match.costs(n, m)
+	for i, c := range b.cells {
+		row := cost[i*m : (i+1)*m]
+		for j := range row {
+			row[j] = b.toGoal[j][c]
+		}
+	}
+	return b.match.solve(n, m)
+}
+
+// frozen tells if the box just pushed to c sits in a 2x2 block of walls and
+// boxes that is not entirely on goals: none of those boxes can move again
+func (b *solverBoard) frozen(c int, occupied []bool) bool {
+
+	x, y := c%b.w, c/b.w
+
+	for _, o := range [][2]int{{-1, -1}, {0, -1}, {-1, 0}, {0, 0}} {
+		blocked, onGoals := true, true
+		for i := 0; i < 4 && blocked; i++ {
+			cx, cy := x+o[0]+i%2, y+o[1]+i/2
+			if cx < 0 || cy < 0 || cx >= b.w || cy >= b.h {
+				continue
+			}
+			cc := cy*b.w + cx
+			if b.wall[cc] {
+				continue
+			}
+			if occupied[cc] || cc == c {
+				if !b.goal[cc] {
+					onGoals = false
+				}
+				continue
+			}
+			blocked = false
+		}
+		if blocked && !onGoals {
+			return true
+		}
+	}
+	return false
+}
+
+// freezeDeadlock tells if the box just pushed to c, occupied holding it,
+// can never move again, nor the boxes holding it, one of them being off a
+// goal. A box is frozen along an axis by a wall on either side, by dead
+// cells on both sides, or by a frozen box on either side, the boxes already
+// looked at counting as walls.
+func (b *solverBoard) freezeDeadlock(c int, occupied []bool) bool {
+
+	b.seen, b.frozenBoxes = b.seen[:0], b.frozenBoxes[:0]
+	stuck := b.freezes(c, occupied)
+	for _, s := range b.seen {
+		b.seenCell[s] = false
+	}
+	if !stuck {
+		return false
+	}
+	for _, f := range b.frozenBoxes {
+		if !b.goal[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// freezes tells if the box on c is frozen along both axes
+func (b *solverBoard) freezes(c int, occupied []bool) bool {
+
+	b.seenCell[c] = true
+	b.seen = append(b.seen, int32(c))
+	if b.frozenAlong(c, LEFT, occupied) && b.frozenAlong(c, UP, occupied) {
+		b.frozenBoxes = append(b.frozenBoxes, int32(c))
+		return true
+	}
+	return false
+}
+
+// frozenAlong tells if the box on c cannot move along direction d and its
+// opposite
+func (b *solverBoard) frozenAlong(c int, d byte, occupied []bool) bool {
+
+	n, m := b.step(c, d), b.step(c, oppositeDir(d))
+	if !b.floor(n) || !b.floor(m) || b.seenCell[n] || b.seenCell[m] {
+		return true
+	}
+	if b.dead[n] && b.dead[m] {
+		return true
+	}
+	return occupied[n] && b.freezes(n, occupied) || occupied[m] && b.freezes(m, occupied)
+}
+
+// reach finds the cells the player can walk to around the occupied ones,
+// recording how far they are, and returns the smallest one, used to
+// identify the player area
+func (b *solverBoard) reach(player int, occupied []bool) int {
+
+	b.blocked.clear()
+	for c, o := range occupied {
+		if o {
+			b.blocked.set(c)
+		}
+	}
+	return b.reachBits(player, b.blocked)
+}
+
+func (b *solverBoard) reached(c int) bool {
+	return b.area.has(c)
+}
+
+// path returns the walk from one cell to another around the boxes
+func (b *solverBoard) path(from, to int, occupied []bool) []byte {
+
+	if from == to {
+		return nil
+	}
+
+	prev := make(map[int]byte)
+	prev[from] = 0
+	queue := []int{from}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, d := range directions {
+			n := b.step(c, d)
+			if !b.floor(n) || occupied[n] {
+				continue
+			}
+			if _, ok := prev[n]; ok {
+				continue
+			}
+			prev[n] = d
+			if n == to {
+				var walk []byte
+				for n != from {
+					walk = append(walk, prev[n])
+					n = b.step(n, oppositeDir(prev[n]))
+				}
+				for i, j := 0, len(walk)-1; i < j; i, j = i+1, j-1 {
+					walk[i], walk[j] = walk[j], walk[i]
+				}
+				return walk
+			}
+			queue = append(queue, n)
+		}
+	}
+	return nil
+}
+
+func (b *solverBoard) solved(boxes bitboard) bool {
+
+	for i, w := range boxes {
+		if w&^b.boxGoals[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeBoxes returns the boxes of node i, over boxCells, kept in arena node
+// after node
+func (b *solverBoard) nodeBoxes(arena bitboard, i int32) bitboard {
+
+	return arena[int(i)*b.words : int(i+1)*b.words]
+}
+
+// unpack sets b.cells and b.blocked to the cells of boxes, a bitboard over
+// boxCells, and returns their Zobrist hash
+func (b *solverBoard) unpack(boxes bitboard) uint64 {
+
+	var h uint64
+	b.cells = b.cells[:0]
+	b.blocked.clear()
+	for _, k := range boxes.appendCells(b.packed[:0]) {
+		c := b.boxCells[k]
+		b.cells = append(b.cells, c)
+		b.blocked.set(int(c))
+		h ^= b.zBox[c]
+	}
+	return h
+}
+
+// solveLevel searches for a solution of l optimal for the given mode,
+// giving up after about maxNodes expanded nodes (0 means no limit) or when
+// cancelled through progress, which may be nil
+func solveLevel(l Level, mode solverMode, maxNodes int, progress *solverProgress) solverResult {
+
+	b, boxes, player := newSolverBoard(l, nil)
+
+	return b.search(boxes, player, mode, maxNodes, progress)
+}
+
+func (b *solverBoard) search(boxes []int32, player int, mode solverMode, maxNodes int, progress *solverProgress) solverResult {
+
+	start := time.Now()
+
+	var heapBefore uint64
+	if solverMeasureMemory {
+		heapBefore = liveHeap()
+	}
+
+	if progress == nil {
+		progress = &solverProgress{}
+	}
+
+	workers := solverWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	b.computeGoalDist()
+	boards := make([]*solverBoard, workers)
+	for w := range boards {
+		boards[w] = b.clone()
+	}
+
+	var res solverResult
+
+	root := newBitboard(len(b.boxCells))
+	for _, c := range boxes {
+		root.set(int(b.boxIndex[c]))
+	}
+	nodes := []solverNode{{player: int32(player), parent: -1}}
+	arena := root
+	table := newSolverTable()
+
+	b.match = matcher{}
+	b.unpack(root)
+	var queue solverQueue
+	queue.push(b.nearestBound(), b.nearestBound(), 0)
+
+	for {
+		cost, batch := queue.pop()
+		if batch == nil {
+			break
+		}
+
+		atomic.StoreInt64(&progress.depth, int64(cost))
+		res.bound = cost
+
+		sort.Slice(batch, func(i, j int) bool {
+			_, a := nodes[batch[i]].cost(mode)
+			_, b := nodes[batch[j]].cost(mode)
+			return a < b
+		})
+
+		// the bound never drops by more than a push, so children cost as
+		// much or more: the first solution of the batch is optimal
+		if i := b.firstSolved(arena, batch); i >= 0 {
+			res.moves, res.pushes = b.replay(nodes, arena, i)
+			res.solved = true
+			break
+		}
+
+		children := make([][]solverNode, workers)
+		childBoxes := make([]bitboard, workers)
+		childLower := make([][]int32, workers)
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for k := w; k < len(batch); k += workers {
+					if progress.isCancelled() || maxNodes > 0 && atomic.LoadInt64(&progress.nodes) >= int64(maxNodes) {
+						return
+					}
+					children[w], childBoxes[w], childLower[w] = boards[w].expand(nodes, arena, batch[k], int32(cost), mode, table,
+						&progress.nodes, children[w], childBoxes[w], childLower[w])
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		for _, board := range boards {
+			for _, l := range board.later {
+				if l.cost < unreachable {
+					p, _ := nodes[l.node].cost(mode)
+					queue.push(l.cost, l.cost-p, l.node)
+				}
+			}
+			board.later = board.later[:0]
+		}
+
+		for w, list := range children {
+			arena = append(arena, childBoxes[w]...)
+			for k, n := range list {
+				nodes = append(nodes, n)
+				p, _ := n.cost(mode)
+				if lower := childLower[w][k]; lower < unreachable {
+					queue.push(p+lower, lower, int32(len(nodes)-1))
+				}
+			}
+		}
+
+		if progress.isCancelled() || maxNodes > 0 && progress.nodes >= int64(maxNodes) {
+			break
+		}
+	}
+
+	res.stats.nodes = int(progress.nodes)
+	res.stats.elapsed = time.Since(start)
+	res.stats.stored = len(nodes)
+
+	if solverMeasureMemory {
+		if heap := liveHeap(); heap > heapBefore {
+			res.stats.memory = heap - heapBefore
+		}
+		runtime.KeepAlive(nodes)
+		runtime.KeepAlive(arena)
+		runtime.KeepAlive(table)
+		runtime.KeepAlive(queue)
+	}
+
+	return res
+}
+
+// liveHeap returns the bytes of the heap in use once the garbage is gone
+func liveHeap() uint64 {
+
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// solverQueue holds the nodes waiting to be expanded by cost, their
+// primary cost plus the lower bound of the pushes left, then by that bound:
+// among the nodes of the cheapest cost those closest to a solution come
+// first, which dives to a solution once the cost is that of the best ones
+type solverQueue struct {
+	buckets [][][]int32
+	cost    int // no node costs less
+}
+
+func (q *solverQueue) push(cost int32, lower int32, i int32) {
+
+	for int(cost) >= len(q.buckets) {
+		q.buckets = append(q.buckets, nil)
+	}
+	bucket := q.buckets[cost]
+	for int(lower) >= len(bucket) {
+		bucket = append(bucket, nil)
+	}
+	bucket[lower] = append(bucket[lower], i)
+	q.buckets[cost] = bucket
+}
+
+// pop takes the nodes of the cheapest cost closest to a solution, and
+// returns that cost; there are none when the queue is empty
+func (q *solverQueue) pop() (int, []int32) {
+
+	for ; q.cost < len(q.buckets); q.cost++ {
+		for lower, batch := range q.buckets[q.cost] {
+			if len(batch) > 0 {
+				q.buckets[q.cost][lower] = nil
+				return q.cost, batch
+			}
+		}
+		q.buckets[q.cost] = nil
+	}
+	return q.cost, nil
+}
+
+func (b *solverBoard) firstSolved(arena bitboard, batch []int32) int {
+
+	for _, i := range batch {
+		if b.solved(b.nodeBoxes(arena, i)) {
+			return int(i)
+		}
+	}
+	return -1
+}
+
+// expand appends to out the children of node i, their boxes to outBoxes
+// and lower bounds of their pushes left to outLower, unless its position
+// has already been expanded at a lower cost. The node was put in the bucket
+// of cost with a bound its own lower bound may exceed: it then goes to
+// b.later instead, to come back in a later bucket.
+func (b *solverBoard) expand(nodes []solverNode, arena bitboard, i int32, cost int32, mode solverMode, table *solverTable, expanded *int64,
+	out []solverNode, outBoxes bitboard, outLower []int32) ([]solverNode, bitboard, []int32) {
+
+	n := &nodes[i]
+	boxes := b.nodeBoxes(arena, i)
+	hash := b.unpack(boxes)
+	occupied := b.occupied
+
+	for _, c := range b.cells {
+		occupied[c] = true
+	}
+	defer func() {
+		for _, c := range b.cells {
+			occupied[c] = false
+		}
+	}()
+
+	area := b.reachBits(int(n.player), b.blocked)
+	if mode == optimizeMoves {
+		area = int(n.player)
+	}
+
+	primary, secondary := n.cost(mode)
+	key := hash ^ b.zPlayer[area]
+	if table.expanded(key, primary, secondary) {
+		return out, outBoxes, outLower
+	}
+
+	lower := b.lowerBound()
+	if primary+lower > cost {
+		b.later = append(b.later, solverLater{i, primary + lower})
+		return out, outBoxes, outLower
+	}
+	nearest := b.nearestBound()
+
+	if !table.claim(key, primary, secondary) {
+		return out, outBoxes, outLower
+	}
+	atomic.AddInt64(expanded, 1)
+
+	// only the boxes of a corral are pushed when there is one
+	var fence bitboard
+	if mode == optimizePushes {
+		var ok bool
+		if fence, ok = b.corralBoxes(occupied); !ok {
+			return out, outBoxes, outLower
+		}
+	}
+
+	for _, c := range b.cells {
+		if fence != nil && !fence.has(int(c)) {
+			continue
+		}
+		for _, d := range directions {
+			from, to, ok := b.canPush(int(c), d, occupied)
+			if !ok || !b.reached(from) || b.dead[to] {
+				continue
+			}
+
+			player, box, run := int(c), to, 1
+			if mode == optimizePushes {
+				player, box, run = b.tunnelRun(player, box, d, occupied)
+			}
+
+			occupied[c], occupied[box] = false, true
+			stuck := b.freezeDeadlock(box, occupied)
+			occupied[c], occupied[box] = true, false
+
+			if stuck {
+				continue
+			}
+
+			outBoxes = append(outBoxes, boxes...)
+			next := outBoxes[len(outBoxes)-b.words:]
+			next.unset(int(b.boxIndex[c]))
+			next.set(int(b.boxIndex[box]))
+
+			// a push takes a box one cell closer to a goal at most
+			childLower := nearest - b.goalDist[c] + b.goalDist[box]
+			if lower-int32(run) > childLower {
+				childLower = lower - int32(run)
+			}
+			outLower = append(outLower, childLower)
+			out = append(out, solverNode{player: int32(player), parent: i, dir: d, run: byte(run),
+				pushes: n.pushes + int32(run), moves: n.moves + b.dist[from] + int32(run)})
+		}
+	}
+
+	return out, outBoxes, outLower
+}
+
+// replay turns the chain of pushes ending at node i into player moves
+func (b *solverBoard) replay(nodes []solverNode, arena bitboard, i int) ([]byte, int) {
+
+	// the root, a start already solved, takes no push
+	pushes := int(nodes[i].pushes)
+
+	var chain []int
+	for ; i > 0; i = int(nodes[i].parent) {
+		chain = append(chain, i)
+	}
+
+	occupied := make([]bool, b.w*b.h)
+	player := int(nodes[0].player)
+
+	var moves []byte
+
+	for k := len(chain) - 1; k >= 0; k-- {
+		n := nodes[chain[k]]
+		b.unpack(b.nodeBoxes(arena, n.parent))
+		boxes := append([]int32(nil), b.cells...)
+
+		for _, c := range boxes {
+			occupied[c] = true
+		}
+
+		from := int(n.player)
+		for r := 0; r < int(n.run); r++ {
+			from = b.step(from, oppositeDir(n.dir))
+		}
+		moves = append(moves, b.path(player, from, occupied)...)
+		for r := 0; r < int(n.run); r++ {
+			moves = append(moves, n.dir)
+		}
+		player = int(n.player)
+
+		for _, c := range boxes {
+			occupied[c] = false
+		}
+	}
+
+	return moves, pushes
+}
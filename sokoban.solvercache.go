@@ -0,0 +1,129 @@
+// Sokoban game
+//
+// Solver cache: solutions and dead cell tables are kept on disk, one file per
+// level named after a hash of the level, so asking again for a level that
+// has already been analyzed is instant.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+type solverCacheEntry struct {
+	Dead      []int                     `json:"dead"`      // dead cells, indexed by y*w+x
+	Solutions map[string]cachedSolution `json:"solutions"` // by solver mode
+}
+
+type cachedSolution struct {
+	Moves  string `json:"moves"` // LURD
+	Pushes int    `json:"pushes"`
+}
+
+// levelHash identifies a position: size, tiles and player
+func levelHash(l Level) string {
+
+	h := sha256.New()
+
+	// the XSB text does not depend on how tiles are numbered in memory
+	for _, row := range levelToXSB(l) {
+		h.Write([]byte(row + "\n"))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// solverCachePath returns where the entry of a level is stored, or "" when
+// there is no usable cache directory
+func solverCachePath(hash string) string {
+
+	dir, err := cacheRoot()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "solver", hash+".json")
+}
+
+func loadSolverCache(hash string) solverCacheEntry {
+
+	var e solverCacheEntry
+
+	if path := solverCachePath(hash); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			if err := json.Unmarshal(data, &e); err != nil {
+				log.Printf("ignoring solver cache %s: %v", path, err)
+				e = solverCacheEntry{}
+			}
+		}
+	}
+
+	if e.Solutions == nil {
+		e.Solutions = make(map[string]cachedSolution)
+	}
+	return e
+}
+
+func saveSolverCache(hash string, e solverCacheEntry) {
+
+	path := solverCachePath(hash)
+	if path == "" {
+		return
+	}
+
+	data, err := json.Marshal(e)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		log.Printf("cannot write solver cache: %v", err)
+	}
+}
+
+// solveLevelCached is solveLevel going through the cache
+func solveLevelCached(l Level, mode solverMode, progress *solverProgress) solverResult {
+
+	hash := levelHash(l)
+	e := loadSolverCache(hash)
+
+	if sol, ok := e.Solutions[mode.String()]; ok {
+		if moves, err := lurdToMoves(sol.Moves); err == nil {
+			return solverResult{moves: moves, pushes: sol.Pushes, solved: true}
+		}
+	}
+
+	var dead []bool
+	if e.Dead != nil {
+		dead = make([]bool, l.w*l.h)
+		for _, c := range e.Dead {
+			if c >= 0 && c < len(dead) {
+				dead[c] = true
+			}
+		}
+	}
+
+	b, boxes, player := newSolverBoard(l, dead)
+	res := b.search(boxes, player, mode, 0, progress)
+
+	if res.solved || e.Dead == nil {
+		e.Dead = []int{}
+		for c, d := range b.dead {
+			if d {
+				e.Dead = append(e.Dead, c)
+			}
+		}
+		if res.solved {
+			e.Solutions[mode.String()] = cachedSolution{movesToLURD(res.moves), res.pushes}
+		}
+		saveSolverCache(hash, e)
+	}
+
+	return res
+}
@@ -0,0 +1,269 @@
+// Sokoban game
+//
+// Speedrun mode: run with -speedrun
+//
+// Times a run through every embedded level, from the first to the last,
+// with a split at each solved level. The best time of each level (best
+// segments) and the splits of the best complete run are kept in
+// speedrun.json, and the overlay compares the current run with them. With
+// -speedrun-lss file the splits are also written as a LiveSplit file at the
+// end of each run.
+//
+// Going to another level than the next one of the run, or letting the
+// solver play, ends the run. The clock stops while the game is paused, for
+// the focus or for a break. With -shuffle the run follows the shuffled
+// order and its records are kept apart, in speedrun-shuffle-SEED.json.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type speedrunRecords struct {
+	Attempts     int       `json:"attempts"`
+	BestSegments []float64 `json:"best_segments"` // seconds per level, 0 when never solved in a run
+	PersonalBest []float64 `json:"personal_best"` // time of each split of the best run
+}
+
+type speedrunRun struct {
+	start     time.Time
+	lastSplit time.Time
+	splits    []float64 // seconds since start
+}
+
+var (
+	speedrunRecord speedrunRecords
+	speedrun       *speedrunRun // nil when no run goes on
+	speedrunLSS    string
+)
+
+func speedrunPackChanged(e GameEvent) {
+
+	speedrun = nil
+}
+
+// speedrunFile returns where the records of the current order are kept
+func speedrunFile() string {
+
+	if shuffleSeed != 0 {
+		return fmt.Sprintf("speedrun-shuffle-%d.json", shuffleSeed)
+	}
+	return "speedrun.json"
+}
+
+func loadSpeedrunRecords() {
+
+	speedrunRecord = speedrunRecords{}
+	if err := loadJSON(speedrunFile(), &speedrunRecord); err != nil && !os.IsNotExist(err) {
+		log.Printf("cannot read speedrun records: %v", err)
+	}
+	for len(speedrunRecord.BestSegments) < LEVEL_MAX+1 {
+		speedrunRecord.BestSegments = append(speedrunRecord.BestSegments, 0)
+	}
+}
+
+func saveSpeedrunRecords() {
+
+	if err := saveJSON(speedrunFile(), &speedrunRecord); err != nil {
+		log.Printf("cannot save speedrun records: %v", err)
+	}
+}
+
+// startSpeedrun starts a run from the first level of the playthrough
+func startSpeedrun(s *GameState) {
+
+	loadSpeedrunRecords()
+	speedrunRecord.Attempts++
+	saveSpeedrunRecords()
+
+	// a run starts every level afresh, the one left included
+	s.loadLevel(levelAt(0))
+	s.sessions = nil
+
+	now := time.Now()
+	speedrun = &speedrunRun{start: now, lastSplit: now}
+}
+
+func endSpeedrun(reason string) {
+
+	speedrun = nil
+	showMessage("speedrun ended: " + reason)
+}
+
+// speedrunPaused takes a pause of d off the clock of the run
+func speedrunPaused(d time.Duration) {
+
+	if speedrun != nil {
+		speedrun.start = speedrun.start.Add(d)
+		speedrun.lastSplit = speedrun.lastSplit.Add(d)
+	}
+}
+
+// speedrunClock is the time the clock of the run stopped at during a
+// pause, or now
+func speedrunClock() time.Time {
+
+	switch {
+	case breakPaused:
+		return breakPausedAt
+	case focusPaused:
+		return pausedAt
+	}
+	return time.Now()
+}
+
+// speedrunLevelStarted ends the run when the player leaves its course
+func speedrunLevelStarted(s *GameState) {
+
+	if speedrun != nil && levelPosition(s.currentLevelNumber) != len(speedrun.splits) {
+		endSpeedrun("level skipped")
+	}
+}
+
+// speedrunSplit records the split of the level just solved
+func speedrunSplit(s *GameState) {
+
+	if speedrun == nil || levelPosition(s.currentLevelNumber) != len(speedrun.splits) {
+		return
+	}
+	if s.assisted {
+		endSpeedrun("the solver played")
+		return
+	}
+
+	now := time.Now()
+	n := len(speedrun.splits)
+	segment := now.Sub(speedrun.lastSplit).Seconds()
+
+	speedrun.splits = append(speedrun.splits, now.Sub(speedrun.start).Seconds())
+	speedrun.lastSplit = now
+
+	if best := speedrunRecord.BestSegments[n]; best == 0 || segment < best {
+		speedrunRecord.BestSegments[n] = segment
+	}
+
+	if n == LEVEL_MAX {
+		total := speedrun.splits[n]
+		pb := speedrunRecord.PersonalBest
+		if len(pb) != LEVEL_MAX+1 || total < pb[LEVEL_MAX] {
+			speedrunRecord.PersonalBest = speedrun.splits
+			showMessage("speedrun finished in " + formatRunTime(total) + ", personal best!")
+		} else {
+			showMessage("speedrun finished in " + formatRunTime(total))
+		}
+		if speedrunLSS != "" {
+			if err := exportLiveSplit(speedrunLSS); err != nil {
+				log.Printf("cannot write %s: %v", speedrunLSS, err)
+			}
+		}
+		speedrun = nil
+	}
+
+	saveSpeedrunRecords()
+}
+
+func formatRunTime(sec float64) string {
+
+	d := time.Duration(sec * float64(time.Second))
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	s := d.Seconds() - float64(int(d.Minutes())*60)
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%04.1f", h, m, s)
+	}
+	return fmt.Sprintf("%d:%04.1f", m, s)
+}
+
+func drawSpeedrun(screen *ebiten.Image) {
+
+	if speedrun == nil {
+		return
+	}
+
+	n := len(speedrun.splits)
+	elapsed := speedrunClock().Sub(speedrun.start).Seconds()
+	msg := fmt.Sprintf("Speedrun %s\nlevel %d, %d of %d solved", formatRunTime(elapsed), levelAt(n), n, LEVEL_MAX+1)
+
+	// compare the last split with the personal best
+	if pb := speedrunRecord.PersonalBest; n > 0 && len(pb) == LEVEL_MAX+1 {
+		delta := speedrun.splits[n-1] - pb[n-1]
+		sign := "+"
+		if delta < 0 {
+			sign, delta = "-", -delta
+		}
+		msg += fmt.Sprintf("\nlast split %s%s", sign, formatRunTime(delta))
+	}
+	if best := speedrunRecord.BestSegments[n]; best > 0 {
+		msg += "\nbest segment " + formatRunTime(best)
+	}
+
+	drawText(screen, msg, screenWidth-300, 120)
+}
+
+// LiveSplit splits file
+type lssRun struct {
+	XMLName      xml.Name     `xml:"Run"`
+	Version      string       `xml:"version,attr"`
+	GameName     string       `xml:"GameName"`
+	CategoryName string       `xml:"CategoryName"`
+	Attempts     int          `xml:"AttemptCount"`
+	Segments     []lssSegment `xml:"Segments>Segment"`
+}
+
+type lssSegment struct {
+	Name        string       `xml:"Name"`
+	SplitTimes  []lssTime    `xml:"SplitTimes>SplitTime"`
+	BestSegment *lssRealTime `xml:"BestSegmentTime,omitempty"`
+}
+
+type lssTime struct {
+	Name     string `xml:"name,attr"`
+	RealTime string `xml:"RealTime,omitempty"`
+}
+
+type lssRealTime struct {
+	RealTime string `xml:"RealTime"`
+}
+
+// lssDuration formats seconds the way LiveSplit does: 00:01:02.3400000
+func lssDuration(sec float64) string {
+
+	d := time.Duration(sec * float64(time.Second))
+	return fmt.Sprintf("%02d:%02d:%010.7f", int(d.Hours()), int(d.Minutes())%60, d.Seconds()-float64(int(d.Minutes())*60))
+}
+
+func exportLiveSplit(path string) error {
+
+	run := lssRun{Version: "1.7.0", GameName: "Sokoban", CategoryName: "All levels", Attempts: speedrunRecord.Attempts}
+	if shuffleSeed != 0 {
+		run.CategoryName = fmt.Sprintf("All levels, shuffle seed %d", shuffleSeed)
+	}
+
+	for n := 0; n <= LEVEL_MAX; n++ {
+		seg := lssSegment{Name: fmt.Sprintf("Level %d", levelAt(n))}
+
+		pb := lssTime{Name: "Personal Best"}
+		if len(speedrunRecord.PersonalBest) == LEVEL_MAX+1 {
+			pb.RealTime = lssDuration(speedrunRecord.PersonalBest[n])
+		}
+		seg.SplitTimes = []lssTime{pb}
+
+		if best := speedrunRecord.BestSegments[n]; best > 0 {
+			seg.BestSegment = &lssRealTime{lssDuration(best)}
+		}
+		run.Segments = append(run.Segments, seg)
+	}
+
+	data, err := xml.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
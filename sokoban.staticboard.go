@@ -0,0 +1,109 @@
+// Sokoban game
+//
+// The floor, walls and goals of a level never change while it is played:
+// they are drawn once into an offscreen image, and each frame only blits
+// that image and draws the boxes and the player over it.
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	staticBoard      *ebiten.Image
+	staticBoardLevel = -1
+	staticBoardView  int
+	staticBoardSize  int // tile size of staticBoard
+)
+
+// forgetStaticBoard has the board drawn again: a level started anew may
+// not be laid out as before, pulled boxes swapping goals and boxes
+func forgetStaticBoard() {
+
+	staticBoardLevel = -1
+}
+
+// staticTile is what lies under whatever is on a cell
+func staticTile(t byte) byte {
+
+	switch t {
+	case BOX:
+		return EMPTY
+	case PLACED_BOX:
+		return GOAL
+	}
+	return t
+}
+
+func prepareStaticBoard(s *GameState, sh *spriteSheets) {
+
+	// drawn with the sheet the tiles would be drawn with
+	_, _, factor := boardView(s)
+	size := sh.tileSheetFor(baseTileSize * factor).size
+
+	if staticBoard != nil && staticBoardLevel == s.currentLevelNumber && staticBoardSize == size && staticBoardView == camera.view {
+		return
+	}
+	if staticBoard != nil {
+		staticBoard.Dispose()
+	}
+
+	// laid out as the board is shown
+	w, h := viewSize(s.curLev, camera.view)
+	staticBoard = ebiten.NewImage(size*w, size*h)
+	staticBoardLevel = s.currentLevelNumber
+	staticBoardSize = size
+	staticBoardView = camera.view
+
+	// the floor outside the walls is left to the background
+	outside := outsideCells(s.curLev)
+
+	factor = float64(size) / baseTileSize
+	for i := 0; i < s.curLev.w; i++ {
+		for j := 0; j < s.curLev.h; j++ {
+			if outside[i][j] {
+				continue
+			}
+			x, y := cellToView(s.curLev, camera.view, i, j)
+			sh.drawSprite(staticBoard, x, y, EMPTY, 0, 0, factor, 64.0, 64.0)
+			sh.drawSprite(staticBoard, x, y, int(staticTile(s.curLev.grid[i][j])), 0, 0, factor, 64.0, 64.0)
+		}
+	}
+}
+
+// drawCurrentLevel draws the level of s and the player where the camera shows them
+func drawCurrentLevel(screen *ebiten.Image, s *GameState, sh *spriteSheets) {
+
+	prepareStaticBoard(s, sh)
+
+	sx, sy, factor := boardView(s)
+	shakeX, shakeY := shakeOffset()
+	sx, sy = sx+shakeX, sy+shakeY
+	scale := factor * baseTileSize / float64(staticBoardSize)
+
+	// blindfold mode leaves out what is to be remembered
+	hidden := blindHidden(s)
+
+	if hidden != blindBoard {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(sx, sy)
+		screen.DrawImage(staticBoard, op)
+	}
+
+	if hidden == blindOff {
+		for i := 0; i < s.curLev.w; i++ {
+			for j := 0; j < s.curLev.h; j++ {
+				if t := s.curLev.grid[i][j]; t == BOX || t == PLACED_BOX {
+					x, y := cellToView(s.curLev, camera.view, i, j)
+					sh.drawSprite(screen, x, y, int(t), sx, sy, factor, 64.0, 64.0)
+				}
+			}
+		}
+
+		drawDeadlockPulse(screen, s)
+	}
+	x, y := cellToView(s.curLev, camera.view, s.curLev.px, s.curLev.py)
+	sh.drawSprite(screen, x, y, int(viewSprite(s.curLev.psprite, camera.view)), sx, sy, factor, 64.0, 64.0)
+}
@@ -0,0 +1,70 @@
+// Sokoban game
+//
+// Staying on solved levels: with -stay the game does not load the next
+// level when one is solved but shows the result over the solved board.
+// Enter, space or a click go to the next level, R plays the level again and
+// undo goes back into the game.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var (
+	stayOnSolved = false
+	solvedTime   time.Duration // time taken by the last solve
+)
+
+// updateSolved handles the input while a solved level waits; it returns
+// true when it took over the input
+func updateSolved(s *GameState, in InputSource, mouseOrTouch bool, x int, y int) bool {
+
+	if !stayOnSolved || s.nBoxesLeft() > 0 {
+		return false
+	}
+
+	undo := in.IsKeyJustPressed(ebiten.KeyBackspace) || (mouseOrTouch && inScreenZone(undoScreenZone, x, y))
+
+	switch {
+	case undo:
+		return false
+	case in.IsKeyJustPressed(ebiten.KeyEnter) || in.IsKeyJustPressed(ebiten.KeySpace) || mouseOrTouch:
+		s.switchLevel(stepLevel(s.currentLevelNumber, 1))
+	case in.IsKeyJustPressed(ebiten.KeyR):
+		s.loadLevel(s.currentLevelNumber)
+	case in.IsKeyJustPressed(ebiten.KeyC) && in.IsKeyPressed(ebiten.KeyShift):
+		makeChallenge(s, moveLimit)
+	case in.IsKeyJustPressed(ebiten.KeyC):
+		makeChallenge(s, timeAttack)
+	}
+
+	return true
+}
+
+func drawSolvedSummary(screen *ebiten.Image, s *GameState) {
+
+	if !stayOnSolved || s.nBoxesLeft() > 0 {
+		return
+	}
+
+	// zen mode keeps the time to itself
+	t := fmt.Sprintf("\ntime:   %s", solvedTime.Round(time.Second))
+	if zenMode {
+		t = ""
+	}
+	msg := fmt.Sprintf("Level %d solved\n\nmoves:  %d\npushes: %d%s\n\nEnter: next level\nR: play again\nBackspace: undo\nH: mistake heatmap\nC, Shift+C: challenge link",
+		s.currentLevelNumber, s.moveCount(), s.pushCount, t)
+
+	x, y := screenWidth/2-110, screenHeight/2-90
+	ebitenutil.DrawRect(screen, float64(x), float64(y), 220, 212, color.RGBA{0, 0, 0, 180})
+	drawText(screen, msg, x+20, y+15)
+
+	drawLeaderboard(screen, s.currentLevelNumber, x+230, y)
+	drawChallengeLink(screen, s, x, y+222)
+}
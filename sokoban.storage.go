@@ -0,0 +1,277 @@
+// Sokoban game
+//
+// Files kept between sessions, stored as JSON in the data directory (see
+// dataRoot)
+//
+// Each file carries the version of its format, {"version": 1, "data": ...},
+// the files written before the versions being version 0. A file of an older
+// version is brought up to date by the migrations of saveMigrations when
+// read, the original being kept next to it as name.vN; a file written by a
+// newer game is neither read nor written over.
+//
+// A file is written whole to a temporary file first, which then takes its
+// place, the previous file being kept as name.bak, a link to or a copy of
+// it; the directory is synced after the rename. The envelope also
+// carries a checksum of the data. When the file is missing or damaged, by
+// a crash in the middle of a write for instance, the backup is read.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// a migration turns the data of a file from one version to the next
+type saveMigration func(data json.RawMessage) (json.RawMessage, error)
+
+// saveMigrations lists, by file, the migrations from version 1 on; version
+// 0 only lacks the envelope. A change of format appends its migration here,
+// which makes the version of the file one more.
+var saveMigrations = map[string][]saveMigration{}
+
+// saved files that a newer game wrote, left alone
+var newerSaves = make(map[string]bool)
+
+type savedFile struct {
+	Version  int             `json:"version"`
+	Checksum string          `json:"checksum,omitempty"` // see saveChecksum
+	Data     json.RawMessage `json:"data"`
+}
+
+var errNewerSave = errors.New("written by a newer game")
+
+// saveChecksum returns the checksum of data, compacted
+func saveChecksum(data json.RawMessage) (string, error) {
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// saveVersion returns the current version of the format of file name
+func saveVersion(name string) int {
+
+	return 1 + len(saveMigrations[name])
+}
+
+func userFilePath(name string) (string, error) {
+
+	dir, err := dataRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// migrateSave brings data of file name from version to the current one
+func migrateSave(name string, version int, data json.RawMessage) (json.RawMessage, error) {
+
+	for v := version; v < saveVersion(name); v++ {
+		if v == 0 {
+			continue
+		}
+		var err error
+		if data, err = saveMigrations[name][v-1](data); err != nil {
+			return nil, fmt.Errorf("%s from version %d: %v", name, v, err)
+		}
+	}
+	return data, nil
+}
+
+// readSaved checks the content of file name, stored at path, and returns
+// its data brought up to the current version
+func readSaved(name string, path string, data []byte) (json.RawMessage, error) {
+
+	version, payload := 0, json.RawMessage(data)
+	latest := saveVersion(name)
+
+	// a newer game may check its files some other way
+	var f savedFile
+	if json.Unmarshal(data, &f) == nil && f.Version > latest {
+		return nil, fmt.Errorf("%s is of version %d (this game reads up to %d): %w", name, f.Version, latest, errNewerSave)
+	}
+
+	if f.Version > 0 && f.Data != nil {
+		version, payload = f.Version, f.Data
+		if sum, err := saveChecksum(f.Data); err != nil || sum != f.Checksum {
+			return nil, fmt.Errorf("%s is damaged, its checksum does not match", name)
+		}
+	} else if !json.Valid(data) {
+		return nil, fmt.Errorf("%s is damaged, it is not JSON", name)
+	}
+
+	if version < latest {
+		var err error
+		if payload, err = migrateSave(name, version, payload); err != nil {
+			return nil, err
+		}
+		backup := fmt.Sprintf("%s.v%d", path, version)
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			os.WriteFile(backup, data, 0644)
+		}
+	}
+	return payload, nil
+}
+
+// loadJSON reads file name into v, the error satisfies os.IsNotExist when
+// the file has never been written
+func loadJSON(name string, v interface{}) error {
+
+	path, err := userFilePath(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	var payload json.RawMessage
+	if err == nil {
+		payload, err = readSaved(name, path, data)
+	}
+
+	if errors.Is(err, errNewerSave) {
+		newerSaves[name] = true
+		return fmt.Errorf("%v, it is left as it is", err)
+	}
+	if err != nil {
+		backup, berr := os.ReadFile(path + ".bak")
+		if berr != nil {
+			return err
+		}
+		if payload, berr = readSaved(name, path, backup); berr != nil {
+			return err
+		}
+		if !os.IsNotExist(err) {
+			log.Printf("%v, read from its backup", err)
+		}
+	}
+
+	return json.Unmarshal(payload, v)
+}
+
+func saveJSON(name string, v interface{}) error {
+
+	if newerSaves[name] {
+		return fmt.Errorf("%s was written by a newer game, not saving over it", name)
+	}
+
+	path, err := userFilePath(name)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sum, err := saveChecksum(payload)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(savedFile{saveVersion(name), sum, payload}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to path through a temporary file, keeping the
+// file it replaces as path.bak
+func writeFileAtomic(path string, data []byte) error {
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := keepBackup(path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// keepBackup makes path.bak a copy of path, linking it when the file
+// system can, the live file staying where it is the whole time
+func keepBackup(path string) error {
+
+	bak := path + ".bak"
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Link(path, bak)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(bak, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// syncDir flushes the entries of dir, the renames in it included, to the
+// disk
+func syncDir(dir string) error {
+
+	// Windows cannot sync a directory: the rename is as durable as it
+	// makes it
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+// Sokoban game
+//
+// Text beyond ASCII: the debug font of ebitenutil only has ASCII glyphs, so
+// the text holding other characters (titles and authors of level packs,
+// notes, names) is drawn with a stack of fonts instead: the font files
+// given with -fonts, then those of the fonts directory next to the level
+// packs, then the bundled M+ font, which covers the Latin, Japanese and most
+// Chinese characters. A glyph missing from a font is taken from the next
+// one.
+//
+// A line whose first letter belongs to a right-to-left script (Hebrew,
+// Arabic...) is shaped right to left, its left edge staying where the text
+// is drawn. Those scripts have no glyph in the bundled font: a font covering
+// them has to be added.
+
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/text/language"
+)
+
+const (
+	fontsDir = "fonts"
+
+	textSize       = 12 // pixels, about the height of the debug font letters
+	textLineHeight = 16 // pixels, as the debug font
+)
+
+var (
+	fontFiles []string // set with -fonts
+
+	textSources     []*text.GoTextFaceSource
+	textSourcesOnce sync.Once
+
+	textFaces     = make(map[textStyle]text.Face)
+	textFacesLock sync.Mutex
+)
+
+// textStyle is what a line needs from its font stack
+type textStyle struct {
+	rtl    bool
+	script string // ISO 15924 code, "" to let the shaper guess
+}
+
+// rtlScripts are the right-to-left scripts and their codes
+var rtlScripts = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hebrew, "Hebr"},
+	{unicode.Arabic, "Arab"},
+	{unicode.Syriac, "Syrc"},
+	{unicode.Thaana, "Thaa"},
+	{unicode.Nko, "Nkoo"},
+}
+
+// drawText draws str on dst with its top left corner at x, y, like
+// ebitenutil.DebugPrintAt does for ASCII
+func drawText(dst *ebiten.Image, str string, x int, y int) {
+
+	if isASCII(str) {
+		ebitenutil.DebugPrintAt(dst, str, x, y)
+		return
+	}
+
+	for i, line := range strings.Split(str, "\n") {
+		if isASCII(line) {
+			ebitenutil.DebugPrintAt(dst, line, x, y+i*textLineHeight)
+			continue
+		}
+		style := lineStyle(line)
+		face := textFace(style)
+		if face == nil {
+			ebitenutil.DebugPrintAt(dst, line, x, y+i*textLineHeight)
+			continue
+		}
+
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(x), float64(y+i*textLineHeight))
+		if style.rtl {
+			// the end of a right-to-left line is its left edge
+			op.PrimaryAlign = text.AlignEnd
+		}
+		text.Draw(dst, line, face, op)
+	}
+}
+
+func isASCII(str string) bool {
+
+	for i := 0; i < len(str); i++ {
+		if str[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// lineStyle finds the direction and the script of line from its first
+// letter, as the paragraphs of the Unicode bidirectional algorithm do
+func lineStyle(line string) textStyle {
+
+	for _, r := range line {
+		for _, s := range rtlScripts {
+			if unicode.Is(s.table, r) {
+				return textStyle{rtl: true, script: s.code}
+			}
+		}
+		if unicode.IsLetter(r) {
+			break
+		}
+	}
+	return textStyle{}
+}
+
+// textFace returns the font stack drawing the lines of style, nil when no
+// font could be read
+func textFace(style textStyle) text.Face {
+
+	textSourcesOnce.Do(loadTextSources)
+
+	textFacesLock.Lock()
+	defer textFacesLock.Unlock()
+
+	if f, ok := textFaces[style]; ok {
+		return f
+	}
+
+	var f text.Face
+	if len(textSources) > 0 {
+		var script language.Script
+		if style.script != "" {
+			script = language.MustParseScript(style.script)
+		}
+		direction := text.DirectionLeftToRight
+		if style.rtl {
+			direction = text.DirectionRightToLeft
+		}
+
+		faces := make([]text.Face, len(textSources))
+		for i, src := range textSources {
+			faces[i] = &text.GoTextFace{Source: src, Size: textSize, Direction: direction, Script: script}
+		}
+		// the faces all have the same direction
+		f, _ = text.NewMultiFace(faces...)
+	}
+	textFaces[style] = f
+	return f
+}
+
+// loadTextSources reads the fonts of the stack, in order
+func loadTextSources() {
+
+	paths := append([]string(nil), fontFiles...)
+	if dir, err := userFilePath(fontsDir); err == nil {
+		for _, pattern := range []string{"*.ttf", "*.otf"} {
+			found, _ := filepath.Glob(filepath.Join(dir, pattern))
+			paths = append(paths, found...)
+		}
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var src *text.GoTextFaceSource
+			if src, err = text.NewGoTextFaceSource(bytes.NewReader(data)); err == nil {
+				textSources = append(textSources, src)
+				continue
+			}
+		}
+		log.Printf("cannot read the font %s: %v", path, err)
+	}
+
+	src, err := text.NewGoTextFaceSource(bytes.NewReader(fonts.MPlus1pRegular_ttf))
+	if err != nil {
+		log.Printf("cannot read the bundled font: %v", err)
+		return
+	}
+	textSources = append(textSources, src)
+}
@@ -0,0 +1,96 @@
+// Sokoban game
+//
+// The tile sheet can be shipped at several resolutions, next to the 64
+// pixel sokoban_tilesheet.png, as sokoban_tilesheet@<size>.png (for
+// instance sokoban_tilesheet@128.png, with the same layout, see tileAtlas). Tiles are
+// drawn from the smallest sheet at least as large as they appear on the
+// screen, and a sheet is only decoded when it is first needed.
+
+package main
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// size of the tiles in sokoban_tilesheet.png, in which levels are laid out
+const baseTileSize = 64
+
+//go:embed sokoban_tilesheet*
+var tileSheetFiles embed.FS
+
+type tileSheetSet struct {
+	size    int // pixels of a tile
+	file    string
+	sprites map[int]*ebiten.Image // by tile, nil until first used
+}
+
+// spriteSheets are the images a game draws from, cut into sprites once
+type spriteSheets struct {
+	icons [][]*ebiten.Image // sub images of the icon sheet, by column and row
+	tiles []*tileSheetSet   // the available tile sheets, by increasing size
+}
+
+func newSpriteSheets() *spriteSheets {
+
+	return &spriteSheets{
+		icons: cutSpriteSheet(prepareSpriteSheet(iconsPNG), 100, 100),
+		tiles: findTileSheets(),
+	}
+}
+
+func findTileSheets() []*tileSheetSet {
+
+	files, _ := tileSheetFiles.ReadDir(".")
+
+	var sets []*tileSheetSet
+	for _, f := range files {
+		if path.Ext(f.Name()) != ".png" {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+		size := baseTileSize
+		if _, s, ok := strings.Cut(name, "@"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				continue
+			}
+			size = n
+		}
+		sets = append(sets, &tileSheetSet{size: size, file: f.Name()})
+	}
+
+	sort.Slice(sets, func(i, j int) bool { return sets[i].size < sets[j].size })
+
+	return sets
+}
+
+// tileSheetFor returns the sheet to draw tiles that are cell pixels large
+func (sh *spriteSheets) tileSheetFor(cell float64) *tileSheetSet {
+
+	set := sh.tiles[len(sh.tiles)-1]
+	for _, s := range sh.tiles {
+		if float64(s.size) >= cell {
+			set = s
+			break
+		}
+	}
+
+	if set.sprites == nil {
+		data, _ := tileSheetFiles.ReadFile(set.file)
+		sheet := prepareSpriteSheet(data)
+		set.sprites = make(map[int]*ebiten.Image)
+		for t, r := range tileAtlas {
+			if !r.Empty() {
+				set.sprites[t] = sheet.SubImage(tileRect(t, set.size)).(*ebiten.Image)
+			}
+		}
+	}
+
+	return set
+}
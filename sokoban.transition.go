@@ -0,0 +1,73 @@
+// Sokoban game
+//
+// Level transitions: when another level is loaded, the previous board
+// slides out and fades away over the new one instead of disappearing at
+// once. It slides left when going to a next level and right when going
+// back. Restarting a level has no transition.
+
+package main
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const transitionDuration = 350 * time.Millisecond
+
+var (
+	// the last board drawn, and the board of the running transition
+	lastBoard       *ebiten.Image
+	transitionBoard *ebiten.Image
+
+	transitionStart time.Time
+	transitionDir   float64 // -1 slides left, 1 right
+	transitionLevel = -1    // level of lastBoard
+)
+
+func transitionPackChanged(e GameEvent) {
+
+	transitionLevel = -1
+}
+
+// drawTransition draws the transition, if any, over the board just drawn
+// and keeps a copy of that board for the next one
+func drawTransition(screen *ebiten.Image, s *GameState) {
+
+	if lastBoard == nil {
+		lastBoard = ebiten.NewImage(screenWidth, screenHeight)
+		transitionBoard = ebiten.NewImage(screenWidth, screenHeight)
+	}
+
+	if transitionLevel >= 0 && transitionLevel != s.currentLevelNumber && motionScale() > 0 {
+		lastBoard, transitionBoard = transitionBoard, lastBoard
+		transitionStart = time.Now()
+		transitionDir = -1
+		if s.currentLevelNumber < transitionLevel {
+			transitionDir = 1
+		}
+	}
+
+	// copy the new board before drawing over it
+	lastBoard.Fill(color.Black)
+	lastBoard.DrawImage(screen, nil)
+	transitionLevel = s.currentLevelNumber
+
+	scale := motionScale()
+	if scale == 0 {
+		return
+	}
+	t := float64(time.Since(transitionStart)) / (float64(transitionDuration) * scale)
+	if t >= 1 {
+		return
+	}
+
+	// ease out
+	t = 1 - (1-t)*(1-t)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(transitionDir*t*screenWidth/3, 0)
+	op.ColorM.Scale(1, 1, 1, 1-t)
+	screen.DrawImage(transitionBoard, op)
+}
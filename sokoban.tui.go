@@ -0,0 +1,210 @@
+// Sokoban game
+//
+// Text mode frontend: run with -tui
+//
+// Draws the board in the terminal and reads keys from it, which is enough
+// to play over SSH. The terminal is put in raw mode with stty; where that
+// is not available each line typed is read as a sequence of keys instead.
+//
+//	arrows, wasd, hjkl  move          u, backspace  undo
+//	U                   undo back to before the last push
+//	n, p                next/previous level
+//	r                   restart       q             quit
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// tuiASCII draws plain XSB characters instead of Unicode ones
+var tuiASCII = false
+
+var tuiGlyphs = map[byte]string{
+	'#': "█",
+	' ': " ",
+	'.': "·",
+	'$': "□",
+	'*': "■",
+	'@': "☺",
+	'+': "☻",
+}
+
+// setRawTerminal switches the terminal to raw mode and returns a function
+// restoring it, or an error when stty is not usable
+func setRawTerminal() (func(), error) {
+
+	stty := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		return cmd.Output()
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	return func() { stty(strings.TrimSpace(string(saved))) }, nil
+}
+
+func drawTUI(w io.Writer, s *GameState) {
+
+	var b strings.Builder
+
+	// clear the screen and home the cursor; raw mode needs explicit \r
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "Sokoban  level %d/%d  moves %d  pushes %d\r\n\r\n",
+		s.currentLevelNumber, lastLevel(), s.moveCount(), s.pushCount)
+
+	if msg := shuffleStatus(s.currentLevelNumber); msg != "" {
+		b.WriteString(msg + "\r\n\r\n")
+	}
+
+	for _, row := range levelToXSB(s.curLev) {
+		b.WriteString("  ")
+		for _, c := range []byte(row) {
+			if tuiASCII {
+				b.WriteByte(c)
+			} else {
+				b.WriteString(tuiGlyphs[c])
+			}
+		}
+		b.WriteString("\r\n")
+	}
+
+	b.WriteString("\r\narrows/wasd/hjkl move, u undo, r restart, n/p level, q quit\r\n")
+	if msg := tuiMessage(); msg != "" {
+		b.WriteString(msg + "\r\n")
+	}
+
+	io.WriteString(w, b.String())
+}
+
+func tuiMessage() string {
+
+	if statusMessage != "" && time.Now().Before(statusMessageUntil) {
+		return statusMessage
+	}
+	return ""
+}
+
+// readTUIKey returns the next key, arrow keys being mapped to their
+// direction letters
+func readTUIKey(r *bufio.Reader) (byte, error) {
+
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	if c == 0x1b && r.Buffered() >= 2 {
+		if next, _ := r.ReadByte(); next == '[' || next == 'O' {
+			arrow, _ := r.ReadByte()
+			switch arrow {
+			case 'A':
+				return 'w', nil
+			case 'B':
+				return 's', nil
+			case 'C':
+				return 'd', nil
+			case 'D':
+				return 'a', nil
+			}
+		}
+		return 0, nil
+	}
+
+	return c, nil
+}
+
+// printSitting writes the session summary when quitting
+func printSitting(w io.Writer) {
+
+	if sittingMoves == 0 {
+		return
+	}
+	t := endSitting()
+	io.WriteString(w, "\r\nThis session\r\n\r\n"+strings.ReplaceAll(t.String(), "\n", "\r\n")+"\r\n")
+}
+
+func runTUI() error {
+
+	if restore, err := setRawTerminal(); err == nil {
+		defer restore()
+	}
+
+	s := newGameState(levelAt(0))
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { recordCompletion(e.State) })
+	s.events.subscribe(LevelCompleted, func(e GameEvent) { recordReplay(e.State) })
+	s.events.subscribe(DeadlockDetected, func(e GameEvent) { showMessage("that box can no longer reach a goal") })
+	s.events.subscribe(MovePerformed, sittingMoved)
+	s.events.subscribe(MoveUndone, sittingUndone)
+	s.events.subscribe(LevelCompleted, sittingLevelSolved)
+	defer printSitting(os.Stdout)
+	in := bufio.NewReader(os.Stdin)
+
+	for {
+		drawTUI(os.Stdout, s)
+
+		key, err := readTUIKey(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if confirmAction != nil {
+			if key == 'y' || key == '\r' {
+				confirmAction()
+			}
+			confirmAction = nil
+			showMessage("")
+			continue
+		}
+
+		switch key {
+		case 'w', 'k':
+			s.playMove(UP)
+		case 's', 'j':
+			s.playMove(DOWN)
+		case 'a', 'h':
+			s.playMove(LEFT)
+		case 'd', 'l':
+			s.playMove(RIGHT)
+		case 'u', 0x7f, 0x08:
+			s.undoMove()
+		case 'U':
+			s.undoPush()
+		case 'r':
+			confirmDiscard(s, "Restart the level?", func() { s.loadLevel(s.currentLevelNumber) })
+			if confirmAction != nil {
+				showMessage(confirmPrompt + " y/n")
+			}
+		case 'n':
+			s.switchLevel(stepLevel(s.currentLevelNumber, 1))
+		case 'p':
+			s.switchLevel(stepLevel(s.currentLevelNumber, -1))
+		case 'q', 0x03, 0x04:
+			io.WriteString(os.Stdout, "\r\n")
+			return nil
+		}
+
+		if s.nBoxesLeft() == 0 && stayOnSolved {
+			showMessage(fmt.Sprintf("Level %d solved in %d moves, n for the next level", s.currentLevelNumber, s.moveCount()))
+		} else if s.nBoxesLeft() == 0 {
+			showMessage(fmt.Sprintf("Level %d solved in %d moves", s.currentLevelNumber, s.moveCount()))
+			s.switchLevel(stepLevel(s.currentLevelNumber, 1))
+		}
+	}
+}
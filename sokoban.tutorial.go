@@ -0,0 +1,154 @@
+// Sokoban game
+//
+// Solution tutorial: Enter while a solution of the solver plays back
+// stops it and steps through the rest one push at a time, the pushes of a
+// box in a line making one step, each explained ("walk to the left of the
+// box on c4, push it right twice"). Right, Space or a click play the next
+// step, Left or Backspace take the last one back and Esc leaves the
+// tutorial where it stands. The directions are those of the board as shown.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// a step of the tutorial: the walk to a box and its pushes in a line
+type tutorialStep struct {
+	moves      []byte
+	text       string
+	boxX, boxY int // where the box stands before the step
+}
+
+var (
+	tutorial    []tutorialStep // nil when the tutorial is not open
+	tutorialPos = 0            // steps played
+)
+
+// the side of a box the player pushes it from, by push direction
+var pushSides = map[byte]string{UP: "below", DOWN: "above", LEFT: "to the right of", RIGHT: "to the left of"}
+
+func timesName(n int) string {
+
+	switch n {
+	case 1:
+		return "once"
+	case 2:
+		return "twice"
+	}
+	return fmt.Sprintf("%d times", n)
+}
+
+// tutorialSteps splits moves, played from the position of l, into steps
+// explained as seen in view v
+func tutorialSteps(l Level, moves []byte, v int) []tutorialStep {
+
+	var steps []tutorialStep
+	c := l.clone()
+	start := 0
+
+	for i := 0; i < len(moves); {
+		dx, dy := dirDelta(moves[i])
+		bx, by := c.px+dx, c.py+dy
+		if t := c.grid[bx][by]; t != BOX && t != PLACED_BOX {
+			c.play(moves[i])
+			i++
+			continue
+		}
+
+		walked := i > start
+		n := 0
+		for ; i < len(moves) && moves[i] == moves[i-n]; i++ {
+			if _, pushed := c.play(moves[i]); !pushed {
+				c.unplay(moveRecord{moves[i], false, EMPTY, false})
+				break
+			}
+			n++
+		}
+
+		d := viewDir(moves[i-1], v)
+		var text strings.Builder
+		if walked {
+			fmt.Fprintf(&text, "walk %s the box on %s, then push it %s %s", pushSides[d], cellName(bx, by), directionName(d), timesName(n))
+		} else {
+			fmt.Fprintf(&text, "push the box on %s %s %s", cellName(bx, by), directionName(d), timesName(n))
+		}
+		if fx, fy := c.px+dx, c.py+dy; c.grid[fx][fy] == PLACED_BOX {
+			text.WriteString(", onto a goal")
+		}
+
+		steps = append(steps, tutorialStep{moves[start:i], text.String(), bx, by})
+		start = i
+	}
+
+	// moves after the last push, if any, end the last step
+	if start < len(moves) && len(steps) > 0 {
+		last := &steps[len(steps)-1]
+		last.moves = moves[start-len(last.moves) : len(moves)]
+	}
+	return steps
+}
+
+// startTutorial steps through moves from the current position
+func startTutorial(s *GameState, moves []byte) {
+
+	tutorial = tutorialSteps(s.curLev, moves, camera.view)
+	tutorialPos = 0
+	if len(tutorial) == 0 {
+		tutorial = nil
+	}
+}
+
+func tutorialLevelStarted(e GameEvent) {
+
+	tutorial = nil
+}
+
+// updateTutorial returns true while the tutorial takes the input
+func updateTutorial(s *GameState, in InputSource, mouseOrTouch bool) bool {
+
+	if tutorial == nil {
+		return false
+	}
+
+	switch {
+	case in.IsKeyJustPressed(ebiten.KeyEscape):
+		tutorial = nil
+	case (in.IsKeyJustPressed(ebiten.KeyArrowRight) || in.IsKeyJustPressed(ebiten.KeySpace) || mouseOrTouch) && tutorialPos < len(tutorial):
+		for _, d := range tutorial[tutorialPos].moves {
+			s.playMove(d)
+		}
+		tutorialPos++
+	case (in.IsKeyJustPressed(ebiten.KeyArrowLeft) || in.IsKeyJustPressed(ebiten.KeyBackspace)) && tutorialPos > 0:
+		tutorialPos--
+		for range tutorial[tutorialPos].moves {
+			s.undoMove()
+		}
+	}
+	return true
+}
+
+func drawTutorial(screen *ebiten.Image, s *GameState) {
+
+	if tutorial == nil {
+		return
+	}
+
+	msg := "Solved! Left: step back, Esc: leave the tutorial"
+	if tutorialPos < len(tutorial) {
+		step := tutorial[tutorialPos]
+		msg = fmt.Sprintf("Step %d/%d: %s\nRight, Space or click: play it, Left: step back, Esc: leave the tutorial",
+			tutorialPos+1, len(tutorial), step.text)
+
+		sx, sy, tile := cellOnScreen(s, step.boxX, step.boxY)
+		ebitenutil.DrawRect(screen, sx, sy, tile, tile, color.RGBA{120, 100, 0, 120})
+	}
+
+	ebitenutil.DrawRect(screen, screenWidth/2-320, screenHeight-110, 640, 44, color.RGBA{0, 0, 0, 200})
+	drawText(screen, msg, screenWidth/2-310, screenHeight-104)
+}
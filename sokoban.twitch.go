@@ -0,0 +1,230 @@
+// Sokoban game
+//
+// Crowd play: with -twitch channel the game joins the channel's chat and
+// lets viewers vote. Chat messages "up", "down", "left", "right" and "undo"
+// count as votes, one per viewer per voting window; when the window closes
+// the winning command is played through the remote control queue.
+//
+// Without -twitch-token the chat is read anonymously, which is all voting
+// needs. With a token the result of each vote is also posted in the chat.
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const twitchServer = "irc.chat.twitch.tv:6697"
+
+var twitchDial = func() (net.Conn, error) {
+	return tls.Dial("tcp", twitchServer, nil)
+}
+
+type twitchVotes struct {
+	lock   sync.Mutex
+	voters map[string]bool
+	counts map[string]int
+	order  []string // commands in the order they got their first vote
+}
+
+// twitchCommands maps chat words, including the LURD letters, to the
+// command they vote for
+var twitchCommands = map[string]string{
+	"up": "up", "u": "up",
+	"down": "down", "d": "down",
+	"left": "left", "l": "left",
+	"right": "right", "r": "right",
+	"undo": "undo", "back": "undo",
+}
+
+func (v *twitchVotes) add(user, text string) {
+
+	cmd, ok := twitchCommands[strings.ToLower(strings.TrimSpace(text))]
+	if !ok {
+		return
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.voters[user] {
+		return
+	}
+	v.voters[user] = true
+
+	if v.counts[cmd] == 0 {
+		v.order = append(v.order, cmd)
+	}
+	v.counts[cmd]++
+}
+
+// close returns the winning command and its votes and starts a new window;
+// ties go to the command voted for first
+func (v *twitchVotes) close() (string, int) {
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	winner, best := "", 0
+	for _, cmd := range v.order {
+		if v.counts[cmd] > best {
+			winner, best = cmd, v.counts[cmd]
+		}
+	}
+
+	v.voters = make(map[string]bool)
+	v.counts = make(map[string]int)
+	v.order = nil
+
+	return winner, best
+}
+
+// startTwitch joins channel and plays the winner of each voting window
+func startTwitch(channel, nick, token string, window time.Duration) {
+
+	channel = strings.ToLower(strings.TrimPrefix(channel, "#"))
+	if token == "" {
+		// anonymous, read only login
+		nick = "justinfan12345"
+	}
+
+	votes := &twitchVotes{}
+	votes.close()
+
+	say := make(chan string, 4)
+
+	go func() {
+		for range time.Tick(window) {
+			cmd, n := votes.close()
+			if cmd == "" {
+				continue
+			}
+
+			if cmd == "undo" {
+				remoteCommands <- remoteCommand{Cmd: "undo"}
+			} else {
+				remoteCommands <- remoteCommand{Cmd: "move", Dir: cmd}
+			}
+
+			if token != "" {
+				select {
+				case say <- fmt.Sprintf("%s wins with %d vote(s)", cmd, n):
+				default:
+				}
+			}
+		}
+	}()
+
+	go func() {
+		delay := time.Second
+		for {
+			err := runTwitchConnection(channel, nick, token, votes, say)
+			log.Printf("twitch: %v, reconnecting in %s", err, delay)
+			time.Sleep(delay)
+			if delay < time.Minute {
+				delay *= 2
+			}
+		}
+	}()
+
+	log.Printf("twitch: voting in #%s every %s", channel, window)
+}
+
+func runTwitchConnection(channel, nick, token string, votes *twitchVotes, say chan string) error {
+
+	conn, err := twitchDial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var writeLock sync.Mutex
+	send := func(line string) error {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		_, err := fmt.Fprintf(conn, "%s\r\n", line)
+		return err
+	}
+
+	if token != "" {
+		if !strings.HasPrefix(token, "oauth:") {
+			token = "oauth:" + token
+		}
+		send("PASS " + token)
+	}
+	send("NICK " + strings.ToLower(nick))
+	if err := send("JOIN #" + channel); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case msg := <-say:
+				send("PRIVMSG #" + channel + " :" + msg)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		// twitch pings every five minutes or so
+		conn.SetReadDeadline(time.Now().Add(10 * time.Minute))
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.HasPrefix(line, "PING") {
+			send("PONG" + strings.TrimPrefix(line, "PING"))
+			continue
+		}
+
+		if user, text, ok := parseTwitchMessage(line); ok {
+			votes.add(user, text)
+		}
+	}
+}
+
+// parseTwitchMessage returns the sender and text of a chat line such as
+// ":nick!nick@nick.tmi.twitch.tv PRIVMSG #channel :up"
+func parseTwitchMessage(line string) (string, string, bool) {
+
+	if strings.HasPrefix(line, "@") {
+		// message tags
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			line = line[i+1:]
+		}
+	}
+
+	if !strings.HasPrefix(line, ":") {
+		return "", "", false
+	}
+
+	prefix, rest, ok := strings.Cut(line[1:], " ")
+	if !ok || !strings.HasPrefix(rest, "PRIVMSG ") {
+		return "", "", false
+	}
+
+	_, text, ok := strings.Cut(rest, " :")
+	if !ok {
+		return "", "", false
+	}
+
+	user, _, _ := strings.Cut(prefix, "!")
+	return user, text, true
+}
@@ -0,0 +1,36 @@
+// Sokoban game
+//
+// Undo limit: with -undo-limit n only the last n moves can be undone. The
+// older moves are forgotten, only their number is kept, so a long session
+// keeps a bounded history.
+//
+// A game that lost its first moves cannot be quick saved or kept as a
+// replay, both store the moves from the start of the level.
+
+package main
+
+var undoLimit = 0 // 0 for an unlimited history
+
+// moveCount returns the number of moves played since the level started
+func (s *GameState) moveCount() int {
+	return s.baseMoves + len(s.moves)
+}
+
+// historyComplete tells if the moves of the whole game are still known
+func (s *GameState) historyComplete() bool {
+	return s.baseMoves == 0
+}
+
+// compactHistory drops the moves beyond the undo limit
+func (s *GameState) compactHistory() {
+
+	extra := len(s.moves) - undoLimit
+	if undoLimit <= 0 || extra <= 0 {
+		return
+	}
+
+	s.baseMoves += extra
+
+	// appending copies the stack away from the dropped moves in time
+	s.moves = s.moves[extra:]
+}
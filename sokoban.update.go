@@ -0,0 +1,136 @@
+// Sokoban game
+//
+// Update check: when enabled in the settings or with -check-updates, the
+// latest GitHub release is looked up in the background at startup and a
+// notice with its link is shown for a while when it is newer than this
+// build. Release builds set their version with
+//
+//	go build -ldflags "-X main.gameVersion=v1.2.0"
+//
+// development builds never check.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	latestReleaseURL = "https://api.github.com/repos/elzibus/Go-sokoban/releases/latest"
+	updateNoticeTime = 20 * time.Second
+)
+
+var (
+	gameVersion  = "dev"
+	checkUpdates = false
+
+	updateLock     sync.Mutex
+	updateNotice   string
+	updateNoticeAt time.Time
+)
+
+// parseVersion returns the numbers of a tag like v1.2.3
+func parseVersion(tag string) ([]int, bool) {
+
+	parts := strings.Split(strings.TrimPrefix(tag, "v"), ".")
+	numbers := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
+
+// newerVersion tells whether tag is a later version than current
+func newerVersion(tag, current string) bool {
+
+	a, ok1 := parseVersion(tag)
+	b, ok2 := parseVersion(current)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	for i := 0; i < len(a) || i < len(b); i++ {
+		x, y := 0, 0
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// checkForUpdate looks up the latest release without blocking the game
+func checkForUpdate() {
+
+	if _, ok := parseVersion(gameVersion); !ok {
+		return
+	}
+
+	go func() {
+		client := http.Client{Timeout: 10 * time.Second}
+
+		req, err := http.NewRequest("GET", latestReleaseURL, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Printf("update check failed: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		var release struct {
+			Tag string `json:"tag_name"`
+			URL string `json:"html_url"`
+		}
+		if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&release) != nil {
+			log.Printf("update check failed: %s", resp.Status)
+			return
+		}
+
+		if newerVersion(release.Tag, gameVersion) {
+			updateLock.Lock()
+			updateNotice = fmt.Sprintf("Sokoban %s is available (you have %s): %s", release.Tag, gameVersion, release.URL)
+			updateNoticeAt = time.Now()
+			updateLock.Unlock()
+		}
+	}()
+}
+
+func drawUpdateNotice(screen *ebiten.Image) {
+
+	updateLock.Lock()
+	msg, at := updateNotice, updateNoticeAt
+	updateLock.Unlock()
+
+	if msg == "" || time.Since(at) > updateNoticeTime {
+		return
+	}
+
+	w := float64(len(msg)*6 + 20)
+	ebitenutil.DrawRect(screen, 10, screenHeight-40, w, 30, color.RGBA{20, 20, 30, 220})
+	drawText(screen, msg, 20, screenHeight-32)
+}
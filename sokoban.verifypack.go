@@ -0,0 +1,171 @@
+// Sokoban game
+//
+// Level pack verification: run with -verifypack
+//
+// Decompresses every embedded level and checks that it makes sense: one
+// player standing on the floor, as many boxes as goals, and no way to walk
+// off the board. With -verify-budget each level is also given to the solver
+// for at most that long, a search that runs out of positions proving the
+// level cannot be solved. With -verify-fuzz the level parser is also fed
+// that many random and damaged levels, which it must reject or accept
+// without crashing.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+func runVerifyPack(budget time.Duration, fuzz int) bool {
+
+	ok := true
+
+	for n := range levels {
+
+		problems := checkLevelData(levels[n])
+
+		status := "ok"
+		if len(problems) > 0 {
+			status = "INVALID"
+			ok = false
+		} else if budget > 0 {
+			status = verifySolvable(levelTemplate(n), budget)
+			if status == "UNSOLVABLE" {
+				ok = false
+			}
+		}
+
+		fmt.Printf("level %2d: %s\n", n, status)
+		for _, p := range problems {
+			fmt.Printf("    %s\n", p)
+		}
+	}
+
+	if len(levels) != LEVEL_MAX+1 {
+		fmt.Printf("%d levels embedded but LEVEL_MAX is %d\n", len(levels), LEVEL_MAX)
+		ok = false
+	}
+
+	if fuzz > 0 && !fuzzLevelParser(fuzz, time.Now().UnixNano()) {
+		ok = false
+	}
+
+	return ok
+}
+
+// fuzzLevelParser decompresses n inputs made from the embedded levels
+// (truncated, with flipped bits or a changed size) or of random bytes,
+// reporting the inputs that crash decompressLevel
+func fuzzLevelParser(n int, seed int64) bool {
+
+	rng := rand.New(rand.NewSource(seed))
+	crashes, rejected := 0, 0
+
+	for i := 0; i < n; i++ {
+
+		var data []byte
+		if i%4 == 3 {
+			data = make([]byte, rng.Intn(64))
+			rng.Read(data)
+		} else {
+			data = append([]byte(nil), levels[rng.Intn(len(levels))]...)
+			switch i % 4 {
+			case 0:
+				data = data[:rng.Intn(len(data)+1)]
+			case 1:
+				for k := rng.Intn(4); k >= 0; k-- {
+					data[rng.Intn(len(data))] ^= 1 << rng.Intn(8)
+				}
+			case 2:
+				data[rng.Intn(2)] = byte(rng.Intn(256))
+			}
+		}
+
+		crash, err := tryDecompressLevel(data)
+		if crash != nil {
+			crashes++
+			fmt.Printf("parser crash on %x: %v\n", data, crash)
+		} else if err != nil {
+			rejected++
+		}
+	}
+
+	fmt.Printf("parser fuzz: %d inputs (seed %d), %d rejected, %d crashes\n", n, seed, rejected, crashes)
+
+	return crashes == 0
+}
+
+// tryDecompressLevel decompresses data, returning the panic it caused if any
+func tryDecompressLevel(data []byte) (crash interface{}, err error) {
+
+	defer func() { crash = recover() }()
+
+	_, err = decompressLevel(data)
+	return nil, err
+}
+
+// checkLevelData returns what is wrong with a compressed level
+func checkLevelData(data []byte) (problems []string) {
+
+	l, err := decompressLevel(data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	w, h := l.w, l.h
+
+	if w < 3 || h < 3 {
+		problems = append(problems, fmt.Sprintf("too small: %dx%d", w, h))
+	}
+
+	if t := l.grid[l.px][l.py]; t != EMPTY && t != GOAL {
+		problems = append(problems, fmt.Sprintf("player not on the floor at %d,%d", l.px, l.py))
+	}
+
+	boxes, goals := 0, 0
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			switch l.grid[x][y] {
+			case BOX:
+				boxes++
+			case GOAL:
+				goals++
+			case PLACED_BOX:
+				boxes++
+				goals++
+			}
+		}
+	}
+
+	if boxes == 0 {
+		problems = append(problems, "no boxes")
+	}
+	if boxes != goals {
+		problems = append(problems, fmt.Sprintf("%d boxes for %d goals", boxes, goals))
+	}
+
+	// the player must be enclosed by walls
+	if x, y, open := l.walkOff(); open {
+		problems = append(problems, fmt.Sprintf("the player can walk off the board at %d,%d", x, y))
+	}
+
+	return problems
+}
+
+func verifySolvable(l Level, budget time.Duration) string {
+
+	progress := &solverProgress{}
+	timer := time.AfterFunc(budget, progress.cancel)
+	defer timer.Stop()
+
+	res := solveLevel(l, optimizePushes, 0, progress)
+
+	switch {
+	case res.solved:
+		return fmt.Sprintf("ok, solved in %d pushes", res.pushes)
+	case progress.isCancelled():
+		return fmt.Sprintf("ok, not solved within %s", budget)
+	}
+	return "UNSOLVABLE"
+}
@@ -0,0 +1,30 @@
+// Sokoban game
+//
+// The level parser fuzzing of -verifypack, under go test -fuzz
+
+package main
+
+import "testing"
+
+func FuzzDecompressLevel(f *testing.F) {
+
+	for _, data := range levels {
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		l, err := decompressLevel(data)
+		if err != nil {
+			return
+		}
+		if len(l.grid) != l.w {
+			t.Errorf("%d columns for a width of %d", len(l.grid), l.w)
+		}
+		for x := range l.grid {
+			if len(l.grid[x]) != l.h {
+				t.Errorf("column %d: %d cells for a height of %d", x, len(l.grid[x]), l.h)
+			}
+		}
+	})
+}
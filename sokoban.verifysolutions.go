@@ -0,0 +1,257 @@
+// Sokoban game
+//
+// Solution verification: run with -verify-solutions sols.lurd, and -pack
+// with a level file or the name of a pack of the levels directory (the
+// built-in levels otherwise), to check a whole archive of solutions
+// without a window and print a report, one line per solution and a
+// summary; the exit status is 1 when a solution fails.
+//
+// The solutions file holds LURD solutions, which may run over several
+// lines and are separated by blank lines. A line before a solution that is
+// not LURD names its level, by number as the game shows it ("12", "Level
+// 12:") or by title, a solution without one going to the level after that
+// of the previous solution. Lines starting with ; are comments, and other
+// "Name: value" lines are skipped. When a solution marks its pushes in
+// uppercase, the marks are checked too. A level that cannot be played, one
+// the player can walk out of for instance, fails its solutions.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// a level solutions are checked against, err telling why it cannot be
+// played
+type verifyLevel struct {
+	title string
+	level Level
+	err   error
+}
+
+// a solution of the file, for level, found on line
+type verifySolution struct {
+	line  int
+	level int
+	lurd  string
+}
+
+// verifyLevels returns the levels of pack, a file or the name of a pack of
+// the levels directory, or the built-in levels when pack is ""
+func verifyLevels(pack string) ([]verifyLevel, error) {
+
+	if pack == "" {
+		list := make([]verifyLevel, LEVEL_MAX+1)
+		for n := range list {
+			list[n] = verifyLevel{"", embeddedLevel(n), nil}
+		}
+		return list, nil
+	}
+
+	file := pack
+	if _, err := os.Stat(file); err != nil {
+		dir, derr := userFilePath(packsDir)
+		if derr != nil {
+			return nil, err
+		}
+		for _, ext := range []string{"", ".xsb", ".sok", ".txt"} {
+			if _, serr := os.Stat(filepath.Join(dir, pack+ext)); serr == nil {
+				file, err = filepath.Join(dir, pack+ext), nil
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("no level file %s, nor pack %s in %s", pack, pack, dir)
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	xls, err := readXSBCollection(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if len(xls) == 0 {
+		return nil, fmt.Errorf("%s: no levels found", file)
+	}
+
+	list := make([]verifyLevel, len(xls))
+	for i, xl := range xls {
+		l, err := xsbToLevel(xl.rows)
+		if err != nil {
+			err = fmt.Errorf("line %d of %s: %v", xl.line, filepath.Base(file), err)
+		}
+		list[i] = verifyLevel{xl.title, l, err}
+	}
+	return list, nil
+}
+
+func isLURDLine(line string) bool {
+
+	return strings.Trim(line, "lurdLURD \t") == ""
+}
+
+// findVerifyLevel returns the level a header line names
+func findVerifyLevel(levels []verifyLevel, header string) (int, bool) {
+
+	name := strings.TrimSuffix(strings.TrimSpace(header), ":")
+	number := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(name), "level"))
+	if n, err := strconv.Atoi(number); err == nil && n >= 0 && n < len(levels) {
+		return n, true
+	}
+
+	if strings.HasPrefix(strings.ToLower(name), "title:") {
+		name = strings.TrimSpace(name[len("title:"):])
+	}
+	for n, l := range levels {
+		if l.title != "" && strings.EqualFold(l.title, name) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// readSolutions reads the solutions of r for levels
+func readSolutions(r io.Reader, levels []verifyLevel) ([]verifySolution, error) {
+
+	var (
+		list []verifySolution
+		next = 0 // level of the next solution, unless a header names one
+		cur  *verifySolution
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+			cur = nil
+
+		case isLURDLine(line):
+			if cur == nil {
+				list = append(list, verifySolution{line: n, level: next})
+				cur = &list[len(list)-1]
+				next = cur.level + 1
+			}
+			cur.lurd += line
+
+		default:
+			cur = nil
+			level, ok := findVerifyLevel(levels, line)
+			lower := strings.ToLower(line)
+			switch {
+			case ok:
+				next = level
+			case strings.Contains(line, ":") && !strings.HasPrefix(lower, "title:") && !strings.HasPrefix(lower, "level"):
+				// other facts about the solution, "Author: ..."
+			default:
+				return nil, fmt.Errorf("line %d: no level %q", n, line)
+			}
+		}
+	}
+	return list, scanner.Err()
+}
+
+// checkSolution replays lurd on l and tells how it went
+func checkSolution(l Level, lurd string) (string, bool) {
+
+	if x, y, open := l.walkOff(); open {
+		return fmt.Sprintf("the level is open, the player can walk off the board at %d,%d", x, y), false
+	}
+
+	moves, err := lurdToMoves(lurd)
+	if err != nil {
+		return err.Error(), false
+	}
+	marks := strings.ToLower(lurd) != lurd
+	letters := strings.Join(strings.Fields(lurd), "")
+
+	c := l.clone()
+	pushes := 0
+	for i, d := range moves {
+		moved, pushed := c.play(d)
+		if !moved {
+			return fmt.Sprintf("move %d (%c) is blocked", i+1, letters[i]), false
+		}
+		if pushed {
+			pushes++
+		}
+		if upper := letters[i] < 'a'; marks && upper != pushed {
+			if pushed {
+				return fmt.Sprintf("move %d (%c) pushes a box but is written as a walk", i+1, letters[i]), false
+			}
+			return fmt.Sprintf("move %d (%c) is written as a push but pushes nothing", i+1, letters[i]), false
+		}
+		if c.boxesLeft == 0 && i < len(moves)-1 {
+			return fmt.Sprintf("solved at move %d, %d moves more are given", i+1, len(moves)-i-1), false
+		}
+	}
+
+	if c.boxesLeft > 0 {
+		return fmt.Sprintf("not solved, %d boxes left after %d moves", c.boxesLeft, len(moves)), false
+	}
+	return fmt.Sprintf("ok, %d moves, %d pushes", len(moves), pushes), true
+}
+
+// runVerifySolutions checks the solutions of file against the levels of
+// pack and tells if they all solve their level
+func runVerifySolutions(file string, pack string) (bool, error) {
+
+	levels, err := verifyLevels(pack)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	solutions, err := readSolutions(f, levels)
+	f.Close()
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", file, err)
+	}
+
+	failed := 0
+	solved := make(map[int]bool)
+	for _, s := range solutions {
+		name := fmt.Sprintf("level %d", s.level)
+		if s.level >= len(levels) {
+			fmt.Printf("line %d: %s: no such level\n", s.line, name)
+			failed++
+			continue
+		}
+		if t := levels[s.level].title; t != "" {
+			name += " (" + t + ")"
+		}
+
+		report, ok := "", false
+		if err := levels[s.level].err; err != nil {
+			report = "the level cannot be played: " + err.Error()
+		} else {
+			report, ok = checkSolution(levels[s.level].level, s.lurd)
+		}
+		if ok {
+			solved[s.level] = true
+		} else {
+			failed++
+			report = "FAILED: " + report
+		}
+		fmt.Printf("line %d: %s: %s\n", s.line, name, report)
+	}
+
+	fmt.Printf("\n%d solutions, %d ok, %d failed; %d of %d levels solved\n",
+		len(solutions), len(solutions)-failed, failed, len(solved), len(levels))
+	return failed == 0, nil
+}
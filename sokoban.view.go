@@ -0,0 +1,151 @@
+// Sokoban game
+//
+// View transforms: V turns the board a quarter turn clockwise and Shift+V
+// mirrors it, for players who find a level easier to read another way
+// round. Only the drawing changes: the arrows move the player the way they
+// point on the screen, while the moves, replays and solutions stay in the
+// level's own orientation. The view of each level is kept with its
+// progress.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// a view is a number of quarter turns, t%4, mirrored when t >= 4
+
+var viewNames = []string{"as designed", "turned right", "upside down", "turned left"}
+
+// levelView returns the view of level n
+func levelView(n int) int {
+
+	if p, ok := levelsProgress()[n]; ok {
+		return p.View
+	}
+	return 0
+}
+
+// viewSize returns the width and height of l as shown with view v
+func viewSize(l Level, v int) (int, int) {
+
+	if v%2 == 1 {
+		return l.h, l.w
+	}
+	return l.w, l.h
+}
+
+// cellToView returns where cell x, y of l is shown with view v
+func cellToView(l Level, v int, x int, y int) (int, int) {
+
+	w, h := l.w, l.h
+	for r := 0; r < v%4; r++ {
+		x, y = h-1-y, x
+		w, h = h, w
+	}
+	if v >= 4 {
+		x = w - 1 - x
+	}
+	return x, y
+}
+
+// viewToCell returns the cell of l shown at x, y with view v
+func viewToCell(l Level, v int, x int, y int) (int, int) {
+
+	w, h := viewSize(l, v)
+	if v >= 4 {
+		x = w - 1 - x
+	}
+	for r := 0; r < v%4; r++ {
+		// before that quarter turn the board was h wide and w high
+		x, y = y, w-1-x
+		w, h = h, w
+	}
+	return x, y
+}
+
+// turnDir returns d turned clockwise by quarter turns
+func turnDir(d byte, turns int) byte {
+
+	for i, dir := range directions {
+		if dir == d {
+			return directions[(i+turns)%4]
+		}
+	}
+	return d
+}
+
+func mirrorDir(d byte) byte {
+
+	switch d {
+	case LEFT:
+		return RIGHT
+	case RIGHT:
+		return LEFT
+	}
+	return d
+}
+
+// viewDir returns the direction on the screen of direction d of the level
+func viewDir(d byte, v int) byte {
+
+	d = turnDir(d, v%4)
+	if v >= 4 {
+		d = mirrorDir(d)
+	}
+	return d
+}
+
+// levelDir returns the direction of the level shown as d on the screen
+func levelDir(d byte, v int) byte {
+
+	if v >= 4 {
+		d = mirrorDir(d)
+	}
+	return turnDir(d, 4-v%4)
+}
+
+// viewSprite returns how player sprite p is shown with view v
+func viewSprite(p byte, v int) byte {
+
+	d := UP
+	switch p {
+	case PLAYERRI:
+		d = RIGHT
+	case PLAYERLE:
+		d = LEFT
+	case PLAYERDN:
+		d = DOWN
+	}
+	return playerSprite(viewDir(d, v))
+}
+
+// updateView turns the board on V and mirrors it on Shift+V
+func updateView(s *GameState, in InputSource) {
+
+	if !in.IsKeyJustPressed(ebiten.KeyV) {
+		return
+	}
+
+	v := levelView(s.currentLevelNumber)
+	turns, mirrored := v%4, v >= 4
+
+	if in.IsKeyPressed(ebiten.KeyShift) {
+		mirrored = !mirrored
+	} else {
+		turns = (turns + 1) % 4
+	}
+
+	v = turns
+	msg := viewNames[turns]
+	if mirrored {
+		v += 4
+		msg += ", mirrored"
+	}
+
+	levelProgressFor(s.currentLevelNumber).View = v
+	saveProgress()
+	showMessage(fmt.Sprintf("board %s", msg))
+}
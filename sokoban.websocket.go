@@ -0,0 +1,211 @@
+// Sokoban game
+//
+// Just enough of the WebSocket protocol (RFC 6455) for the remote control:
+// the opening handshake, reading masked client frames (answering pings) and
+// writing unfragmented server frames.
+//
+// Browsers let any web page open a WebSocket to localhost, so connections
+// from pages are only accepted from local origins, or from the origins
+// given with -allow-origin; clients which are not browsers send no origin.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	wsText  = 1
+	wsClose = 8
+	wsPing  = 9
+	wsPong  = 10
+
+	wsMaxMessage = 64 * 1024
+
+	wsProtocolError = 1002 // close status
+)
+
+// web page origins allowed besides the local ones, e.g. https://example.com
+var wsAllowedOrigins []string
+
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	writeLock sync.Mutex
+}
+
+// wsOriginAllowed tells if a page of origin may connect
+func wsOriginAllowed(origin string) bool {
+
+	if origin == "" {
+		return true
+	}
+	for _, o := range wsAllowedOrigins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "websocket connections only", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "websocket version 13 only", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+	if origin := r.Header.Get("Origin"); !wsOriginAllowed(origin) {
+		http.Error(w, "origin not allowed, see -allow-origin", http.StatusForbidden)
+		return nil, fmt.Errorf("websocket connection from %s refused", origin)
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "cannot upgrade", http.StatusInternalServerError)
+		return nil, errors.New("connection cannot be hijacked")
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// readMessage returns the next data message, io.EOF once the peer closed
+func (c *wsConn) readMessage() ([]byte, error) {
+
+	var message []byte
+
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(c.r, head[:]); err != nil {
+			return nil, err
+		}
+
+		fin := head[0]&0x80 != 0
+		op := head[0] & 0x0f
+		masked := head[1]&0x80 != 0
+		size := uint64(head[1] & 0x7f)
+
+		switch size {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return nil, err
+			}
+			size = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return nil, err
+			}
+			size = binary.BigEndian.Uint64(ext[:])
+		}
+
+		// checked alone first: a 64 bit size near the maximum would wrap
+		// around once added to the message
+		if size > wsMaxMessage || size+uint64(len(message)) > wsMaxMessage {
+			return nil, errors.New("websocket message too large")
+		}
+
+		// clients must mask their frames, the connection fails otherwise
+		if !masked {
+			c.writeMessage(wsClose, []byte{wsProtocolError >> 8, wsProtocolError & 0xff})
+			return nil, errors.New("unmasked websocket frame")
+		}
+		var mask [4]byte
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return nil, err
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(c.r, payload); err != nil {
+			return nil, err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch op {
+		case wsClose:
+			c.writeMessage(wsClose, nil)
+			return nil, io.EOF
+		case wsPing:
+			if err := c.writeMessage(wsPong, payload); err != nil {
+				return nil, err
+			}
+		case wsPong:
+		default:
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		}
+	}
+}
+
+func (c *wsConn) writeMessage(op byte, data []byte) error {
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	head := []byte{0x80 | op}
+
+	switch n := len(data); {
+	case n < 126:
+		head = append(head, byte(n))
+	case n < 1<<16:
+		head = append(head, 126, byte(n>>8), byte(n))
+	default:
+		head = append(head, 127, 0, 0, 0, 0, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+
+	if _, err := c.conn.Write(append(head, data...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
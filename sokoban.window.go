@@ -0,0 +1,74 @@
+// Sokoban game
+//
+// Window title, icon and modes
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type windowOptions struct {
+	Borderless bool `json:"borderless"` // no window decorations
+	Fullscreen bool `json:"fullscreen"` // borderless fullscreen
+	Floating   bool `json:"floating"`   // always on top of other windows
+}
+
+var (
+	windowTitle    string
+	windowSettings windowOptions
+)
+
+func applyWindowOptions() {
+
+	ebiten.SetWindowDecorated(!windowSettings.Borderless)
+	ebiten.SetWindowFloating(windowSettings.Floating)
+	ebiten.SetFullscreen(windowSettings.Fullscreen)
+}
+
+// updateWindowOptions toggles fullscreen with F11
+func updateWindowOptions(in InputSource) {
+
+	if in.IsKeyJustPressed(ebiten.KeyF11) {
+		windowSettings.Fullscreen = !windowSettings.Fullscreen
+		ebiten.SetFullscreen(windowSettings.Fullscreen)
+		saveSettings()
+	}
+}
+
+// setWindowIcon uses the player facing down from the tile sheet
+func setWindowIcon() {
+
+	img, err := png.Decode(bytes.NewReader(spritePNG))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := tileRect(PLAYERDN, baseTileSize)
+
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		ebiten.SetWindowIcon([]image.Image{sub.SubImage(r)})
+	}
+}
+
+// updateWindowTitle shows the level and the number of moves played
+func updateWindowTitle(s *GameState) {
+
+	title := fmt.Sprintf("Sokoban — Level %d — %d moves", s.currentLevelNumber, s.moveCount())
+	if s.moveCount() == 1 {
+		title = fmt.Sprintf("Sokoban — Level %d — 1 move", s.currentLevelNumber)
+	}
+
+	if title != windowTitle {
+		windowTitle = title
+		ebiten.SetWindowTitle(title)
+	}
+}
@@ -0,0 +1,198 @@
+// Sokoban game
+//
+// Levels as text, in the usual XSB notation:
+//
+//	#  wall        @  player
+//	   floor       +  player on a goal
+//	.  goal        $  box
+//	*  box on a goal
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// levelToXSB returns the rows of l
+func levelToXSB(l Level) []string {
+
+	rows := make([]string, l.h)
+
+	for y := 0; y < l.h; y++ {
+		row := make([]byte, l.w)
+		for x := 0; x < l.w; x++ {
+			switch l.grid[x][y] {
+			case WALL:
+				row[x] = '#'
+			case GOAL:
+				row[x] = '.'
+			case BOX:
+				row[x] = '$'
+			case PLACED_BOX:
+				row[x] = '*'
+			default:
+				row[x] = ' '
+			}
+			if x == l.px && y == l.py {
+				if row[x] == '.' {
+					row[x] = '+'
+				} else {
+					row[x] = '@'
+				}
+			}
+		}
+		rows[y] = string(row)
+	}
+
+	return rows
+}
+
+// walkOff returns a cell of the edge of l the player can walk to, when
+// the walls do not enclose it; boxes are walked through, as they may be
+// pushed away
+func (l Level) walkOff() (int, int, bool) {
+
+	seen := make([][]bool, l.w)
+	for x := range seen {
+		seen[x] = make([]bool, l.h)
+	}
+
+	stack := [][2]int{{l.px, l.py}}
+	seen[l.px][l.py] = true
+
+	for len(stack) > 0 {
+		x, y := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		if x == 0 || y == 0 || x == l.w-1 || y == l.h-1 {
+			return x, y, true
+		}
+
+		for _, d := range directions {
+			dx, dy := dirDelta(d)
+			nx, ny := x+dx, y+dy
+			if !seen[nx][ny] && l.grid[nx][ny] != WALL {
+				seen[nx][ny] = true
+				stack = append(stack, [2]int{nx, ny})
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// xsbToLevel builds a level from its rows; '-' and '_' are also read as
+// floor. The player must be enclosed by walls.
+func xsbToLevel(rows []string) (Level, error) {
+
+	var l Level
+
+	w := 0
+	for _, row := range rows {
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	if w == 0 || len(rows) == 0 {
+		return l, errors.New("empty level")
+	}
+
+	l.w, l.h = w, len(rows)
+	l.grid = make([][]byte, w)
+	for x := range l.grid {
+		l.grid[x] = make([]byte, len(rows))
+		for y := range l.grid[x] {
+			l.grid[x][y] = EMPTY
+		}
+	}
+
+	players := 0
+	for y, row := range rows {
+		for x, c := range []byte(row) {
+			switch c {
+			case '#':
+				l.grid[x][y] = WALL
+			case '.', '+':
+				l.grid[x][y] = GOAL
+			case '$':
+				l.grid[x][y] = BOX
+			case '*':
+				l.grid[x][y] = PLACED_BOX
+			case ' ', '-', '_', '@':
+			default:
+				return l, fmt.Errorf("row %d: unknown character %q", y+1, c)
+			}
+			if c == '@' || c == '+' {
+				l.px, l.py = x, y
+				players++
+			}
+		}
+	}
+	if players != 1 {
+		return l, fmt.Errorf("%d players, expected one", players)
+	}
+	if x, y, open := l.walkOff(); open {
+		return l, fmt.Errorf("row %d: the player can walk off the board at column %d", y+1, x+1)
+	}
+
+	l.placeOnScreen()
+	l.psprite = PLAYERUP
+	l.countBoxes()
+
+	return l, nil
+}
+
+// xsbLevel is a level read from a collection file
+type xsbLevel struct {
+	title string
+	line  int // where its first row is in the file
+	rows  []string
+}
+
+// isXSBRow tells whether line is a row of a level rather than text
+func isXSBRow(line string) bool {
+
+	return strings.Contains(line, "#") && strings.Trim(line, "#@+$*. -_") == ""
+}
+
+// readXSBCollection reads the levels of a collection: rows of consecutive
+// board lines, titled by a "Title:" line after them or a "; comment" line
+// before them
+func readXSBCollection(r io.Reader) ([]xsbLevel, error) {
+
+	var (
+		levels  []xsbLevel
+		comment string
+		inLevel = false
+	)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if isXSBRow(line) {
+			if !inLevel {
+				levels = append(levels, xsbLevel{title: comment, line: n})
+				comment = ""
+				inLevel = true
+			}
+			last := &levels[len(levels)-1]
+			last.rows = append(last.rows, line)
+			continue
+		}
+		inLevel = false
+
+		text := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(strings.ToLower(text), "title:") && len(levels) > 0:
+			levels[len(levels)-1].title = strings.TrimSpace(text[len("title:"):])
+		case strings.HasPrefix(text, ";"):
+			comment = strings.TrimSpace(text[1:])
+		}
+	}
+
+	return levels, scanner.Err()
+}
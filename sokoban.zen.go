@@ -0,0 +1,162 @@
+// Sokoban game
+//
+// Zen mode (settings > gameplay), to play for relaxation: the level number,
+// the counters and the timers are hidden, soft chords loop in the
+// background, whose colours drift slowly, and the position is saved every
+// few seconds in zen.json. The next start in zen mode goes on from there.
+
+package main
+
+import (
+	"encoding/binary"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+const (
+	zenSaveFile    = "zen.json"
+	zenSaveEvery   = 15 * time.Second
+	zenColorPeriod = 90.0 // seconds for the background to go through its colours
+	zenChordLength = 12.0 // seconds, the next chord starting during the fade out
+	zenChordFade   = 4.0
+	zenVolume      = 0.06
+)
+
+// the chords of the ambient loop, in turn
+var zenChords = [][]float64{
+	{110.00, 164.81, 220.00, 277.18},
+	{98.00, 146.83, 196.00, 246.94},
+	{87.31, 130.81, 174.61, 220.00},
+	{98.00, 146.83, 196.00, 293.66},
+}
+
+var (
+	zenMode = false
+
+	zenPCM       = make([][]byte, len(zenChords)) // synthesized when first played
+	zenPlayer    *audio.Player
+	zenChord     = 0
+	zenNextChord time.Time
+
+	zenSavedAt time.Time
+	zenSaved   quickSave // the position last written
+)
+
+// zenChordPCM returns chord i as 16 bit stereo, fading in and out
+func zenChordPCM(i int) []byte {
+
+	if zenPCM[i] != nil {
+		return zenPCM[i]
+	}
+
+	n := int(zenChordLength * audioSampleRate)
+	fade := zenChordFade * audioSampleRate
+	pcm := make([]byte, 0, 4*n)
+	for j := 0; j < n; j++ {
+		t := float64(j) / audioSampleRate
+		env := math.Min(1, math.Min(float64(j), float64(n-j))/fade)
+		// a slow swell keeps the chord from sounding like an organ note
+		env *= 0.8 + 0.2*math.Sin(2*math.Pi*t/6)
+		v := 0.0
+		for _, f := range zenChords[i] {
+			v += math.Sin(2 * math.Pi * f * t)
+		}
+		v *= zenVolume * env / float64(len(zenChords[i]))
+		sample := uint16(int16(v * math.MaxInt16))
+		pcm = binary.LittleEndian.AppendUint16(pcm, sample)
+		pcm = binary.LittleEndian.AppendUint16(pcm, sample)
+	}
+	zenPCM[i] = pcm
+	return pcm
+}
+
+// zenTint returns how the background colours are scaled, drifting in zen
+// mode
+func zenTint() (float64, float64, float64) {
+
+	if !zenMode || reducedMotion {
+		return 0.55, 0.55, 0.55
+	}
+	a := 2 * math.Pi * float64(time.Now().UnixMilli()) / 1000 / zenColorPeriod
+	return 0.45 + 0.15*math.Sin(a), 0.45 + 0.15*math.Sin(a+2*math.Pi/3), 0.5 + 0.15*math.Sin(a+4*math.Pi/3)
+}
+
+// saveZen writes the position, when it changed since the last time
+func saveZen(s *GameState) {
+
+	zenSavedAt = time.Now()
+	if !s.historyComplete() || s.hasPulls() {
+		return
+	}
+
+	save := quickSave{s.currentLevelNumber, packID(), historyToLURD(s.moves), time.Now()}
+	if save.Level == zenSaved.Level && save.Pack == zenSaved.Pack && save.Moves == zenSaved.Moves {
+		return
+	}
+	if err := saveJSON(zenSaveFile, &save); err != nil {
+		log.Printf("cannot save the zen position: %v", err)
+		return
+	}
+	zenSaved = save
+}
+
+// restoreZen goes back to the position of the last zen session, returning
+// false when there is none
+func restoreZen(s *GameState) bool {
+
+	var save quickSave
+	if err := loadJSON(zenSaveFile, &save); err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("cannot read the zen position: %v", err)
+		}
+		return false
+	}
+
+	m, err := lurdToMoves(save.Moves)
+	if err != nil {
+		log.Printf("the zen position is damaged: %v", err)
+		return false
+	}
+	if !resumeRecent(s, recentPlay{Pack: save.Pack, Level: save.Level}) {
+		return false
+	}
+	// the moves only fit the level they were saved on
+	if s.currentLevelNumber == save.Level {
+		for _, d := range m {
+			s.playMove(d)
+		}
+	}
+	zenSaved = save
+	return true
+}
+
+func updateZen(s *GameState) {
+
+	if !zenMode {
+		if zenPlayer != nil {
+			zenPlayer.Pause()
+			zenPlayer = nil
+		}
+		return
+	}
+
+	if now := time.Now(); now.After(zenNextChord) {
+		if audioContext == nil {
+			audioContext = audio.NewContext(audioSampleRate)
+		}
+		zenPlayer = audioContext.NewPlayerFromBytes(zenChordPCM(zenChord))
+		zenPlayer.SetVolume(cueVolume)
+		zenPlayer.Play()
+		zenChord = (zenChord + 1) % len(zenChords)
+		zenNextChord = now.Add(time.Duration((zenChordLength - zenChordFade) * float64(time.Second)))
+	}
+
+	if ebiten.IsWindowBeingClosed() || time.Since(zenSavedAt) >= zenSaveEvery {
+		saveZen(s)
+	}
+}
@@ -0,0 +1,138 @@
+// Sokoban game
+//
+// Zobrist hashing: a position hashes to the xor of a random key for each
+// box and a key for the cell of the player, so that a push updates the hash
+// with a few xors instead of going over the board again. The keys are drawn
+// from a fixed seed, a hash being the same on every run.
+//
+// The solver keys its transposition table with it, the identity of a level
+// is the hash of its walls, goals, boxes and player (see levelIdentity),
+// and the moves played are checked for pushes bringing the boxes
+// back where they already were, which tells the player they went around in
+// a circle.
+
+package main
+
+import "fmt"
+
+// what a key stands for on its cell
+const (
+	zobristBox uint64 = iota + 1
+	zobristPlayer
+	zobristWall
+	zobristGoal
+)
+
+// zobristKey returns the key of piece on cell x, y, splitmix64 of the three
+func zobristKey(x int, y int, piece uint64) uint64 {
+
+	z := 0x9e3779b97f4a7c15 * (uint64(x)<<40 ^ uint64(y)<<20 ^ piece)
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// zobristBoxes hashes the boxes of l
+func zobristBoxes(l Level) uint64 {
+
+	var h uint64
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			if t := l.grid[x][y]; t == BOX || t == PLACED_BOX {
+				h ^= zobristKey(x, y, zobristBox)
+			}
+		}
+	}
+	return h
+}
+
+// zobristPosition hashes the boxes and the player of l
+func zobristPosition(l Level) uint64 {
+
+	return zobristBoxes(l) ^ zobristKey(l.px, l.py, zobristPlayer)
+}
+
+// zobristLevel hashes the whole of l, walls and goals included
+func zobristLevel(l Level) uint64 {
+
+	h := zobristPosition(l)
+	for x := 0; x < l.w; x++ {
+		for y := 0; y < l.h; y++ {
+			switch l.grid[x][y] {
+			case WALL:
+				h ^= zobristKey(x, y, zobristWall)
+			case GOAL, PLACED_BOX:
+				h ^= zobristKey(x, y, zobristGoal)
+			}
+		}
+	}
+	return h
+}
+
+// zobristPush updates h, the hash of some boxes, for the box on x, y going
+// one cell in direction d
+func zobristPush(h uint64, x int, y int, d byte) uint64 {
+
+	dx, dy := dirDelta(d)
+	return h ^ zobristKey(x, y, zobristBox) ^ zobristKey(x+dx, y+dy, zobristBox)
+}
+
+// the boxes after a push of the moves played
+type boxesSeen struct {
+	hash  uint64
+	moves int // moveCount after the push
+}
+
+var pushHistory []boxesSeen // oldest first, the level started or resumed first
+
+func historyLevelStarted(e GameEvent) {
+
+	pushHistory = []boxesSeen{{zobristBoxes(e.State.curLev), e.State.moveCount()}}
+}
+
+// historyMoved follows the boxes, telling when a push brings them back as
+// they were after an earlier one
+func historyMoved(e GameEvent) {
+
+	s := e.State
+	if !e.Moved || len(pushHistory) == 0 || len(s.moves) == 0 {
+		return
+	}
+	last := s.moves[len(s.moves)-1]
+	if !last.pushed && !last.pulled {
+		return
+	}
+
+	// the box went from the cell the player left, or followed it
+	dx, dy := dirDelta(last.dir)
+	h := zobristPush(pushHistory[len(pushHistory)-1].hash, s.curLev.px, s.curLev.py, last.dir)
+	if last.pulled {
+		h = zobristPush(pushHistory[len(pushHistory)-1].hash, s.curLev.px-2*dx, s.curLev.py-2*dy, last.dir)
+	}
+
+	if last.pushed && s.nBoxesLeft() > 0 {
+		for _, b := range pushHistory {
+			if b.hash != h {
+				continue
+			}
+			if b.moves == 0 {
+				showMessage("the boxes are back where the level starts them")
+			} else {
+				showMessage(fmt.Sprintf("the boxes are back as they were after move %d", b.moves))
+			}
+			break
+		}
+	}
+	pushHistory = append(pushHistory, boxesSeen{h, s.moveCount()})
+}
+
+func historyUndone(e GameEvent) {
+
+	n := e.State.moveCount()
+	for len(pushHistory) > 0 && pushHistory[len(pushHistory)-1].moves > n {
+		pushHistory = pushHistory[:len(pushHistory)-1]
+	}
+	if len(pushHistory) == 0 {
+		historyLevelStarted(e)
+	}
+}